@@ -4,6 +4,8 @@ go 1.18
 
 require (
 	github.com/ThreeDotsLabs/esja v0.0.0-20221208191400-8fbb493947e7
+	github.com/ThreeDotsLabs/pii v0.0.0-20230103125711-e0908da9a963
+	github.com/brianvoe/gofakeit/v6 v6.20.1
 	github.com/google/uuid v1.3.0
 	github.com/lib/pq v1.10.6
 	github.com/mattn/go-sqlite3 v1.14.16
@@ -11,10 +13,12 @@ require (
 )
 
 require (
-	github.com/ThreeDotsLabs/pii v0.0.0-20230103125711-e0908da9a963 // indirect
-	github.com/brianvoe/gofakeit/v6 v6.20.1 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/fxamacker/cbor/v2 v2.7.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/vmihailenco/msgpack/v5 v5.3.5 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
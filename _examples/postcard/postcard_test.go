@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/ThreeDotsLabs/esja"
+	"github.com/ThreeDotsLabs/esja/esjatest"
 
 	"postcard"
 )
@@ -89,3 +90,33 @@ func TestPostcard_Lifecycle(t *testing.T) {
 
 	assert.Equal(expectedEvents, events)
 }
+
+func TestPostcard_Send(t *testing.T) {
+	id := uuid.NewString()
+
+	esjatest.NewFixture[postcard.Postcard](t, id).
+		WhenNew(func() (*postcard.Postcard, error) {
+			return postcard.NewPostcard(id)
+		}).
+		Then(postcard.Created{ID: id})
+
+	esjatest.NewFixture[postcard.Postcard](t, id).
+		Given(
+			postcard.Created{ID: id},
+			postcard.Addressed{Sender: senderAddress, Addressee: addresseeAddress},
+		).
+		When(func(p *postcard.Postcard) error {
+			return p.Send()
+		}).
+		Then(postcard.Sent{})
+
+	esjatest.NewFixture[postcard.Postcard](t, id).
+		Given(
+			postcard.Created{ID: id},
+			postcard.Sent{},
+		).
+		When(func(p *postcard.Postcard) error {
+			return p.Send()
+		}).
+		ThenAnyError()
+}
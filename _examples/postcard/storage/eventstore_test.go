@@ -1,11 +1,18 @@
 package storage_test
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
 	_ "github.com/lib/pq"
@@ -13,7 +20,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ThreeDotsLabs/esja"
 	"github.com/ThreeDotsLabs/esja/eventstore"
+	"github.com/ThreeDotsLabs/esja/eventstore/eventstoretest"
+	"github.com/ThreeDotsLabs/esja/telemetry"
+	"github.com/ThreeDotsLabs/esja/transport"
 
 	"postcard"
 	"postcard/storage"
@@ -44,6 +55,22 @@ func TestPostcard_Repositories(t *testing.T) {
 			name:       "in_memory",
 			repository: eventstore.NewInMemoryStore[postcard.Postcard](),
 		},
+		{
+			name: "in_memory_simple_serializing",
+			repository: func() eventstore.EventStore[postcard.Postcard] {
+				repo, err := storage.NewSerializingInMemoryPostcardRepository()
+				require.NoError(t, err)
+				return repo
+			}(),
+		},
+		{
+			name: "in_memory_mapping_serializing",
+			repository: func() eventstore.EventStore[postcard.Postcard] {
+				repo, err := storage.NewMappingSerializingInMemoryPostcardRepository()
+				require.NoError(t, err)
+				return repo
+			}(),
+		},
 		{
 			name: "postgres_simple",
 			repository: func() eventstore.EventStore[postcard.Postcard] {
@@ -124,6 +151,22 @@ func TestPostcard_Repositories(t *testing.T) {
 				return repo
 			}(),
 		},
+		{
+			name: "sqlite_registry",
+			repository: func() eventstore.EventStore[postcard.Postcard] {
+				repo, err := storage.NewRegistrySQLitePostcardRepository(context.Background(), sqliteDB)
+				require.NoError(t, err)
+				return repo
+			}(),
+		},
+		{
+			name: "sqlite_mapped_registry",
+			repository: func() eventstore.EventStore[postcard.Postcard] {
+				repo, err := storage.NewMappedRegistrySQLitePostcardRepository(context.Background(), sqliteDB)
+				require.NoError(t, err)
+				return repo
+			}(),
+		},
 	}
 
 	ctx := context.Background()
@@ -187,6 +230,1248 @@ func TestPostcard_Repositories(t *testing.T) {
 	}
 }
 
+func TestPostcard_PayloadQuery(t *testing.T) {
+	ctx := context.Background()
+
+	supportedEvents := []esja.Event[postcard.Postcard]{
+		postcard.Created{},
+		postcard.Addressed{},
+		postcard.Written{},
+		postcard.Sent{},
+	}
+
+	t.Run("postgres", func(t *testing.T) {
+		config := eventstore.NewPostgresSQLConfig[postcard.Postcard](supportedEvents)
+		config.SchemaAdapter = eventstore.NewPostgresSchemaAdapterWithConfig[postcard.Postcard](eventstore.SchemaConfig{
+			TableName:    "payload_events",
+			PayloadIndex: true,
+		})
+
+		store, err := eventstore.NewSQLStore[postcard.Postcard](ctx, testPostgresDB(t), config)
+		require.NoError(t, err)
+
+		id := gofakeit.UUID()
+		addressee := addresseeAddress
+		addressee.Name = "Bob " + gofakeit.UUID()
+
+		pc, err := postcard.NewPostcard(id)
+		require.NoError(t, err)
+		err = pc.Address(senderAddress, addressee)
+		require.NoError(t, err)
+		err = store.Save(ctx, pc)
+		require.NoError(t, err)
+
+		page, err := store.QueryEvents(ctx, eventstore.EventQuery{
+			EventNames: []string{postcard.Addressed{}.EventName()},
+			Payload: []eventstore.PayloadPredicate{
+				eventstore.PayloadEquals("Addressee.Name", addressee.Name),
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, page.Events, 1)
+		assert.Equal(t, id, page.Events[0].StreamID)
+
+		page, err = store.QueryEvents(ctx, eventstore.EventQuery{
+			Payload: []eventstore.PayloadPredicate{
+				eventstore.PayloadLike("Addressee.Name", addressee.Name[:20]+"%"),
+				eventstore.PayloadExists("Sender.Line1"),
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, page.Events, 1)
+		assert.Equal(t, id, page.Events[0].StreamID)
+
+		streams, err := store.ListStreams(ctx, eventstore.StreamQuery{
+			StreamType: "Postcard",
+			Payload: []eventstore.PayloadPredicate{
+				eventstore.PayloadEquals("Addressee", addressee),
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, streams.Streams, 1)
+		assert.Equal(t, id, streams.Streams[0].StreamID)
+		assert.Equal(t, 2, streams.Streams[0].EventCount)
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		store, err := eventstore.NewSQLStore[postcard.Postcard](
+			ctx,
+			testSQLiteDB(t),
+			eventstore.NewSQLiteConfig[postcard.Postcard](supportedEvents),
+		)
+		require.NoError(t, err)
+
+		_, err = store.QueryEvents(ctx, eventstore.EventQuery{
+			Payload: []eventstore.PayloadPredicate{
+				eventstore.PayloadEquals("Addressee.Name", "Bob"),
+			},
+		})
+		assert.ErrorIs(t, err, eventstore.ErrPayloadQueryNotSupported)
+	})
+}
+
+func TestEventStoreContract(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		eventstoretest.TestEventStore(t, func(t *testing.T) eventstore.EventStore[eventstoretest.Entity] {
+			store, err := eventstore.NewSQLStore[eventstoretest.Entity](
+				context.Background(),
+				testSQLiteDB(t),
+				eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents()),
+			)
+			require.NoError(t, err)
+			return store
+		})
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		eventstoretest.TestEventStore(t, func(t *testing.T) eventstore.EventStore[eventstoretest.Entity] {
+			store, err := eventstore.NewSQLStore[eventstoretest.Entity](
+				context.Background(),
+				testPostgresDB(t),
+				eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents()),
+			)
+			require.NoError(t, err)
+			return store
+		})
+	})
+}
+
+func TestEventStoreCompression(t *testing.T) {
+	marshaler, err := transport.NewCompressingMarshaler(transport.JSONMarshaler{}, transport.CompressionConfig{
+		// All payloads of the suite are small, so they are compressed regardless of the size.
+		Threshold: 1,
+	})
+	require.NoError(t, err)
+
+	t.Run("sqlite", func(t *testing.T) {
+		eventstoretest.TestEventStore(t, func(t *testing.T) eventstore.EventStore[eventstoretest.Entity] {
+			config := eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			config.Marshaler = marshaler
+
+			store, err := eventstore.NewSQLStore[eventstoretest.Entity](context.Background(), testSQLiteDB(t), config)
+			require.NoError(t, err)
+			return store
+		})
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		eventstoretest.TestEventStore(t, func(t *testing.T) eventstore.EventStore[eventstoretest.Entity] {
+			config := eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			config.SchemaAdapter = eventstore.NewPostgresSchemaAdapterWithConfig[eventstoretest.Entity](eventstore.SchemaConfig{
+				TableName:     "compressed_events",
+				BinaryPayload: true,
+			})
+			config.Marshaler = marshaler
+
+			store, err := eventstore.NewSQLStore[eventstoretest.Entity](context.Background(), testPostgresDB(t), config)
+			require.NoError(t, err)
+			return store
+		})
+	})
+}
+
+func TestEventStoreBinaryMarshalers(t *testing.T) {
+	marshalers := map[string]transport.Marshaler{
+		"cbor":    transport.CBORMarshaler{},
+		"msgpack": transport.MsgPackMarshaler{},
+	}
+
+	for name, marshaler := range marshalers {
+		name, marshaler := name, marshaler
+
+		t.Run(name+"/sqlite", func(t *testing.T) {
+			eventstoretest.TestEventStore(t, func(t *testing.T) eventstore.EventStore[eventstoretest.Entity] {
+				config := eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+				config.Marshaler = marshaler
+
+				store, err := eventstore.NewSQLStore[eventstoretest.Entity](context.Background(), testSQLiteDB(t), config)
+				require.NoError(t, err)
+				return store
+			})
+		})
+
+		t.Run(name+"/postgres", func(t *testing.T) {
+			eventstoretest.TestEventStore(t, func(t *testing.T) eventstore.EventStore[eventstoretest.Entity] {
+				config := eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+				config.SchemaAdapter = eventstore.NewPostgresSchemaAdapterWithConfig[eventstoretest.Entity](eventstore.SchemaConfig{
+					TableName:     name + "_events",
+					BinaryPayload: true,
+				})
+				config.Marshaler = marshaler
+
+				store, err := eventstore.NewSQLStore[eventstoretest.Entity](context.Background(), testPostgresDB(t), config)
+				require.NoError(t, err)
+				return store
+			})
+		})
+	}
+}
+
+func TestEventStoreContentType(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		testContentType(t, testSQLiteDB(t), "events", func(config eventstore.SchemaConfig) eventstore.SQLConfig[eventstoretest.Entity] {
+			c := eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			c.SchemaAdapter = eventstore.NewSQLiteSchemaAdapterWithConfig[eventstoretest.Entity](config)
+			return c
+		})
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		db := testPostgresDB(t)
+
+		_, err := db.Exec("DROP TABLE IF EXISTS content_type_events")
+		require.NoError(t, err)
+
+		testContentType(t, db, "content_type_events", func(config eventstore.SchemaConfig) eventstore.SQLConfig[eventstoretest.Entity] {
+			config.BinaryPayload = true
+
+			c := eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			c.SchemaAdapter = eventstore.NewPostgresSchemaAdapterWithConfig[eventstoretest.Entity](config)
+			return c
+		})
+	})
+}
+
+func testContentType(
+	t *testing.T,
+	db *sql.DB,
+	tableName string,
+	newConfig func(config eventstore.SchemaConfig) eventstore.SQLConfig[eventstoretest.Entity],
+) {
+	ctx := context.Background()
+
+	gobConfig := newConfig(eventstore.SchemaConfig{TableName: tableName})
+	gobConfig.Marshaler = transport.GOBMarshaler{}
+
+	gobStore, err := eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, gobConfig)
+	require.NoError(t, err)
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+
+	err = gobStore.Save(ctx, entity)
+	require.NoError(t, err)
+
+	_, err = db.Exec("ALTER TABLE " + tableName + " ADD COLUMN content_type varchar(255) NOT NULL DEFAULT ''")
+	require.NoError(t, err)
+
+	registry, err := transport.NewMarshalerRegistry(transport.MarshalerRegistryConfig{
+		Marshalers: map[string]transport.Marshaler{
+			transport.ContentTypeJSON: transport.JSONMarshaler{},
+			transport.ContentTypeGOB:  transport.GOBMarshaler{},
+		},
+		DefaultContentType: transport.ContentTypeJSON,
+		LegacyContentType:  transport.ContentTypeGOB,
+	})
+	require.NoError(t, err)
+
+	withoutColumnConfig := newConfig(eventstore.SchemaConfig{TableName: tableName})
+	withoutColumnConfig.Marshaler = nil
+	withoutColumnConfig.Marshalers = registry
+
+	_, err = eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, withoutColumnConfig)
+	require.ErrorContains(t, err, "marshalers require the content type enabled in the schema config")
+
+	config := newConfig(eventstore.SchemaConfig{TableName: tableName, ContentType: true})
+	config.Marshalers = registry
+
+	_, err = eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, config)
+	require.ErrorContains(t, err, "marshaler and marshalers must not be both set")
+
+	config.Marshaler = nil
+
+	store, err := eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, config)
+	require.NoError(t, err)
+
+	loaded, err := store.Load(ctx, entity.ID())
+	require.NoError(t, err)
+	err = loaded.Update("json")
+	require.NoError(t, err)
+
+	err = store.Save(ctx, loaded)
+	require.NoError(t, err)
+
+	loaded, err = store.Load(ctx, entity.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "json", loaded.Value())
+	assert.Equal(t, 2, loaded.Stream().Version())
+
+	rows, err := db.Query("SELECT content_type FROM " + tableName + " WHERE stream_id = '" + entity.ID() + "' ORDER BY stream_version")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var contentTypes []string
+	for rows.Next() {
+		var contentType string
+		require.NoError(t, rows.Scan(&contentType))
+		contentTypes = append(contentTypes, contentType)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"", transport.ContentTypeJSON}, contentTypes)
+
+	recorded, err := store.ReadByStreamType(ctx, "Entity", 0)
+	require.NoError(t, err)
+	assert.Len(t, recorded, 2)
+
+	var rewritten int
+	result, err := store.RewritePayloads(ctx, func(payload []byte) ([]byte, bool, error) {
+		assert.True(t, json.Valid(payload), "only payloads of the default content type should be rewritten")
+		rewritten++
+		return nil, false, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, rewritten)
+	assert.Equal(t, 2, result.Scanned)
+}
+
+func TestEventStoreClaimCheck(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		testClaimCheck(t, testSQLiteDB(t), "events", func(marshaler transport.Marshaler) eventstore.SQLConfig[eventstoretest.Entity] {
+			config := eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			config.Marshaler = marshaler
+			return config
+		})
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		db := testPostgresDB(t)
+
+		_, err := db.Exec("DROP TABLE IF EXISTS claim_check_events")
+		require.NoError(t, err)
+
+		testClaimCheck(t, db, "claim_check_events", func(marshaler transport.Marshaler) eventstore.SQLConfig[eventstoretest.Entity] {
+			config := eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			config.SchemaAdapter = eventstore.NewPostgresSchemaAdapterWithConfig[eventstoretest.Entity](eventstore.SchemaConfig{
+				TableName:     "claim_check_events",
+				BinaryPayload: true,
+			})
+			config.Marshaler = marshaler
+			return config
+		})
+	})
+}
+
+func testClaimCheck(
+	t *testing.T,
+	db *sql.DB,
+	tableName string,
+	newConfig func(marshaler transport.Marshaler) eventstore.SQLConfig[eventstoretest.Entity],
+) {
+	ctx := context.Background()
+
+	blobs, err := transport.NewFileBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	marshaler, err := transport.NewClaimCheckMarshaler(transport.JSONMarshaler{}, blobs, transport.ClaimCheckConfig{
+		Threshold: 1024,
+	})
+	require.NoError(t, err)
+
+	store, err := eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, newConfig(marshaler))
+	require.NoError(t, err)
+
+	deleted, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = deleted.Update(strings.Repeat("deleted ", 1000))
+	require.NoError(t, err)
+
+	err = store.Save(ctx, deleted)
+	require.NoError(t, err)
+
+	kept, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = kept.Update(strings.Repeat("kept ", 1000))
+	require.NoError(t, err)
+
+	err = store.Save(ctx, kept)
+	require.NoError(t, err)
+
+	var payload []byte
+	err = db.QueryRow(
+		"SELECT event_payload FROM " + tableName + " WHERE stream_id = '" + kept.ID() + "' AND stream_version = 2",
+	).Scan(&payload)
+	require.NoError(t, err)
+
+	keptKey, ok := transport.BlobKey(payload)
+	require.True(t, ok, "large payload should be offloaded")
+
+	loaded, err := store.Load(ctx, kept.ID())
+	require.NoError(t, err)
+	assert.Equal(t, kept.Value(), loaded.Value())
+
+	err = store.DeleteStream(ctx, deleted.ID())
+	require.NoError(t, err)
+
+	_, err = store.Load(ctx, deleted.ID())
+	assert.ErrorIs(t, err, eventstore.ErrEntityNotFound)
+
+	err = store.DeleteStream(ctx, deleted.ID())
+	assert.ErrorIs(t, err, eventstore.ErrEntityNotFound)
+
+	result, err := store.CollectBlobs(ctx, blobs, eventstore.BlobCollectionConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Deleted, "blobs younger than MinAge should be kept")
+
+	result, err = store.CollectBlobs(ctx, blobs, eventstore.BlobCollectionConfig{MinAge: time.Nanosecond})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Referenced)
+	assert.Equal(t, 1, result.Deleted)
+
+	listed, err := blobs.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, keptKey, listed[0].Key)
+
+	loaded, err = store.Load(ctx, kept.ID())
+	require.NoError(t, err)
+	assert.Equal(t, kept.Value(), loaded.Value())
+}
+
+func TestEventStoreClaimCheckKeyRotation(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		testClaimCheckKeyRotation(t, testSQLiteDB(t), func(marshaler transport.Marshaler) eventstore.SQLConfig[eventstoretest.Entity] {
+			config := eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			config.Marshaler = marshaler
+			return config
+		})
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		db := testPostgresDB(t)
+
+		_, err := db.Exec("DROP TABLE IF EXISTS claim_check_rotation_events")
+		require.NoError(t, err)
+
+		testClaimCheckKeyRotation(t, db, func(marshaler transport.Marshaler) eventstore.SQLConfig[eventstoretest.Entity] {
+			config := eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			config.SchemaAdapter = eventstore.NewPostgresSchemaAdapterWithConfig[eventstoretest.Entity](eventstore.SchemaConfig{
+				TableName:     "claim_check_rotation_events",
+				BinaryPayload: true,
+			})
+			config.Marshaler = marshaler
+			return config
+		})
+	})
+}
+
+// testClaimCheckKeyRotation re-encrypts the payloads kept inline and offloaded to the blob store.
+func testClaimCheckKeyRotation(
+	t *testing.T,
+	db *sql.DB,
+	newConfig func(marshaler transport.Marshaler) eventstore.SQLConfig[eventstoretest.Entity],
+) {
+	ctx := context.Background()
+
+	blobs := transport.NewMemoryBlobStore()
+
+	keyRing, err := transport.NewMemoryKeyRing("a", bytes.Repeat([]byte("a"), 32))
+	require.NoError(t, err)
+
+	encrypting, err := transport.NewEncryptingMarshaler(transport.JSONMarshaler{}, keyRing)
+	require.NoError(t, err)
+
+	marshaler, err := transport.NewClaimCheckMarshaler(encrypting, blobs, transport.ClaimCheckConfig{
+		Threshold: 1024,
+	})
+	require.NoError(t, err)
+
+	store, err := eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, newConfig(marshaler))
+	require.NoError(t, err)
+
+	large := strings.Repeat("secret ", 500)
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = entity.Update(large)
+	require.NoError(t, err)
+	err = entity.Update("small")
+	require.NoError(t, err)
+
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	keyB := bytes.Repeat([]byte("b"), 32)
+	err = keyRing.Rotate("b", keyB)
+	require.NoError(t, err)
+
+	result, err := store.RewritePayloads(ctx, encrypting.Reencrypt)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Rewritten, "references should be kept by Reencrypt")
+
+	result, err = store.RewritePayloads(ctx, marshaler.RewriteBlobs(ctx, encrypting.Reencrypt))
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Rewritten, "the offloaded payload should be re-encrypted")
+
+	result, err = store.RewritePayloads(ctx, marshaler.RewriteBlobs(ctx, encrypting.Reencrypt))
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Rewritten)
+
+	collected, err := store.CollectBlobs(ctx, blobs, eventstore.BlobCollectionConfig{MinAge: time.Nanosecond})
+	require.NoError(t, err)
+	assert.Equal(t, 1, collected.Referenced)
+	assert.Equal(t, 1, collected.Deleted, "the blob encrypted with the old key should be removed")
+
+	onlyB, err := transport.NewMemoryKeyRing("b", keyB)
+	require.NoError(t, err)
+
+	onlyBEncrypting, err := transport.NewEncryptingMarshaler(transport.JSONMarshaler{}, onlyB)
+	require.NoError(t, err)
+
+	onlyBMarshaler, err := transport.NewClaimCheckMarshaler(onlyBEncrypting, blobs, transport.ClaimCheckConfig{
+		Threshold: 1024,
+	})
+	require.NoError(t, err)
+
+	rotatedStore, err := eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, newConfig(onlyBMarshaler))
+	require.NoError(t, err)
+
+	loaded, err := rotatedStore.Load(ctx, entity.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "small", loaded.Value())
+	assert.Equal(t, 3, loaded.Stream().Version())
+
+	events, err := rotatedStore.LoadEvents(ctx, entity.ID(), 1)
+	require.NoError(t, err)
+	require.Len(t, events.Events, 2)
+	assert.Equal(t, &eventstoretest.Updated{Value: large}, events.Events[0].Event)
+
+	err = blobs.Put(ctx, "orphan", []byte("orphan"))
+	require.NoError(t, err)
+
+	runCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	err = rotatedStore.RunBlobCollection(runCtx, blobs, eventstore.BlobCollectionConfig{
+		MinAge:   time.Nanosecond,
+		Interval: 10 * time.Millisecond,
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = blobs.Get(ctx, "orphan")
+	assert.ErrorIs(t, err, transport.ErrBlobNotFound, "blobs no event refers to should be collected")
+
+	loaded, err = rotatedStore.Load(ctx, entity.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "small", loaded.Value())
+}
+
+func TestEventStoreHashChain(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		testHashChain(t, testSQLiteDB(t), "events", func() eventstore.SQLConfig[eventstoretest.Entity] {
+			config := eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			config.SchemaAdapter = eventstore.NewSQLiteSchemaAdapterWithConfig[eventstoretest.Entity](eventstore.SchemaConfig{
+				HashChain: true,
+			})
+			return config
+		})
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		db := testPostgresDB(t)
+
+		_, err := db.Exec("DROP TABLE IF EXISTS hash_chain_events")
+		require.NoError(t, err)
+
+		jsonbConfig := eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+		jsonbConfig.SchemaAdapter = eventstore.NewPostgresSchemaAdapterWithConfig[eventstoretest.Entity](eventstore.SchemaConfig{
+			TableName: "hash_chain_events",
+			HashChain: true,
+		})
+
+		_, err = eventstore.NewSQLStore[eventstoretest.Entity](context.Background(), db, jsonbConfig)
+		require.ErrorContains(t, err, "hash chain requires payloads stored as they are")
+
+		testHashChain(t, db, "hash_chain_events", func() eventstore.SQLConfig[eventstoretest.Entity] {
+			config := eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			config.SchemaAdapter = eventstore.NewPostgresSchemaAdapterWithConfig[eventstoretest.Entity](eventstore.SchemaConfig{
+				TableName:     "hash_chain_events",
+				HashChain:     true,
+				BinaryPayload: true,
+			})
+			return config
+		})
+	})
+}
+
+func testHashChain(
+	t *testing.T,
+	db *sql.DB,
+	tableName string,
+	newConfig func() eventstore.SQLConfig[eventstoretest.Entity],
+) {
+	ctx := context.Background()
+
+	store, err := eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, newConfig())
+	require.NoError(t, err)
+
+	intact, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+
+	tampered, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+
+	for _, e := range []*eventstoretest.Entity{intact, tampered} {
+		err = e.Update("first")
+		require.NoError(t, err)
+
+		err = store.Save(ctx, e)
+		require.NoError(t, err)
+
+		// The next events are chained to the events saved before.
+		err = e.Update("second")
+		require.NoError(t, err)
+		err = e.Update("third")
+		require.NoError(t, err)
+
+		err = store.Save(ctx, e)
+		require.NoError(t, err)
+	}
+
+	loaded, err := store.Load(ctx, tampered.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "third", loaded.Value())
+
+	report, err := store.VerifyIntegrity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Streams)
+	assert.Equal(t, 8, report.Events)
+	assert.Empty(t, report.Errors)
+
+	_, err = store.RewritePayloads(ctx, func(payload []byte) ([]byte, bool, error) {
+		return payload, true, nil
+	})
+	assert.ErrorContains(t, err, "payloads can't be rewritten with the hash chain enabled")
+
+	_, err = db.Exec(
+		"UPDATE "+tableName+" SET event_payload = $1 WHERE stream_id = $2 AND stream_version = 3",
+		[]byte(`{"Value":"edited"}`),
+		tampered.ID(),
+	)
+	require.NoError(t, err)
+
+	_, err = store.Load(ctx, tampered.ID())
+	var integrityErr eventstore.IntegrityError
+	require.ErrorAs(t, err, &integrityErr)
+	assert.Equal(t, tampered.ID(), integrityErr.StreamID)
+	assert.Equal(t, 3, integrityErr.StreamVersion)
+
+	loaded, err = store.Load(ctx, intact.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "third", loaded.Value())
+
+	report, err = store.VerifyIntegrity(ctx)
+	require.NoError(t, err)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, tampered.ID(), report.Errors[0].StreamID)
+	assert.Equal(t, 3, report.Errors[0].StreamVersion)
+
+	// Deleting an event breaks the chain of the next one.
+	_, err = db.Exec("DELETE FROM "+tableName+" WHERE stream_id = $1 AND stream_version = 2", intact.ID())
+	require.NoError(t, err)
+
+	report, err = store.VerifyIntegrity(ctx)
+	require.NoError(t, err)
+	require.Len(t, report.Errors, 2)
+
+	var brokenVersions []int
+	for _, e := range report.Errors {
+		if e.StreamID == intact.ID() {
+			brokenVersions = append(brokenVersions, e.StreamVersion)
+		}
+	}
+	assert.Equal(t, []int{3}, brokenVersions)
+}
+
+func TestEventStoreMultiTenantHashChain(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		testMultiTenantHashChain(t, testSQLiteDB(t), "tenant_hash_chain_events", func() eventstore.SQLConfig[eventstoretest.Entity] {
+			config := eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			config.SchemaAdapter = eventstore.NewSQLiteSchemaAdapterWithConfig[eventstoretest.Entity](eventstore.SchemaConfig{
+				TableName:   "tenant_hash_chain_events",
+				HashChain:   true,
+				MultiTenant: true,
+				ContentType: true,
+			})
+			return config
+		})
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		db := testPostgresDB(t)
+
+		_, err := db.Exec("DROP TABLE IF EXISTS tenant_hash_chain_events")
+		require.NoError(t, err)
+
+		testMultiTenantHashChain(t, db, "tenant_hash_chain_events", func() eventstore.SQLConfig[eventstoretest.Entity] {
+			config := eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			config.SchemaAdapter = eventstore.NewPostgresSchemaAdapterWithConfig[eventstoretest.Entity](eventstore.SchemaConfig{
+				TableName:     "tenant_hash_chain_events",
+				HashChain:     true,
+				MultiTenant:   true,
+				ContentType:   true,
+				BinaryPayload: true,
+			})
+			return config
+		})
+	})
+}
+
+func testMultiTenantHashChain(
+	t *testing.T,
+	db *sql.DB,
+	tableName string,
+	newConfig func() eventstore.SQLConfig[eventstoretest.Entity],
+) {
+	store, err := eventstore.NewSQLStore[eventstoretest.Entity](context.Background(), db, newConfig())
+	require.NoError(t, err)
+
+	tenantA := "tenant-a-" + eventstoretest.NewID()
+	tenantB := "tenant-b-" + eventstoretest.NewID()
+	ctxA := eventstore.WithTenantID(context.Background(), tenantA)
+	ctxB := eventstore.WithTenantID(context.Background(), tenantB)
+
+	// The same stream ID is used by both tenants.
+	id := eventstoretest.NewID()
+	for _, ctx := range []context.Context{ctxA, ctxB} {
+		e, err := eventstoretest.NewEntity(id)
+		require.NoError(t, err)
+
+		err = e.Update("first")
+		require.NoError(t, err)
+
+		err = store.Save(ctx, e)
+		require.NoError(t, err)
+	}
+
+	// All tenants are checked, no tenant is needed in the context.
+	report, err := store.VerifyIntegrity(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Streams)
+	assert.Equal(t, 4, report.Events)
+	assert.Empty(t, report.Errors)
+
+	_, err = db.Exec(
+		"UPDATE "+tableName+" SET content_type = $1 WHERE tenant_id = $2 AND stream_id = $3 AND stream_version = 2",
+		"application/edited",
+		tenantA,
+		id,
+	)
+	require.NoError(t, err)
+
+	_, err = store.Load(ctxA, id)
+	var integrityErr eventstore.IntegrityError
+	require.ErrorAs(t, err, &integrityErr)
+	assert.Equal(t, tenantA, integrityErr.TenantID)
+	assert.Equal(t, 2, integrityErr.StreamVersion)
+
+	_, err = store.Load(ctxB, id)
+	require.NoError(t, err)
+
+	// Moving the events to another tenant breaks their hashes.
+	tenantC := "tenant-c-" + eventstoretest.NewID()
+	_, err = db.Exec("UPDATE "+tableName+" SET tenant_id = $1 WHERE tenant_id = $2", tenantC, tenantB)
+	require.NoError(t, err)
+
+	report, err = store.VerifyIntegrity(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Streams)
+	require.Len(t, report.Errors, 3)
+
+	errorsByTenant := map[string][]int{}
+	for _, e := range report.Errors {
+		errorsByTenant[e.TenantID] = append(errorsByTenant[e.TenantID], e.StreamVersion)
+	}
+	assert.Equal(t, map[string][]int{tenantA: {2}, tenantC: {1, 2}}, errorsByTenant)
+}
+
+func TestEventStoreKeyedHashChain(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		testKeyedHashChain(t, testSQLiteDB(t), func(tableName string) eventstore.SQLConfig[eventstoretest.Entity] {
+			config := eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			config.SchemaAdapter = eventstore.NewSQLiteSchemaAdapterWithConfig[eventstoretest.Entity](eventstore.SchemaConfig{
+				TableName: tableName,
+				HashChain: true,
+			})
+			return config
+		})
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		db := testPostgresDB(t)
+
+		for _, tableName := range []string{"unkeyed_hash_chain_events", "keyed_hash_chain_events"} {
+			_, err := db.Exec("DROP TABLE IF EXISTS " + tableName)
+			require.NoError(t, err)
+		}
+
+		testKeyedHashChain(t, db, func(tableName string) eventstore.SQLConfig[eventstoretest.Entity] {
+			config := eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			config.SchemaAdapter = eventstore.NewPostgresSchemaAdapterWithConfig[eventstoretest.Entity](eventstore.SchemaConfig{
+				TableName:     tableName,
+				HashChain:     true,
+				BinaryPayload: true,
+			})
+			return config
+		})
+	})
+}
+
+func testKeyedHashChain(
+	t *testing.T,
+	db *sql.DB,
+	newConfig func(tableName string) eventstore.SQLConfig[eventstoretest.Entity],
+) {
+	ctx := context.Background()
+	key := bytes.Repeat([]byte("k"), 32)
+
+	invalidConfig := newConfig("keyed_hash_chain_events")
+	invalidConfig.HashChainKey = key[:16]
+	_, err := eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, invalidConfig)
+	require.ErrorContains(t, err, "hash chain key must have at least 32 bytes")
+
+	unkeyedStore, err := eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, newConfig("unkeyed_hash_chain_events"))
+	require.NoError(t, err)
+
+	keyedConfig := newConfig("keyed_hash_chain_events")
+	keyedConfig.HashChainKey = key
+	keyedStore, err := eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, keyedConfig)
+	require.NoError(t, err)
+
+	// The payload is edited and its hash recomputed the way the unkeyed chain computes it.
+	tamper := func(tableName string, id string) {
+		var previousHash string
+		err := db.QueryRow(
+			"SELECT event_hash FROM "+tableName+" WHERE stream_id = $1 AND stream_version = 1",
+			id,
+		).Scan(&previousHash)
+		require.NoError(t, err)
+
+		var eventName string
+		err = db.QueryRow(
+			"SELECT event_name FROM "+tableName+" WHERE stream_id = $1 AND stream_version = 2",
+			id,
+		).Scan(&eventName)
+		require.NoError(t, err)
+
+		payload := []byte(`{"Value":"edited"}`)
+		_, err = db.Exec(
+			"UPDATE "+tableName+" SET event_payload = $1, event_hash = $2 WHERE stream_id = $3 AND stream_version = 2",
+			payload,
+			unkeyedEventHash(previousHash, id, 2, eventName, payload),
+			id,
+		)
+		require.NoError(t, err)
+	}
+
+	unkeyed, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = unkeyed.Update("first")
+	require.NoError(t, err)
+	err = unkeyedStore.Save(ctx, unkeyed)
+	require.NoError(t, err)
+
+	tamper("unkeyed_hash_chain_events", unkeyed.ID())
+
+	// The unkeyed chain doesn't detect the hashes recomputed along with the edit.
+	loaded, err := unkeyedStore.Load(ctx, unkeyed.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "edited", loaded.Value())
+
+	keyed, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = keyed.Update("first")
+	require.NoError(t, err)
+	err = keyedStore.Save(ctx, keyed)
+	require.NoError(t, err)
+
+	loaded, err = keyedStore.Load(ctx, keyed.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "first", loaded.Value())
+
+	tamper("keyed_hash_chain_events", keyed.ID())
+
+	_, err = keyedStore.Load(ctx, keyed.ID())
+	var integrityErr eventstore.IntegrityError
+	require.ErrorAs(t, err, &integrityErr)
+	assert.Equal(t, 2, integrityErr.StreamVersion)
+
+	report, err := keyedStore.VerifyIntegrity(ctx)
+	require.NoError(t, err)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, keyed.ID(), report.Errors[0].StreamID)
+}
+
+// unkeyedEventHash computes the hash of the unkeyed chain of the single-tenant table without the content type.
+func unkeyedEventHash(previousHash string, streamID string, streamVersion int, eventName string, payload []byte) string {
+	h := sha256.New()
+
+	writeField := func(b []byte) {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(b)))
+		h.Write(length[:])
+		h.Write(b)
+	}
+
+	var version [8]byte
+	binary.BigEndian.PutUint64(version[:], uint64(streamVersion))
+
+	writeField([]byte(previousHash))
+	writeField(nil)
+	writeField([]byte(streamID))
+	writeField(version[:])
+	writeField([]byte(eventName))
+	writeField(nil)
+	writeField(payload)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func TestEventStoreKeyRotation(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		testKeyRotation(t, testSQLiteDB(t), func(marshaler transport.Marshaler) eventstore.SQLConfig[eventstoretest.Entity] {
+			config := eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			config.Marshaler = marshaler
+			return config
+		})
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		testKeyRotation(t, testPostgresDB(t), func(marshaler transport.Marshaler) eventstore.SQLConfig[eventstoretest.Entity] {
+			config := eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+			config.SchemaAdapter = eventstore.NewPostgresSchemaAdapterWithConfig[eventstoretest.Entity](eventstore.SchemaConfig{
+				TableName:     "encrypted_events",
+				BinaryPayload: true,
+			})
+			config.Marshaler = marshaler
+			return config
+		})
+	})
+}
+
+func testKeyRotation(
+	t *testing.T,
+	db *sql.DB,
+	newConfig func(marshaler transport.Marshaler) eventstore.SQLConfig[eventstoretest.Entity],
+) {
+	ctx := context.Background()
+
+	keyA := bytes.Repeat([]byte("a"), 32)
+	keyB := bytes.Repeat([]byte("b"), 32)
+
+	keyRing, err := transport.NewMemoryKeyRing("a", keyA)
+	require.NoError(t, err)
+
+	marshaler, err := transport.NewEncryptingMarshaler(transport.JSONMarshaler{}, keyRing)
+	require.NoError(t, err)
+
+	store, err := eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, newConfig(marshaler))
+	require.NoError(t, err)
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = entity.Update("secret")
+	require.NoError(t, err)
+
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	err = keyRing.Rotate("b", keyB)
+	require.NoError(t, err)
+
+	result, err := store.RewritePayloads(ctx, marshaler.Reencrypt)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, result.Rewritten, 2)
+	assert.GreaterOrEqual(t, result.Scanned, result.Rewritten)
+
+	result, err = store.RewritePayloads(ctx, marshaler.Reencrypt)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Rewritten, "payloads encrypted with the current key should be kept")
+
+	onlyB, err := transport.NewMemoryKeyRing("b", keyB)
+	require.NoError(t, err)
+
+	onlyBMarshaler, err := transport.NewEncryptingMarshaler(transport.JSONMarshaler{}, onlyB)
+	require.NoError(t, err)
+
+	rotatedStore, err := eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, newConfig(onlyBMarshaler))
+	require.NoError(t, err)
+
+	loaded, err := rotatedStore.Load(ctx, entity.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "secret", loaded.Value())
+	assert.Equal(t, 2, loaded.Stream().Version())
+}
+
+func TestEventStoreStreamCatalog(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		store, err := eventstore.NewSQLStore[eventstoretest.Entity](
+			context.Background(),
+			testSQLiteDB(t),
+			eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents()),
+		)
+		require.NoError(t, err)
+
+		eventstoretest.TestStreamCatalog(t, store)
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		store, err := eventstore.NewSQLStore[eventstoretest.Entity](
+			context.Background(),
+			testPostgresDB(t),
+			eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents()),
+		)
+		require.NoError(t, err)
+
+		eventstoretest.TestStreamCatalog(t, store)
+	})
+}
+
+func TestEventStoreStreamDeletion(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		store, err := eventstore.NewSQLStore[eventstoretest.Entity](
+			context.Background(),
+			testSQLiteDB(t),
+			eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents()),
+		)
+		require.NoError(t, err)
+
+		eventstoretest.TestStreamDeletion(t, store)
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		store, err := eventstore.NewSQLStore[eventstoretest.Entity](
+			context.Background(),
+			testPostgresDB(t),
+			eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents()),
+		)
+		require.NoError(t, err)
+
+		eventstoretest.TestStreamDeletion(t, store)
+	})
+}
+
+func TestEventStoreReadByStreamType(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		store, err := eventstore.NewSQLStore[eventstoretest.Entity](
+			context.Background(),
+			testSQLiteDB(t),
+			eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents()),
+		)
+		require.NoError(t, err)
+
+		eventstoretest.TestReadByStreamType(t, store)
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		store, err := eventstore.NewSQLStore[eventstoretest.Entity](
+			context.Background(),
+			testPostgresDB(t),
+			eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents()),
+		)
+		require.NoError(t, err)
+
+		eventstoretest.TestReadByStreamType(t, store)
+	})
+}
+
+func TestEventStoreQueryEvents(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		store, err := eventstore.NewSQLStore[eventstoretest.Entity](
+			context.Background(),
+			testSQLiteDB(t),
+			eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents()),
+		)
+		require.NoError(t, err)
+
+		eventstoretest.TestQueryEvents(t, store)
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		store, err := eventstore.NewSQLStore[eventstoretest.Entity](
+			context.Background(),
+			testPostgresDB(t),
+			eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents()),
+		)
+		require.NoError(t, err)
+
+		eventstoretest.TestQueryEvents(t, store)
+	})
+}
+
+func TestEventStoreMultiTenancy(t *testing.T) {
+	schemaConfig := eventstore.SchemaConfig{
+		TableName:   "tenant_events",
+		MultiTenant: true,
+	}
+
+	t.Run("sqlite", func(t *testing.T) {
+		config := eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+		config.SchemaAdapter = eventstore.NewSQLiteSchemaAdapterWithConfig[eventstoretest.Entity](schemaConfig)
+
+		store, err := eventstore.NewSQLStore[eventstoretest.Entity](context.Background(), testSQLiteDB(t), config)
+		require.NoError(t, err)
+
+		eventstoretest.TestMultiTenancy(t, store)
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		config := eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+		config.SchemaAdapter = eventstore.NewPostgresSchemaAdapterWithConfig[eventstoretest.Entity](schemaConfig)
+
+		store, err := eventstore.NewSQLStore[eventstoretest.Entity](context.Background(), testPostgresDB(t), config)
+		require.NoError(t, err)
+
+		eventstoretest.TestMultiTenancy(t, store)
+	})
+}
+
+func TestEventStoreIdempotency(t *testing.T) {
+	schemaConfig := eventstore.SchemaConfig{
+		TableName:       "idempotent_events",
+		IdempotencyKeys: true,
+	}
+
+	t.Run("sqlite", func(t *testing.T) {
+		config := eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+		config.SchemaAdapter = eventstore.NewSQLiteSchemaAdapterWithConfig[eventstoretest.Entity](schemaConfig)
+
+		store, err := eventstore.NewSQLStore[eventstoretest.Entity](context.Background(), testSQLiteDB(t), config)
+		require.NoError(t, err)
+
+		eventstoretest.TestIdempotency(t, store)
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		config := eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+		config.SchemaAdapter = eventstore.NewPostgresSchemaAdapterWithConfig[eventstoretest.Entity](schemaConfig)
+
+		store, err := eventstore.NewSQLStore[eventstoretest.Entity](context.Background(), testPostgresDB(t), config)
+		require.NoError(t, err)
+
+		eventstoretest.TestIdempotency(t, store)
+	})
+
+	t.Run("sqlite_disabled", func(t *testing.T) {
+		ctx := eventstore.WithIdempotencyKey(context.Background(), "key-"+eventstoretest.NewID())
+
+		store, err := eventstore.NewSQLStore[eventstoretest.Entity](
+			context.Background(),
+			testSQLiteDB(t),
+			eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents()),
+		)
+		require.NoError(t, err)
+
+		entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+		require.NoError(t, err)
+
+		err = store.Save(ctx, entity)
+		require.NoError(t, err, "the idempotency key should be ignored")
+
+		_, err = store.Load(ctx, entity.ID())
+		require.NoError(t, err)
+	})
+}
+
+func TestEventStoreUnknownEvents(t *testing.T) {
+	schemaConfig := eventstore.SchemaConfig{
+		TableName: "retired_events",
+	}
+	retiredEvents := []esja.Event[eventstoretest.Entity]{
+		eventstoretest.Created{},
+	}
+
+	t.Run("sqlite", func(t *testing.T) {
+		db := testSQLiteDB(t)
+
+		config := eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+		config.SchemaAdapter = eventstore.NewSQLiteSchemaAdapterWithConfig[eventstoretest.Entity](schemaConfig)
+
+		retiredConfig := eventstore.NewSQLiteConfig[eventstoretest.Entity](retiredEvents)
+		retiredConfig.SchemaAdapter = config.SchemaAdapter
+
+		testUnknownEvents(t, db, config, retiredConfig)
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		db := testPostgresDB(t)
+
+		config := eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+		config.SchemaAdapter = eventstore.NewPostgresSchemaAdapterWithConfig[eventstoretest.Entity](schemaConfig)
+
+		retiredConfig := eventstore.NewPostgresSQLConfig[eventstoretest.Entity](retiredEvents)
+		retiredConfig.SchemaAdapter = config.SchemaAdapter
+
+		testUnknownEvents(t, db, config, retiredConfig)
+	})
+}
+
+// testUnknownEvents saves the events with the config and loads them with retiredConfig,
+// which doesn't support the Updated event anymore.
+func testUnknownEvents(
+	t *testing.T,
+	db *sql.DB,
+	config eventstore.SQLConfig[eventstoretest.Entity],
+	retiredConfig eventstore.SQLConfig[eventstoretest.Entity],
+) {
+	ctx := context.Background()
+
+	store, err := eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, config)
+	require.NoError(t, err)
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = entity.Update("retired")
+	require.NoError(t, err)
+
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	retiredStore, err := eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, retiredConfig)
+	require.NoError(t, err)
+
+	_, err = retiredStore.Load(ctx, entity.ID())
+	require.ErrorContains(t, err, "error creating new event instance")
+
+	var reported []eventstore.UndecodedEvent
+
+	retiredConfig.UnknownEventPolicy = eventstore.UnknownEventSkip
+	retiredConfig.OnUnknownEvent = func(_ context.Context, event eventstore.UndecodedEvent) error {
+		reported = append(reported, event)
+		return nil
+	}
+
+	retiredStore, err = eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, retiredConfig)
+	require.NoError(t, err)
+
+	loaded, err := retiredStore.Load(ctx, entity.ID())
+	require.NoError(t, err)
+	assert.Equal(t, entity.ID(), loaded.ID())
+	assert.Empty(t, loaded.Value())
+	assert.Equal(t, 2, loaded.Stream().Version(), "should keep the version of the skipped event")
+
+	require.Len(t, reported, 1)
+	assert.Equal(t, entity.ID(), reported[0].StreamID)
+	assert.Equal(t, 2, reported[0].StreamVersion)
+	assert.Equal(t, "Updated_v1", reported[0].EventName)
+
+	recorded, err := retiredStore.ReadByStreamType(ctx, "Entity", 0)
+	require.NoError(t, err)
+
+	var placeholders int
+	for _, e := range recorded {
+		if e.StreamID != entity.ID() {
+			continue
+		}
+
+		if unknown, ok := e.Event.(eventstore.UnknownEvent[eventstoretest.Entity]); ok {
+			assert.Equal(t, "Updated_v1", unknown.Name)
+			assert.Equal(t, 2, e.StreamVersion)
+			placeholders++
+		}
+	}
+	assert.Equal(t, 1, placeholders, "reading across streams should keep the skipped events as placeholders")
+}
+
 const (
 	host     = "localhost"
 	port     = 5432
@@ -195,14 +1480,93 @@ const (
 	dbname   = "postgres"
 )
 
+func TestEventStoreStages(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		testStages(t, testSQLiteDB(t), eventstore.NewSQLiteConfig[eventstoretest.Entity](eventstoretest.SupportedEvents()))
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		testStages(t, testPostgresDB(t), eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents()))
+	})
+}
+
+func testStages(t *testing.T, db *sql.DB, config eventstore.SQLConfig[eventstoretest.Entity]) {
+	ctx := context.Background()
+	recorder := telemetry.NewRecorder()
+
+	config.Marshaler = telemetry.NewMarshaler(config.Marshaler, recorder, recorder)
+	config.Hooks.Stage = []eventstore.StageHook{telemetry.NewStageHook(recorder, recorder)}
+
+	inner, err := eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, config)
+	require.NoError(t, err)
+	store := telemetry.NewEventStore[eventstoretest.Entity](inner, recorder, recorder)
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	_, err = store.Load(ctx, entity.ID())
+	require.NoError(t, err)
+
+	var stages []any
+	for _, s := range recorder.SpansByName(telemetry.SpanStage) {
+		stages = append(stages, s.Attributes[telemetry.AttributeStage])
+	}
+	assert.Equal(t, []any{"encode", "write", "query", "decode", "apply"}, stages)
+
+	assert.Equal(t, telemetry.SpanStage, recorder.SpansByName(telemetry.SpanMarshal)[0].Parent)
+	assert.Equal(t, telemetry.SpanStage, recorder.SpansByName(telemetry.SpanUnmarshal)[0].Parent)
+	assert.Len(t, recorder.MeasurementsByName(telemetry.MetricStageDuration), 5)
+}
+
+func TestEventStoreStoredAtTimeZone(t *testing.T) {
+	t.Run("postgres", func(t *testing.T) {
+		ctx := context.Background()
+
+		// The session's time zone is not UTC.
+		db := testPostgresDBWithOptions(t, "timezone=America/New_York")
+
+		_, err := db.Exec("DROP TABLE IF EXISTS time_zone_events")
+		require.NoError(t, err)
+
+		config := eventstore.NewPostgresSQLConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+		config.SchemaAdapter = eventstore.NewPostgresSchemaAdapterWithConfig[eventstoretest.Entity](eventstore.SchemaConfig{
+			TableName: "time_zone_events",
+		})
+
+		store, err := eventstore.NewSQLStore[eventstoretest.Entity](ctx, db, config)
+		require.NoError(t, err)
+
+		entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+		require.NoError(t, err)
+		err = store.Save(ctx, entity)
+		require.NoError(t, err)
+
+		events, err := store.ReadByStreamType(ctx, "Entity", 0)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.WithinDuration(t, time.Now(), events[0].StoredAt, time.Minute)
+
+		page, err := store.QueryEvents(ctx, eventstore.EventQuery{StoredFrom: time.Now().Add(-time.Minute)})
+		require.NoError(t, err)
+		assert.Len(t, page.Events, 1)
+	})
+}
+
 func testPostgresDB(t *testing.T) *sql.DB {
+	return testPostgresDBWithOptions(t, "")
+}
+
+func testPostgresDBWithOptions(t *testing.T, options string) *sql.DB {
 	conn := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=disable %s",
 		host,
 		port,
 		user,
 		password,
 		dbname,
+		options,
 	)
 	postgresDB, err := sql.Open("postgres", conn)
 	require.NoError(t, err)
@@ -1,10 +1,11 @@
+//go:generate go run github.com/ThreeDotsLabs/esja/cmd/esja-gen -source ../ -import postcard -entity Postcard
+
 package storage
 
 import (
 	"context"
 	"database/sql"
 
-	"github.com/ThreeDotsLabs/esja"
 	"github.com/ThreeDotsLabs/esja/eventstore"
 	"github.com/ThreeDotsLabs/esja/transport"
 	"github.com/ThreeDotsLabs/pii"
@@ -17,50 +18,45 @@ func NewDefaultMappingPostgresRepository(ctx context.Context, db *sql.DB) (event
 		ctx,
 		db,
 		eventstore.NewMappingPostgresSQLConfig[postcard.Postcard](
-			[]transport.Event[postcard.Postcard]{
-				&Created{},
-				&Addressed{},
-				&Written{},
-				&Sent{},
-			},
+			PostcardTransportEvents(),
 		),
 	)
 }
 
 func NewCustomMappingPostcardRepository(ctx context.Context, db *sql.DB) (eventstore.EventStore[postcard.Postcard], error) {
+	mapper, err := transport.NewValidatedDefaultMapper[postcard.Postcard](
+		PostcardTransportEvents(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return eventstore.NewSQLStore[postcard.Postcard](
 		ctx,
 		db,
 		eventstore.SQLConfig[postcard.Postcard]{
 			SchemaAdapter: eventstore.NewPostgresSchemaAdapter[postcard.Postcard](),
-			Mapper: transport.NewDefaultMapper(
-				[]transport.Event[postcard.Postcard]{
-					&Created{},
-					&Addressed{},
-					&Written{},
-					&Sent{},
-				},
-			),
-			Marshaler: transport.JSONMarshaler{},
+			Mapper:        mapper,
+			Marshaler:     transport.JSONMarshaler{},
 		},
 	)
 }
 
 func NewMappingAnonymizingPostcardRepository(ctx context.Context, db *sql.DB) (eventstore.EventStore[postcard.Postcard], error) {
+	mapper, err := transport.NewValidatedDefaultMapper[postcard.Postcard](
+		PostcardTransportEvents(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return eventstore.NewSQLStore[postcard.Postcard](
 		ctx,
 		db,
 		eventstore.SQLConfig[postcard.Postcard]{
 			SchemaAdapter: eventstore.NewPostgresSchemaAdapter[postcard.Postcard](),
 			Mapper: transport.NewAnonymizer[postcard.Postcard](
-				transport.NewDefaultMapper[postcard.Postcard](
-					[]transport.Event[postcard.Postcard]{
-						&Created{},
-						&Addressed{},
-						&Written{},
-						&Sent{},
-					},
-				),
+				mapper,
 				pii.NewStructAnonymizer[string, any](
 					pii.NewAESAnonymizer[string](ConstantSecretProvider{}),
 				),
@@ -75,110 +71,34 @@ func NewMappingSQLitePostcardRepository(ctx context.Context, db *sql.DB) (events
 		ctx,
 		db,
 		eventstore.NewMappingSQLiteConfig[postcard.Postcard](
-			[]transport.Event[postcard.Postcard]{
-				&Created{},
-				&Addressed{},
-				&Written{},
-				&Sent{},
-			},
+			PostcardTransportEvents(),
 		),
 	)
 }
 
 func NewGOBMappingSQLitePostcardRepository(ctx context.Context, db *sql.DB) (eventstore.EventStore[postcard.Postcard], error) {
+	mapper, err := transport.NewValidatedDefaultMapper[postcard.Postcard](
+		PostcardTransportEvents(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return eventstore.NewSQLStore[postcard.Postcard](
 		ctx,
 		db,
 		eventstore.SQLConfig[postcard.Postcard]{
 			SchemaAdapter: eventstore.NewSQLiteSchemaAdapter[postcard.Postcard](),
-			Mapper: transport.NewDefaultMapper[postcard.Postcard](
-				[]transport.Event[postcard.Postcard]{
-					&Created{},
-					&Addressed{},
-					&Written{},
-					&Sent{},
-				},
-			),
-			Marshaler: transport.GOBMarshaler{},
+			Mapper:        mapper,
+			Marshaler:     transport.GOBMarshaler{},
 		},
 	)
 }
 
-type Created struct {
-	ID string `json:"id"`
-}
-
-func (e *Created) StreamEventName() string {
-	return postcard.Created{}.EventName()
-}
-
-func (e *Created) FromStreamEvent(event esja.Event[postcard.Postcard]) {
-	created := event.(postcard.Created)
-	e.ID = created.ID
-}
-
-func (e *Created) ToStreamEvent() esja.Event[postcard.Postcard] {
-	return postcard.Created{
-		ID: e.ID,
-	}
-}
-
-type Addressed struct {
-	Sender    Address `json:"sender"`
-	Addressee Address `json:"addressee"`
-}
-
-func (e *Addressed) StreamEventName() string {
-	return postcard.Addressed{}.EventName()
-}
-
-func (e *Addressed) FromStreamEvent(event esja.Event[postcard.Postcard]) {
-	addressed := event.(postcard.Addressed)
-	e.Sender = Address(addressed.Sender)
-	e.Addressee = Address(addressed.Addressee)
-}
-
-func (e *Addressed) ToStreamEvent() esja.Event[postcard.Postcard] {
-	return postcard.Addressed{
-		Sender:    postcard.Address(e.Sender),
-		Addressee: postcard.Address(e.Addressee),
-	}
-}
-
-type Address struct {
-	Name  string `json:"name" anonymize:"true"`
-	Line1 string `json:"line1"`
-	Line2 string `json:"line2"`
-	Line3 string `json:"line3"`
-}
-
-type Written struct {
-	Content string `json:"content"`
-}
-
-func (e *Written) StreamEventName() string {
-	return postcard.Written{}.EventName()
-}
-
-func (e *Written) FromStreamEvent(event esja.Event[postcard.Postcard]) {
-	written := event.(postcard.Written)
-	e.Content = written.Content
-}
-
-func (e *Written) ToStreamEvent() esja.Event[postcard.Postcard] {
-	return postcard.Written{
-		Content: e.Content,
-	}
-}
-
-type Sent struct{}
-
-func (e *Sent) StreamEventName() string {
-	return postcard.Sent{}.EventName()
-}
-
-func (e *Sent) FromStreamEvent(_ esja.Event[postcard.Postcard]) {}
-
-func (e *Sent) ToStreamEvent() esja.Event[postcard.Postcard] {
-	return postcard.Sent{}
+func NewMappingSerializingInMemoryPostcardRepository() (eventstore.EventStore[postcard.Postcard], error) {
+	return eventstore.NewInMemoryStoreWithConfig[postcard.Postcard](
+		eventstore.NewMappingInMemoryConfig[postcard.Postcard](
+			PostcardTransportEvents(),
+		),
+	)
 }
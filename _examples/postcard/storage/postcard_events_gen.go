@@ -0,0 +1,134 @@
+// Code generated by esja-gen. DO NOT EDIT.
+
+package storage
+
+import (
+	"github.com/ThreeDotsLabs/esja"
+	"github.com/ThreeDotsLabs/esja/transport"
+
+	"postcard"
+)
+
+// PostcardTransportEvents returns the transport models of all postcard.Postcard events,
+// to be used with transport.NewValidatedDefaultMapper.
+func PostcardTransportEvents() []transport.Event[postcard.Postcard] {
+	return []transport.Event[postcard.Postcard]{
+		&Created{},
+		&Addressed{},
+		&Written{},
+		&Sent{},
+	}
+}
+
+// Created is the transport model of postcard.Created.
+type Created struct {
+	ID string `json:"id"`
+}
+
+func (e *Created) StreamEventName() string {
+	return postcard.Created{}.EventName()
+}
+
+func (e *Created) FromStreamEvent(event esja.Event[postcard.Postcard]) {
+	e.FromEvent(event.(postcard.Created))
+}
+
+func (e *Created) ToStreamEvent() esja.Event[postcard.Postcard] {
+	return e.ToEvent()
+}
+
+func (e *Created) FromEvent(event postcard.Created) {
+	e.ID = event.ID
+}
+
+func (e *Created) ToEvent() postcard.Created {
+	return postcard.Created{
+		ID: e.ID,
+	}
+}
+
+// Addressed is the transport model of postcard.Addressed.
+type Addressed struct {
+	Sender    Address `json:"sender"`
+	Addressee Address `json:"addressee"`
+}
+
+func (e *Addressed) StreamEventName() string {
+	return postcard.Addressed{}.EventName()
+}
+
+func (e *Addressed) FromStreamEvent(event esja.Event[postcard.Postcard]) {
+	e.FromEvent(event.(postcard.Addressed))
+}
+
+func (e *Addressed) ToStreamEvent() esja.Event[postcard.Postcard] {
+	return e.ToEvent()
+}
+
+func (e *Addressed) FromEvent(event postcard.Addressed) {
+	e.Sender = Address(event.Sender)
+	e.Addressee = Address(event.Addressee)
+}
+
+func (e *Addressed) ToEvent() postcard.Addressed {
+	return postcard.Addressed{
+		Sender:    postcard.Address(e.Sender),
+		Addressee: postcard.Address(e.Addressee),
+	}
+}
+
+// Address is the transport model of postcard.Address.
+type Address struct {
+	Name  string `json:"name" anonymize:"true"`
+	Line1 string `json:"line1"`
+	Line2 string `json:"line2"`
+	Line3 string `json:"line3"`
+}
+
+// Written is the transport model of postcard.Written.
+type Written struct {
+	Content string `json:"content"`
+}
+
+func (e *Written) StreamEventName() string {
+	return postcard.Written{}.EventName()
+}
+
+func (e *Written) FromStreamEvent(event esja.Event[postcard.Postcard]) {
+	e.FromEvent(event.(postcard.Written))
+}
+
+func (e *Written) ToStreamEvent() esja.Event[postcard.Postcard] {
+	return e.ToEvent()
+}
+
+func (e *Written) FromEvent(event postcard.Written) {
+	e.Content = event.Content
+}
+
+func (e *Written) ToEvent() postcard.Written {
+	return postcard.Written{
+		Content: e.Content,
+	}
+}
+
+// Sent is the transport model of postcard.Sent.
+type Sent struct{}
+
+func (e *Sent) StreamEventName() string {
+	return postcard.Sent{}.EventName()
+}
+
+func (e *Sent) FromStreamEvent(event esja.Event[postcard.Postcard]) {
+	e.FromEvent(event.(postcard.Sent))
+}
+
+func (e *Sent) ToStreamEvent() esja.Event[postcard.Postcard] {
+	return e.ToEvent()
+}
+
+func (e *Sent) FromEvent(_ postcard.Sent) {}
+
+func (e *Sent) ToEvent() postcard.Sent {
+	return postcard.Sent{}
+}
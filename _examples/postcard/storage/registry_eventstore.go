@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ThreeDotsLabs/esja/eventstore"
+	"github.com/ThreeDotsLabs/esja/transport"
+
+	"postcard"
+)
+
+func NewRegistrySQLitePostcardRepository(ctx context.Context, db *sql.DB) (eventstore.EventStore[postcard.Postcard], error) {
+	return eventstore.NewSQLStore[postcard.Postcard](
+		ctx,
+		db,
+		eventstore.SQLConfig[postcard.Postcard]{
+			SchemaAdapter: eventstore.NewSQLiteSchemaAdapter[postcard.Postcard](),
+			Mapper: transport.NewRegistry(
+				transport.Register[postcard.Postcard, postcard.Created](),
+				transport.Register[postcard.Postcard, postcard.Addressed](),
+				transport.Register[postcard.Postcard, postcard.Written](),
+				transport.Register[postcard.Postcard, postcard.Sent](),
+			),
+			Marshaler: transport.JSONMarshaler{},
+		},
+	)
+}
+
+func NewMappedRegistrySQLitePostcardRepository(ctx context.Context, db *sql.DB) (eventstore.EventStore[postcard.Postcard], error) {
+	return eventstore.NewSQLStore[postcard.Postcard](
+		ctx,
+		db,
+		eventstore.SQLConfig[postcard.Postcard]{
+			SchemaAdapter: eventstore.NewSQLiteSchemaAdapter[postcard.Postcard](),
+			Mapper: transport.NewRegistry(
+				transport.RegisterMapped[postcard.Postcard, postcard.Created, Created](),
+				transport.RegisterMapped[postcard.Postcard, postcard.Addressed, Addressed](),
+				transport.RegisterMapped[postcard.Postcard, postcard.Written, Written](),
+				transport.RegisterMapped[postcard.Postcard, postcard.Sent, Sent](),
+			),
+			Marshaler: transport.JSONMarshaler{},
+		},
+	)
+}
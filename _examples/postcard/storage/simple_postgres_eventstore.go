@@ -30,39 +30,49 @@ func NewDefaultSimplePostcardRepository(ctx context.Context, db *sql.DB) (events
 }
 
 func NewCustomSimplePostcardRepository(ctx context.Context, db *sql.DB) (eventstore.EventStore[postcard.Postcard], error) {
+	mapper, err := transport.NewValidatedNoOpMapper[postcard.Postcard](
+		[]esja.Event[postcard.Postcard]{
+			postcard.Created{},
+			postcard.Addressed{},
+			postcard.Written{},
+			postcard.Sent{},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return eventstore.NewSQLStore[postcard.Postcard](
 		ctx,
 		db,
 		eventstore.SQLConfig[postcard.Postcard]{
 			SchemaAdapter: eventstore.NewPostgresSchemaAdapter[postcard.Postcard](),
-			Mapper: transport.NewNoOpMapper(
-				[]esja.Event[postcard.Postcard]{
-					postcard.Created{},
-					postcard.Addressed{},
-					postcard.Written{},
-					postcard.Sent{},
-				},
-			),
-			Marshaler: transport.JSONMarshaler{},
+			Mapper:        mapper,
+			Marshaler:     transport.JSONMarshaler{},
 		},
 	)
 }
 
 func NewSimpleAnonymizingPostcardRepository(ctx context.Context, db *sql.DB) (eventstore.EventStore[postcard.Postcard], error) {
+	mapper, err := transport.NewValidatedNoOpMapper[postcard.Postcard](
+		[]esja.Event[postcard.Postcard]{
+			postcard.Created{},
+			postcard.Addressed{},
+			postcard.Written{},
+			postcard.Sent{},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return eventstore.NewSQLStore[postcard.Postcard](
 		ctx,
 		db,
 		eventstore.SQLConfig[postcard.Postcard]{
 			SchemaAdapter: eventstore.NewPostgresSchemaAdapter[postcard.Postcard](),
 			Mapper: transport.NewAnonymizer[postcard.Postcard](
-				transport.NewNoOpMapper[postcard.Postcard](
-					[]esja.Event[postcard.Postcard]{
-						postcard.Created{},
-						postcard.Addressed{},
-						postcard.Written{},
-						postcard.Sent{},
-					},
-				),
+				mapper,
 				pii.NewStructAnonymizer[string, any](
 					pii.NewAESAnonymizer[string](ConstantSecretProvider{}),
 				),
@@ -88,24 +98,42 @@ func NewSimpleSQLitePostcardRepository(ctx context.Context, db *sql.DB) (eventst
 }
 
 func NewGOBSQLitePostcardRepository(ctx context.Context, db *sql.DB) (eventstore.EventStore[postcard.Postcard], error) {
+	mapper, err := transport.NewValidatedNoOpMapper[postcard.Postcard](
+		[]esja.Event[postcard.Postcard]{
+			postcard.Created{},
+			postcard.Addressed{},
+			postcard.Written{},
+			postcard.Sent{},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return eventstore.NewSQLStore[postcard.Postcard](
 		ctx,
 		db,
 		eventstore.SQLConfig[postcard.Postcard]{
 			SchemaAdapter: eventstore.NewSQLiteSchemaAdapter[postcard.Postcard](),
-			Mapper: transport.NewNoOpMapper[postcard.Postcard](
-				[]esja.Event[postcard.Postcard]{
-					postcard.Created{},
-					postcard.Addressed{},
-					postcard.Written{},
-					postcard.Sent{},
-				},
-			),
-			Marshaler: transport.GOBMarshaler{},
+			Mapper:        mapper,
+			Marshaler:     transport.GOBMarshaler{},
 		},
 	)
 }
 
+func NewSerializingInMemoryPostcardRepository() (eventstore.EventStore[postcard.Postcard], error) {
+	return eventstore.NewInMemoryStoreWithConfig[postcard.Postcard](
+		eventstore.NewInMemoryConfig[postcard.Postcard](
+			[]esja.Event[postcard.Postcard]{
+				postcard.Created{},
+				postcard.Addressed{},
+				postcard.Written{},
+				postcard.Sent{},
+			},
+		),
+	)
+}
+
 type ConstantSecretProvider struct{}
 
 func (c ConstantSecretProvider) SecretForKey(_ context.Context, id string) ([]byte, error) {
@@ -0,0 +1,633 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	esjaImportPath      = "github.com/ThreeDotsLabs/esja"
+	transportImportPath = "github.com/ThreeDotsLabs/esja/transport"
+)
+
+type config struct {
+	sourceDir   string
+	importPath  string
+	entity      string
+	packageName string
+}
+
+func (c config) validate() error {
+	if c.sourceDir == "" {
+		return fmt.Errorf("source directory is required")
+	}
+	if c.importPath == "" {
+		return fmt.Errorf("import path is required")
+	}
+	if c.entity == "" {
+		return fmt.Errorf("entity is required")
+	}
+	if c.packageName == "" {
+		return fmt.Errorf("package name is required")
+	}
+	return nil
+}
+
+// domainType is a type declared in the domain package.
+type domainType struct {
+	name    string
+	spec    *ast.TypeSpec
+	imports map[string]string
+	methods map[string]*ast.FuncDecl
+}
+
+// domainPackage is the parsed domain package.
+type domainPackage struct {
+	name  string
+	types map[string]*domainType
+	order []string
+}
+
+func parseDomainPackage(dir string) (*domainPackage, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileNames []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		fileNames = append(fileNames, name)
+	}
+	sort.Strings(fileNames)
+
+	pkg := &domainPackage{
+		types: map[string]*domainType{},
+	}
+
+	fset := token.NewFileSet()
+	var methods []*ast.FuncDecl
+
+	for _, name := range fileNames {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+
+		if pkg.name == "" {
+			pkg.name = file.Name.Name
+		} else if pkg.name != file.Name.Name {
+			return nil, fmt.Errorf("found packages %s and %s in %s", pkg.name, file.Name.Name, dir)
+		}
+
+		imports := map[string]string{}
+		for _, spec := range file.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			alias := path.Base(importPath)
+			if spec.Name != nil {
+				alias = spec.Name.Name
+			}
+
+			imports[alias] = importPath
+		}
+
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				if d.Tok != token.TYPE {
+					continue
+				}
+
+				for _, spec := range d.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					pkg.types[typeSpec.Name.Name] = &domainType{
+						name:    typeSpec.Name.Name,
+						spec:    typeSpec,
+						imports: imports,
+						methods: map[string]*ast.FuncDecl{},
+					}
+					pkg.order = append(pkg.order, typeSpec.Name.Name)
+				}
+			case *ast.FuncDecl:
+				if d.Recv != nil && len(d.Recv.List) == 1 {
+					methods = append(methods, d)
+				}
+			}
+		}
+	}
+
+	if pkg.name == "" {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+
+	for _, m := range methods {
+		receiver, _ := receiverType(m.Recv.List[0].Type)
+		if t, ok := pkg.types[receiver]; ok {
+			t.methods[m.Name.Name] = m
+		}
+	}
+
+	return pkg, nil
+}
+
+// receiverType returns the name of the receiver type and if it's a pointer.
+func receiverType(expr ast.Expr) (string, bool) {
+	pointer := false
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+		pointer = true
+	}
+
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+
+	return ident.Name, pointer
+}
+
+// events returns the types with EventName and ApplyTo methods of the entity, in the source order.
+func (p *domainPackage) events(entity string) []*domainType {
+	var events []*domainType
+	for _, name := range p.order {
+		t := p.types[name]
+		if isEventNameMethod(t.methods["EventName"]) && isApplyToMethod(t.methods["ApplyTo"], entity) {
+			events = append(events, t)
+		}
+	}
+
+	return events
+}
+
+func isEventNameMethod(m *ast.FuncDecl) bool {
+	if m == nil || m.Type.Params.NumFields() != 0 || m.Type.Results.NumFields() != 1 {
+		return false
+	}
+
+	result, ok := m.Type.Results.List[0].Type.(*ast.Ident)
+	return ok && result.Name == "string"
+}
+
+func isApplyToMethod(m *ast.FuncDecl, entity string) bool {
+	if m == nil || m.Type.Params.NumFields() != 1 || m.Type.Results.NumFields() != 1 {
+		return false
+	}
+
+	param, pointer := receiverType(m.Type.Params.List[0].Type)
+	if !pointer || param != entity {
+		return false
+	}
+
+	result, ok := m.Type.Results.List[0].Type.(*ast.Ident)
+	return ok && result.Name == "error"
+}
+
+// transportField is a field of a generated transport struct.
+type transportField struct {
+	name string
+	typ  string
+	tag  string
+
+	// conversion is the transport type the domain value is converted to. Empty if not converted.
+	conversion string
+}
+
+// transportStruct is a generated transport struct.
+type transportStruct struct {
+	name   string
+	fields []transportField
+}
+
+type generator struct {
+	cfg     config
+	pkg     *domainPackage
+	imports map[string]string
+
+	nested     []transportStruct
+	nestedSeen map[string]bool
+}
+
+func generate(cfg config) ([]byte, error) {
+	err := cfg.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, err := parseDomainPackage(cfg.sourceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := pkg.types[cfg.entity]; !ok {
+		return nil, fmt.Errorf("entity %s not found in package %s", cfg.entity, pkg.name)
+	}
+
+	events := pkg.events(cfg.entity)
+	if len(events) == 0 {
+		return nil, fmt.Errorf("no events of %s found in package %s", cfg.entity, pkg.name)
+	}
+
+	g := &generator{
+		cfg: cfg,
+		pkg: pkg,
+		imports: map[string]string{
+			esjaImportPath:      "esja",
+			transportImportPath: "transport",
+			cfg.importPath:      pkg.name,
+		},
+		nestedSeen: map[string]bool{},
+	}
+
+	return g.generate(events)
+}
+
+func (g *generator) generate(events []*domainType) ([]byte, error) {
+	body := &bytes.Buffer{}
+
+	entity := g.qualified(g.cfg.entity)
+	eventsFunc := g.cfg.entity + "TransportEvents"
+
+	fmt.Fprintf(body, "// %s returns the transport models of all %s events,\n", eventsFunc, entity)
+	fmt.Fprintf(body, "// to be used with transport.NewValidatedDefaultMapper.\n")
+	fmt.Fprintf(body, "func %s() []transport.Event[%s] {\n", eventsFunc, entity)
+	fmt.Fprintf(body, "return []transport.Event[%s]{\n", entity)
+	for _, e := range events {
+		fmt.Fprintf(body, "&%s{},\n", e.name)
+	}
+	fmt.Fprintf(body, "}\n}\n")
+
+	names := map[string]bool{eventsFunc: true}
+
+	for _, e := range events {
+		structType, ok := e.spec.Type.(*ast.StructType)
+		if !ok || e.spec.TypeParams != nil {
+			return nil, fmt.Errorf("event %s is not a struct", e.name)
+		}
+
+		g.nested = nil
+
+		event, err := g.transportStruct(e, structType, true)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range append([]transportStruct{event}, g.nested...) {
+			if names[s.name] {
+				return nil, fmt.Errorf("duplicate generated name %s", s.name)
+			}
+			names[s.name] = true
+		}
+
+		g.writeEvent(body, e, event)
+
+		for _, s := range g.nested {
+			fmt.Fprintf(body, "\n// %s is the transport model of %s.\n", s.name, g.qualified(s.name))
+			writeStruct(body, s)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "// Code generated by esja-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(out, "package %s\n\n", g.cfg.packageName)
+	g.writeImports(out)
+	out.Write(body.Bytes())
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error formatting generated code: %w", err)
+	}
+
+	return formatted, nil
+}
+
+func (g *generator) writeImports(out *bytes.Buffer) {
+	// Standard library, other packages and the domain package are imported in separate groups.
+	groups := make([][]string, 3)
+	for importPath, alias := range g.imports {
+		spec := strconv.Quote(importPath)
+		if alias != path.Base(importPath) {
+			spec = alias + " " + spec
+		}
+
+		switch {
+		case importPath == g.cfg.importPath:
+			groups[2] = append(groups[2], spec)
+		case strings.Contains(strings.Split(importPath, "/")[0], "."):
+			groups[1] = append(groups[1], spec)
+		default:
+			groups[0] = append(groups[0], spec)
+		}
+	}
+
+	fmt.Fprintf(out, "import (\n")
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+
+		sort.Strings(group)
+		for _, spec := range group {
+			fmt.Fprintf(out, "%s\n", spec)
+		}
+		fmt.Fprintf(out, "\n")
+	}
+	fmt.Fprintf(out, ")\n\n")
+}
+
+func (g *generator) writeEvent(body *bytes.Buffer, e *domainType, s transportStruct) {
+	domainEvent := g.qualified(e.name)
+	entity := g.qualified(g.cfg.entity)
+
+	fmt.Fprintf(body, "\n// %s is the transport model of %s.\n", s.name, domainEvent)
+	writeStruct(body, s)
+
+	eventNameReceiver := domainEvent + "{}"
+	if _, pointer := receiverType(e.methods["EventName"].Recv.List[0].Type); pointer {
+		eventNameReceiver = "(&" + domainEvent + "{})"
+	}
+
+	fmt.Fprintf(body, "\nfunc (e *%s) StreamEventName() string {\n", s.name)
+	fmt.Fprintf(body, "return %s.EventName()\n}\n", eventNameReceiver)
+
+	fmt.Fprintf(body, "\nfunc (e *%s) FromStreamEvent(event esja.Event[%s]) {\n", s.name, entity)
+	fmt.Fprintf(body, "e.FromEvent(event.(%s))\n}\n", domainEvent)
+
+	fmt.Fprintf(body, "\nfunc (e *%s) ToStreamEvent() esja.Event[%s] {\n", s.name, entity)
+	fmt.Fprintf(body, "return e.ToEvent()\n}\n")
+
+	if len(s.fields) == 0 {
+		fmt.Fprintf(body, "\nfunc (e *%s) FromEvent(_ %s) {}\n", s.name, domainEvent)
+	} else {
+		fmt.Fprintf(body, "\nfunc (e *%s) FromEvent(event %s) {\n", s.name, domainEvent)
+		for _, f := range s.fields {
+			if f.conversion != "" {
+				fmt.Fprintf(body, "e.%s = %s(event.%s)\n", f.name, f.conversion, f.name)
+			} else {
+				fmt.Fprintf(body, "e.%s = event.%s\n", f.name, f.name)
+			}
+		}
+		fmt.Fprintf(body, "}\n")
+	}
+
+	fmt.Fprintf(body, "\nfunc (e *%s) ToEvent() %s {\n", s.name, domainEvent)
+	if len(s.fields) == 0 {
+		fmt.Fprintf(body, "return %s{}\n}\n", domainEvent)
+		return
+	}
+
+	fmt.Fprintf(body, "return %s{\n", domainEvent)
+	for _, f := range s.fields {
+		if f.conversion != "" {
+			fmt.Fprintf(body, "%s: %s(e.%s),\n", f.name, g.qualified(f.conversion), f.name)
+		} else {
+			fmt.Fprintf(body, "%s: e.%s,\n", f.name, f.name)
+		}
+	}
+	fmt.Fprintf(body, "}\n}\n")
+}
+
+func writeStruct(body *bytes.Buffer, s transportStruct) {
+	if len(s.fields) == 0 {
+		fmt.Fprintf(body, "type %s struct{}\n", s.name)
+		return
+	}
+
+	fmt.Fprintf(body, "type %s struct {\n", s.name)
+	for _, f := range s.fields {
+		fmt.Fprintf(body, "%s %s `%s`\n", f.name, f.typ, f.tag)
+	}
+	fmt.Fprintf(body, "}\n")
+}
+
+// transportStruct returns the transport struct of the domain struct.
+// Fields of event structs using domain struct types get their own transport structs.
+func (g *generator) transportStruct(t *domainType, structType *ast.StructType, event bool) (transportStruct, error) {
+	s := transportStruct{
+		name: t.name,
+	}
+
+	for _, field := range structType.Fields.List {
+		names := make([]string, len(field.Names))
+		for i, n := range field.Names {
+			names[i] = n.Name
+		}
+
+		if len(names) == 0 {
+			embedded, _ := receiverType(field.Type)
+			if sel, ok := field.Type.(*ast.SelectorExpr); ok {
+				embedded = sel.Sel.Name
+			}
+			names = []string{embedded}
+		}
+
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			unquoted, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return transportStruct{}, err
+			}
+			tag = reflect.StructTag(unquoted)
+		}
+
+		for _, name := range names {
+			if !ast.IsExported(name) {
+				return transportStruct{}, fmt.Errorf("field %s of %s is not exported", name, t.name)
+			}
+
+			f := transportField{
+				name: name,
+				tag:  jsonTag(name, tag),
+			}
+
+			nested, ok := g.nestedStruct(field.Type)
+			if event && ok {
+				f.typ = nested.name
+				f.conversion = nested.name
+
+				err := g.addNested(nested)
+				if err != nil {
+					return transportStruct{}, err
+				}
+			} else {
+				typ, err := g.typeString(field.Type, t.imports)
+				if err != nil {
+					return transportStruct{}, fmt.Errorf("field %s of %s: %w", name, t.name, err)
+				}
+				f.typ = typ
+			}
+
+			s.fields = append(s.fields, f)
+		}
+	}
+
+	return s, nil
+}
+
+// nestedStruct returns the domain struct type used directly as the field type.
+func (g *generator) nestedStruct(expr ast.Expr) (*domainType, bool) {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return nil, false
+	}
+
+	t, ok := g.pkg.types[ident.Name]
+	if !ok || t.spec.TypeParams != nil || t.spec.Assign.IsValid() {
+		return nil, false
+	}
+
+	_, ok = t.spec.Type.(*ast.StructType)
+	return t, ok
+}
+
+func (g *generator) addNested(t *domainType) error {
+	if g.nestedSeen[t.name] {
+		return nil
+	}
+	g.nestedSeen[t.name] = true
+
+	s, err := g.transportStruct(t, t.spec.Type.(*ast.StructType), false)
+	if err != nil {
+		return err
+	}
+
+	g.nested = append(g.nested, s)
+
+	return nil
+}
+
+// typeString returns the type expression valid in the generated package.
+func (g *generator) typeString(expr ast.Expr, imports map[string]string) (string, error) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if _, ok := g.pkg.types[e.Name]; ok {
+			if !ast.IsExported(e.Name) {
+				return "", fmt.Errorf("type %s is not exported", e.Name)
+			}
+			return g.qualified(e.Name), nil
+		}
+		if types.Universe.Lookup(e.Name) != nil {
+			return e.Name, nil
+		}
+		return "", fmt.Errorf("unknown type %s", e.Name)
+	case *ast.SelectorExpr:
+		pkg, ok := e.X.(*ast.Ident)
+		if !ok {
+			return "", fmt.Errorf("unsupported type expression")
+		}
+
+		importPath, ok := imports[pkg.Name]
+		if !ok {
+			return "", fmt.Errorf("unknown package %s", pkg.Name)
+		}
+
+		alias, ok := g.imports[importPath]
+		if !ok {
+			alias = pkg.Name
+			for _, used := range g.imports {
+				if used == alias {
+					return "", fmt.Errorf("package alias %s is used by two packages", alias)
+				}
+			}
+			g.imports[importPath] = alias
+		}
+
+		return alias + "." + e.Sel.Name, nil
+	case *ast.StarExpr:
+		x, err := g.typeString(e.X, imports)
+		return "*" + x, err
+	case *ast.ArrayType:
+		elem, err := g.typeString(e.Elt, imports)
+		if err != nil {
+			return "", err
+		}
+
+		if e.Len == nil {
+			return "[]" + elem, nil
+		}
+
+		length, ok := e.Len.(*ast.BasicLit)
+		if !ok {
+			return "", fmt.Errorf("unsupported array length")
+		}
+
+		return "[" + length.Value + "]" + elem, nil
+	case *ast.MapType:
+		key, err := g.typeString(e.Key, imports)
+		if err != nil {
+			return "", err
+		}
+
+		value, err := g.typeString(e.Value, imports)
+		if err != nil {
+			return "", err
+		}
+
+		return "map[" + key + "]" + value, nil
+	case *ast.InterfaceType:
+		if e.Methods.NumFields() == 0 {
+			return "interface{}", nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported type expression")
+}
+
+// qualified returns the name of the domain type qualified with the domain package name.
+func (g *generator) qualified(name string) string {
+	return g.pkg.name + "." + name
+}
+
+// jsonTag returns the tag with the json key added, if it's missing.
+// Other keys of the domain tag are kept, e.g. for the anonymizer.
+func jsonTag(fieldName string, tag reflect.StructTag) string {
+	if _, ok := tag.Lookup("json"); ok {
+		return string(tag)
+	}
+
+	jsonKey := `json:"` + snakeCase(fieldName) + `"`
+	if tag == "" {
+		return jsonKey
+	}
+
+	return jsonKey + " " + string(tag)
+}
+
+// snakeCase converts the field name to snake_case, e.g. AddresseeID to addressee_id.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	b := strings.Builder{}
+
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			previous := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				b.WriteRune('_')
+			}
+		}
+
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGenerate(t *testing.T) {
+	generated, err := generate(config{
+		sourceDir:   filepath.Join("testdata", "shop"),
+		importPath:  "example.com/shop",
+		entity:      "Order",
+		packageName: "storage",
+	})
+	require.NoError(t, err)
+
+	golden := filepath.Join("testdata", "shop.golden")
+
+	if *update {
+		err = os.WriteFile(golden, generated, 0o644)
+		require.NoError(t, err)
+	}
+
+	expected, err := os.ReadFile(golden)
+	require.NoError(t, err)
+
+	assert.Equal(t, string(expected), string(generated))
+}
+
+func TestGenerate_errors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		cfg           config
+		expectedError string
+	}{
+		{
+			name: "unexported_field",
+			cfg: config{
+				sourceDir:   filepath.Join("testdata", "unexported"),
+				importPath:  "example.com/unexported",
+				entity:      "Order",
+				packageName: "storage",
+			},
+			expectedError: "field orderID of OrderPlaced is not exported",
+		},
+		{
+			name: "unknown_entity",
+			cfg: config{
+				sourceDir:   filepath.Join("testdata", "shop"),
+				importPath:  "example.com/shop",
+				entity:      "Invoice",
+				packageName: "storage",
+			},
+			expectedError: "entity Invoice not found in package shop",
+		},
+		{
+			name: "missing_package_name",
+			cfg: config{
+				sourceDir:  filepath.Join("testdata", "shop"),
+				importPath: "example.com/shop",
+				entity:     "Order",
+			},
+			expectedError: "package name is required",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := generate(tc.cfg)
+			assert.EqualError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	testCases := map[string]string{
+		"ID":            "id",
+		"OrderID":       "order_id",
+		"Line1":         "line1",
+		"HTTPServer":    "http_server",
+		"PlacedAt":      "placed_at",
+		"AddresseeName": "addressee_name",
+	}
+
+	for name, expected := range testCases {
+		assert.Equal(t, expected, snakeCase(name), name)
+	}
+}
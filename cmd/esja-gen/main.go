@@ -0,0 +1,74 @@
+// Command esja-gen generates transport models of events for transport.NewValidatedDefaultMapper.
+//
+// It reads the events of the entity from the domain package (types with EventName
+// and ApplyTo methods) and writes a file with:
+//   - a transport struct with JSON tags for each event,
+//   - a transport struct for each struct type used directly by event fields,
+//   - mapping methods implementing transport.Event and the typed
+//     FromEvent and ToEvent methods used by transport.RegisterMapped,
+//   - a function returning all transport events, to pass to transport.NewValidatedDefaultMapper.
+//
+// The file is fully regenerated on each run, so it's kept in sync with the events.
+//
+// Usage with go generate:
+//
+//	//go:generate go run github.com/ThreeDotsLabs/esja/cmd/esja-gen -source ../ -import postcard -entity Postcard
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func main() {
+	err := run(os.Args[1:])
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "esja-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("esja-gen", flag.ContinueOnError)
+
+	cfg := config{}
+	flags.StringVar(&cfg.sourceDir, "source", "", "directory of the domain package with events (required)")
+	flags.StringVar(&cfg.importPath, "import", "", "import path of the domain package (required)")
+	flags.StringVar(&cfg.entity, "entity", "", "name of the entity type the events are applied to (required)")
+	flags.StringVar(&cfg.packageName, "package", os.Getenv("GOPACKAGE"), "name of the generated package (defaults to $GOPACKAGE)")
+
+	output := flags.String("output", "", "generated file (defaults to <entity>_events_gen.go)")
+	check := flags.Bool("check", false, "don't write the file, fail if it's not up to date")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		*output = strings.ToLower(cfg.entity) + "_events_gen.go"
+	}
+
+	generated, err := generate(cfg)
+	if err != nil {
+		return err
+	}
+
+	if *check {
+		existing, err := os.ReadFile(*output)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(existing, generated) {
+			return fmt.Errorf("%s is not up to date, run go generate", *output)
+		}
+
+		return nil
+	}
+
+	return os.WriteFile(*output, generated, 0o644)
+}
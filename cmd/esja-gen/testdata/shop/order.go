@@ -0,0 +1,75 @@
+package shop
+
+import (
+	"time"
+)
+
+type Order struct {
+	id     string
+	items  []Item
+	placed time.Time
+}
+
+type Item struct {
+	SKU      string `json:"sku"`
+	Quantity int
+}
+
+type Customer struct {
+	Name  string `anonymize:"true"`
+	Email string `anonymize:"true"`
+}
+
+type Metadata struct {
+	Tags map[string]string
+}
+
+type OrderPlaced struct {
+	OrderID  string
+	Customer Customer
+	Items    []Item
+	PlacedAt time.Time
+	Metadata
+}
+
+func (OrderPlaced) EventName() string {
+	return "OrderPlaced_v1"
+}
+
+func (e OrderPlaced) ApplyTo(o *Order) error {
+	o.id = e.OrderID
+	o.items = e.Items
+	o.placed = e.PlacedAt
+	return nil
+}
+
+type ItemAdded struct {
+	Item     Item
+	Discount *float64
+}
+
+func (*ItemAdded) EventName() string {
+	return "ItemAdded_v1"
+}
+
+func (e *ItemAdded) ApplyTo(o *Order) error {
+	o.items = append(o.items, e.Item)
+	return nil
+}
+
+type OrderCancelled struct{}
+
+func (OrderCancelled) EventName() string {
+	return "OrderCancelled_v1"
+}
+
+func (OrderCancelled) ApplyTo(*Order) error {
+	return nil
+}
+
+// NotAnEvent has no ApplyTo method.
+type NotAnEvent struct{}
+
+func (NotAnEvent) EventName() string {
+	return "NotAnEvent_v1"
+}
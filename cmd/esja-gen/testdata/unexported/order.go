@@ -0,0 +1,15 @@
+package unexported
+
+type Order struct{}
+
+type OrderPlaced struct {
+	orderID string
+}
+
+func (OrderPlaced) EventName() string {
+	return "OrderPlaced_v1"
+}
+
+func (e OrderPlaced) ApplyTo(*Order) error {
+	return nil
+}
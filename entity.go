@@ -36,6 +36,12 @@ type Entity[T any] interface {
 // At the same time the entity's internal Stream is initialised,
 // so it can record new upcoming stream.
 func NewEntity[T Entity[T]](id string, eventsSlice []VersionedEvent[T]) (*T, error) {
+	return NewEntityWithStreamType(id, "", eventsSlice)
+}
+
+// NewEntityWithStreamType works like NewEntity,
+// but also sets the type of the entity's internal Stream.
+func NewEntityWithStreamType[T Entity[T]](id string, streamType string, eventsSlice []VersionedEvent[T]) (*T, error) {
 	var t T
 
 	stream, err := newStream(id, eventsSlice)
@@ -43,6 +49,8 @@ func NewEntity[T Entity[T]](id string, eventsSlice []VersionedEvent[T]) (*T, err
 		return nil, err
 	}
 
+	stream.streamType = streamType
+
 	eventsSlice = stream.PopEvents()
 
 	target := t.NewWithStream(stream)
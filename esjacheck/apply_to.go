@@ -0,0 +1,110 @@
+package esjacheck
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// ApplyToAnalyzer reports ApplyTo methods doing I/O or returning errors.
+var ApplyToAnalyzer = &analysis.Analyzer{
+	Name: "esjaapplyto",
+	Doc: `report ApplyTo methods doing I/O or returning errors
+
+ApplyTo is called each time the entity is loaded, so it should only change the state of the entity.
+The event is a fact that already happened, so the validation belongs to the method recording it,
+and ApplyTo should return nil.`,
+	Run: runApplyTo,
+}
+
+// ioPackages are the packages whose functions and methods do I/O.
+var ioPackages = map[string]bool{
+	"database/sql": true,
+	"io":           true,
+	"io/ioutil":    true,
+	"log":          true,
+	"log/slog":     true,
+	"net":          true,
+	"net/http":     true,
+	"net/rpc":      true,
+	"os":           true,
+	"os/exec":      true,
+	"syscall":      true,
+}
+
+// ioFmtFuncs are the functions of the fmt package doing I/O.
+var ioFmtFuncs = map[string]bool{
+	"Fprint":   true,
+	"Fprintf":  true,
+	"Fprintln": true,
+	"Fscan":    true,
+	"Fscanf":   true,
+	"Fscanln":  true,
+	"Print":    true,
+	"Printf":   true,
+	"Println":  true,
+	"Scan":     true,
+	"Scanf":    true,
+	"Scanln":   true,
+}
+
+func runApplyTo(pass *analysis.Pass) (any, error) {
+	eventMethods(pass, "ApplyTo", func(decl *ast.FuncDecl, _ *types.Func) {
+		ast.Inspect(decl.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+
+			fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+			if ok && isIO(fn) {
+				pass.Reportf(call.Pos(), "ApplyTo should not do I/O, but calls %s", fn.FullName())
+			}
+
+			return true
+		})
+
+		inspectBody(decl.Body, func(n ast.Node) {
+			ret, ok := n.(*ast.ReturnStmt)
+			if !ok || len(ret.Results) != 1 || isNilOrApplyTo(pass, ret.Results[0]) {
+				return
+			}
+
+			pass.Reportf(
+				ret.Results[0].Pos(),
+				"ApplyTo should only change the state and return nil, validate before recording the event",
+			)
+		})
+	})
+
+	return nil, nil
+}
+
+func isIO(fn *types.Func) bool {
+	if fn.Pkg() == nil {
+		return false
+	}
+
+	if fn.Pkg().Path() == "fmt" {
+		return ioFmtFuncs[fn.Name()]
+	}
+
+	return ioPackages[fn.Pkg().Path()]
+}
+
+// isNilOrApplyTo reports whether the returned error is nil or comes from ApplyTo of another event.
+func isNilOrApplyTo(pass *analysis.Pass, expr ast.Expr) bool {
+	if pass.TypesInfo.Types[expr].IsNil() {
+		return true
+	}
+
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	return ok && fn.Name() == "ApplyTo"
+}
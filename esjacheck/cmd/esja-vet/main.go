@@ -0,0 +1,16 @@
+// Command esja-vet runs the esjacheck analyzers.
+//
+// It can be used standalone or as the vet tool:
+//
+//	go vet -vettool=$(which esja-vet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/multichecker"
+
+	"github.com/ThreeDotsLabs/esja/esjacheck"
+)
+
+func main() {
+	multichecker.Main(esjacheck.Analyzers...)
+}
@@ -0,0 +1,145 @@
+// Package esjacheck provides analyzers reporting common mistakes in the domain code using esja.
+//
+// The analyzers can be run with go vet using the esja-vet command:
+//
+//	go install github.com/ThreeDotsLabs/esja/esjacheck/cmd/esja-vet@latest
+//	go vet -vettool=$(which esja-vet) ./...
+//
+// It's a separate module, so the esja module doesn't depend on golang.org/x/tools.
+package esjacheck
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+const (
+	esjaPath      = "github.com/ThreeDotsLabs/esja"
+	transportPath = esjaPath + "/transport"
+)
+
+// Analyzers are all analyzers of the package.
+var Analyzers = []*analysis.Analyzer{
+	RecordAnalyzer,
+	EventNameAnalyzer,
+	RegistrationAnalyzer,
+	ApplyToAnalyzer,
+}
+
+// namedFrom returns t as the named type if it's the type of the name from the package.
+// Instances of generic types are matched by their origin.
+func namedFrom(t types.Type, pkgPath string, name string) (*types.Named, bool) {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok {
+		return nil, false
+	}
+
+	obj := named.Origin().Obj()
+	if obj.Pkg() == nil || obj.Pkg().Path() != pkgPath || obj.Name() != name {
+		return nil, false
+	}
+
+	return named, true
+}
+
+// eventEntity returns the entity type T if t or a pointer to t implements esja.Event[T].
+func eventEntity(t types.Type) (types.Type, bool) {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || types.IsInterface(named) {
+		return nil, false
+	}
+
+	methods := types.NewMethodSet(types.NewPointer(named))
+
+	eventName, ok := methodSignature(methods, "EventName")
+	if !ok || eventName.Params().Len() != 0 || eventName.Results().Len() != 1 ||
+		!types.Identical(eventName.Results().At(0).Type(), types.Typ[types.String]) {
+		return nil, false
+	}
+
+	applyTo, ok := methodSignature(methods, "ApplyTo")
+	if !ok || applyTo.Params().Len() != 1 || applyTo.Results().Len() != 1 ||
+		!types.Identical(applyTo.Results().At(0).Type(), types.Universe.Lookup("error").Type()) {
+		return nil, false
+	}
+
+	entity, ok := applyTo.Params().At(0).Type().(*types.Pointer)
+	if !ok {
+		return nil, false
+	}
+
+	return entity.Elem(), true
+}
+
+func methodSignature(methods *types.MethodSet, name string) (*types.Signature, bool) {
+	selection := methods.Lookup(nil, name)
+	if selection == nil {
+		return nil, false
+	}
+
+	signature, ok := selection.Type().(*types.Signature)
+	return signature, ok
+}
+
+// eventMethods calls fn for each declaration of the method of an event type.
+func eventMethods(pass *analysis.Pass, name string, fn func(decl *ast.FuncDecl, method *types.Func)) {
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Recv == nil || funcDecl.Body == nil || funcDecl.Name.Name != name {
+				continue
+			}
+
+			method, ok := pass.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+
+			recv := method.Type().(*types.Signature).Recv()
+			if _, ok := eventEntity(recv.Type()); !ok {
+				continue
+			}
+
+			fn(funcDecl, method)
+		}
+	}
+}
+
+// funcDecl returns the declaration of the function from the analyzed package.
+func funcDecl(pass *analysis.Pass, fn *types.Func) *ast.FuncDecl {
+	if fn.Pkg() != pass.Pkg {
+		return nil
+	}
+
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if ok && funcDecl.Body != nil && pass.TypesInfo.Defs[funcDecl.Name] == fn {
+				return funcDecl
+			}
+		}
+	}
+
+	return nil
+}
+
+// inspectBody calls fn for the nodes of the function body, skipping nested function literals.
+func inspectBody(body *ast.BlockStmt, fn func(n ast.Node)) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+
+		if n != nil {
+			fn(n)
+		}
+
+		return true
+	})
+}
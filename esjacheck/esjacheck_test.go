@@ -0,0 +1,25 @@
+package esjacheck_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/ThreeDotsLabs/esja/esjacheck"
+)
+
+func TestRecordAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), esjacheck.RecordAnalyzer, "record")
+}
+
+func TestEventNameAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), esjacheck.EventNameAnalyzer, "eventname")
+}
+
+func TestApplyToAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), esjacheck.ApplyToAnalyzer, "applyto")
+}
+
+func TestRegistrationAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), esjacheck.RegistrationAnalyzer, "domain", "storage", "registry")
+}
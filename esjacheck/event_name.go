@@ -0,0 +1,45 @@
+package esjacheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"regexp"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var versionSuffix = regexp.MustCompile(`[_.-]v[0-9]+$`)
+
+// EventNameAnalyzer reports event names without a version suffix.
+var EventNameAnalyzer = &analysis.Analyzer{
+	Name: "esjaeventname",
+	Doc: `report event names without a version suffix
+
+EventName should identify the event and the version of its schema, like "FooCreated_v1",
+so a new version of the event can be introduced next to the stored ones.`,
+	Run: runEventName,
+}
+
+func runEventName(pass *analysis.Pass) (any, error) {
+	eventMethods(pass, "EventName", func(decl *ast.FuncDecl, _ *types.Func) {
+		inspectBody(decl.Body, func(n ast.Node) {
+			ret, ok := n.(*ast.ReturnStmt)
+			if !ok || len(ret.Results) != 1 {
+				return
+			}
+
+			value := pass.TypesInfo.Types[ret.Results[0]].Value
+			if value == nil || value.Kind() != constant.String {
+				return
+			}
+
+			name := constant.StringVal(value)
+			if !versionSuffix.MatchString(name) {
+				pass.Reportf(ret.Results[0].Pos(), "event name %q has no version suffix, like %q", name, name+"_v1")
+			}
+		})
+	})
+
+	return nil, nil
+}
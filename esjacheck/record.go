@@ -0,0 +1,89 @@
+package esjacheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// RecordAnalyzer reports Stream.Record calls applying the event to a copy of the entity.
+var RecordAnalyzer = &analysis.Analyzer{
+	Name: "esjarecord",
+	Doc: `report Stream.Record calls applying events to a copy of the entity
+
+A method with a value receiver works on a copy of the entity,
+so recording an event with a pointer to the receiver changes only the copy.
+The event is queued in the stream, but the entity's state is lost.`,
+	Run: runRecord,
+}
+
+func runRecord(pass *analysis.Pass) (any, error) {
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Recv == nil || funcDecl.Body == nil || len(funcDecl.Recv.List[0].Names) == 0 {
+				continue
+			}
+
+			recv := pass.TypesInfo.Defs[funcDecl.Recv.List[0].Names[0]]
+			if recv == nil {
+				continue
+			}
+			if _, ok := recv.Type().(*types.Pointer); ok {
+				continue
+			}
+
+			ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || len(call.Args) == 0 || !isRecordCall(pass, call) {
+					return true
+				}
+
+				if isAddressOf(pass, call.Args[0], recv) {
+					pass.Reportf(
+						call.Args[0].Pos(),
+						"Record applies the event to a copy of the entity, use a pointer receiver in %s",
+						funcDecl.Name.Name,
+					)
+				}
+
+				return true
+			})
+		}
+	}
+
+	return nil, nil
+}
+
+func isRecordCall(pass *analysis.Pass, call *ast.CallExpr) bool {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Name() != "Record" {
+		return false
+	}
+
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return false
+	}
+
+	recvType := recv.Type()
+	if ptr, ok := recvType.(*types.Pointer); ok {
+		recvType = ptr.Elem()
+	}
+
+	_, ok = namedFrom(recvType, esjaPath, "Stream")
+	return ok
+}
+
+func isAddressOf(pass *analysis.Pass, expr ast.Expr, obj types.Object) bool {
+	unary, ok := ast.Unparen(expr).(*ast.UnaryExpr)
+	if !ok || unary.Op != token.AND {
+		return false
+	}
+
+	ident, ok := ast.Unparen(unary.X).(*ast.Ident)
+	return ok && pass.TypesInfo.Uses[ident] == obj
+}
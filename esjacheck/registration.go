@@ -0,0 +1,332 @@
+package esjacheck
+
+import (
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// RegistrationAnalyzer reports events missing in the mappers.
+var RegistrationAnalyzer = &analysis.Analyzer{
+	Name: "esjaregistration",
+	Doc: `report events not registered in the mapper
+
+The calls to esja functions accepting the supported events (like transport.NewValidatedDefaultMapper,
+transport.NewValidatedNoOpMapper and transport.NewRegistry) are checked to register all events
+of the entity declared in its package. An event missing in the mapper can be recorded,
+but it can't be saved.
+
+The events are checked only if all of them are known statically:
+listed in a slice literal, or returned as one by a function from the same package.`,
+	Run: runRegistration,
+}
+
+func runRegistration(pass *analysis.Pass) (any, error) {
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+
+			fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+			if !ok || fn.Pkg() == nil || !isEsjaPackage(fn.Pkg().Path()) {
+				return true
+			}
+
+			signature, ok := pass.TypesInfo.TypeOf(call.Fun).(*types.Signature)
+			if !ok {
+				return true
+			}
+
+			for i := 0; i < signature.Params().Len() && i < len(call.Args); i++ {
+				entity, registered, ok := registeredEvents(pass, signature, call, i)
+				if ok {
+					reportUnregistered(pass, call, entity, registered)
+				}
+			}
+
+			return true
+		})
+	}
+
+	return nil, nil
+}
+
+func isEsjaPackage(path string) bool {
+	return path == esjaPath || strings.HasPrefix(path, esjaPath+"/")
+}
+
+// registeredEvents returns the events registered with the call argument at index i.
+func registeredEvents(
+	pass *analysis.Pass,
+	signature *types.Signature,
+	call *ast.CallExpr,
+	i int,
+) (types.Type, []types.Type, bool) {
+	slice, ok := signature.Params().At(i).Type().(*types.Slice)
+	if !ok {
+		return nil, nil, false
+	}
+
+	if signature.Variadic() && i == signature.Params().Len()-1 {
+		registration, ok := namedFrom(slice.Elem(), transportPath, "Registration")
+		if !ok || call.Ellipsis.IsValid() {
+			return nil, nil, false
+		}
+
+		events, ok := registrationEvents(pass, call.Args[i:])
+		return registration.TypeArgs().At(0), events, ok
+	}
+
+	elements, ok := sliceElements(pass, call.Args[i])
+	if !ok {
+		return nil, nil, false
+	}
+
+	if event, ok := namedFrom(slice.Elem(), esjaPath, "Event"); ok {
+		events, ok := elementTypes(pass, elements, derefNamed)
+		return event.TypeArgs().At(0), events, ok
+	}
+
+	if event, ok := namedFrom(slice.Elem(), transportPath, "Event"); ok {
+		events, ok := elementTypes(pass, elements, func(t types.Type) (types.Type, bool) {
+			return transportEventType(pass, t)
+		})
+		return event.TypeArgs().At(0), events, ok
+	}
+
+	return nil, nil, false
+}
+
+// sliceElements returns the elements of the slice literal,
+// or of the slice literal returned by the function from the analyzed package.
+func sliceElements(pass *analysis.Pass, expr ast.Expr) ([]ast.Expr, bool) {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.CompositeLit:
+		return compositeElements(e), true
+	case *ast.CallExpr:
+		fn, ok := typeutil.Callee(pass.TypesInfo, e).(*types.Func)
+		if !ok {
+			return nil, false
+		}
+
+		decl := funcDecl(pass, fn)
+		if decl == nil || len(decl.Body.List) != 1 {
+			return nil, false
+		}
+
+		ret, ok := decl.Body.List[0].(*ast.ReturnStmt)
+		if !ok || len(ret.Results) != 1 {
+			return nil, false
+		}
+
+		lit, ok := ast.Unparen(ret.Results[0]).(*ast.CompositeLit)
+		if !ok {
+			return nil, false
+		}
+
+		return compositeElements(lit), true
+	default:
+		return nil, false
+	}
+}
+
+func compositeElements(lit *ast.CompositeLit) []ast.Expr {
+	elements := make([]ast.Expr, 0, len(lit.Elts))
+	for _, e := range lit.Elts {
+		if kv, ok := e.(*ast.KeyValueExpr); ok {
+			e = kv.Value
+		}
+		elements = append(elements, e)
+	}
+	return elements
+}
+
+func elementTypes(
+	pass *analysis.Pass,
+	elements []ast.Expr,
+	eventType func(t types.Type) (types.Type, bool),
+) ([]types.Type, bool) {
+	events := make([]types.Type, 0, len(elements))
+
+	for _, e := range elements {
+		t := pass.TypesInfo.TypeOf(e)
+		if t == nil {
+			return nil, false
+		}
+
+		event, ok := eventType(t)
+		if !ok {
+			return nil, false
+		}
+
+		events = append(events, event)
+	}
+
+	return events, true
+}
+
+// registrationEvents returns the events registered with transport.Register, transport.RegisterPointer
+// and transport.RegisterMapped.
+func registrationEvents(pass *analysis.Pass, args []ast.Expr) ([]types.Type, bool) {
+	events := make([]types.Type, 0, len(args))
+
+	for _, arg := range args {
+		call, ok := ast.Unparen(arg).(*ast.CallExpr)
+		if !ok {
+			return nil, false
+		}
+
+		fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != transportPath ||
+			(fn.Name() != "Register" && fn.Name() != "RegisterPointer" && fn.Name() != "RegisterMapped") {
+			return nil, false
+		}
+
+		ident := calleeIdent(call.Fun)
+		if ident == nil {
+			return nil, false
+		}
+
+		instance, ok := pass.TypesInfo.Instances[ident]
+		if !ok || instance.TypeArgs.Len() < 2 {
+			return nil, false
+		}
+
+		event, ok := derefNamed(instance.TypeArgs.At(1))
+		if !ok {
+			return nil, false
+		}
+
+		events = append(events, event)
+	}
+
+	return events, true
+}
+
+func calleeIdent(expr ast.Expr) *ast.Ident {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	case *ast.IndexExpr:
+		return calleeIdent(e.X)
+	case *ast.IndexListExpr:
+		return calleeIdent(e.X)
+	default:
+		return nil
+	}
+}
+
+func derefNamed(t types.Type) (types.Type, bool) {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+
+	named, ok := types.Unalias(t).(*types.Named)
+	return named, ok
+}
+
+// transportEventType returns the event mapped by the transport event.
+// It's the result of the typed ToEvent method, or the type returned by ToStreamEvent
+// if it's declared in the analyzed package.
+func transportEventType(pass *analysis.Pass, t types.Type) (types.Type, bool) {
+	methods := types.NewMethodSet(t)
+
+	if toEvent, ok := methodSignature(methods, "ToEvent"); ok && toEvent.Results().Len() == 1 {
+		return derefNamed(toEvent.Results().At(0).Type())
+	}
+
+	selection := methods.Lookup(nil, "ToStreamEvent")
+	if selection == nil {
+		return nil, false
+	}
+
+	decl := funcDecl(pass, selection.Obj().(*types.Func))
+	if decl == nil {
+		return nil, false
+	}
+
+	var events []types.Type
+	resolved := true
+
+	inspectBody(decl.Body, func(n ast.Node) {
+		ret, ok := n.(*ast.ReturnStmt)
+		if !ok || len(ret.Results) != 1 {
+			return
+		}
+
+		event, ok := derefNamed(pass.TypesInfo.TypeOf(ret.Results[0]))
+		if !ok || types.IsInterface(event) {
+			resolved = false
+			return
+		}
+
+		events = append(events, event)
+	})
+
+	if !resolved || len(events) == 0 {
+		return nil, false
+	}
+
+	for _, event := range events[1:] {
+		if !types.Identical(event, events[0]) {
+			return nil, false
+		}
+	}
+
+	return events[0], true
+}
+
+// reportUnregistered reports the events of the entity missing in the registered events.
+func reportUnregistered(pass *analysis.Pass, call *ast.CallExpr, entity types.Type, registered []types.Type) {
+	named, ok := types.Unalias(entity).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return
+	}
+
+	pkg := named.Obj().Pkg()
+	qualifier := types.RelativeTo(pass.Pkg)
+
+	var missing []string
+
+	for _, name := range pkg.Scope().Names() {
+		typeName, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok || typeName.IsAlias() || (pkg != pass.Pkg && !typeName.Exported()) {
+			continue
+		}
+
+		eventEntityType, ok := eventEntity(typeName.Type())
+		if !ok || !types.Identical(eventEntityType, entity) || containsType(registered, typeName.Type()) {
+			continue
+		}
+
+		missing = append(missing, types.TypeString(typeName.Type(), qualifier))
+	}
+
+	sort.Strings(missing)
+
+	for _, event := range missing {
+		pass.Reportf(
+			call.Pos(),
+			"event %s of %s is not registered",
+			event,
+			types.TypeString(entity, qualifier),
+		)
+	}
+}
+
+func containsType(list []types.Type, t types.Type) bool {
+	for _, candidate := range list {
+		if types.Identical(candidate, t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package applyto
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+type Postcard struct {
+	content string
+	sent    bool
+}
+
+type Written struct {
+	Content string
+}
+
+func (Written) EventName() string {
+	return "Written_v1"
+}
+
+func (e Written) ApplyTo(p *Postcard) error {
+	if e.Content == "" {
+		return errors.New("empty content") // want `ApplyTo should only change the state and return nil, validate before recording the event`
+	}
+
+	p.content = fmt.Sprintf("%s\n", e.Content)
+	return nil
+}
+
+type Sent struct{}
+
+func (Sent) EventName() string {
+	return "Sent_v1"
+}
+
+func (Sent) ApplyTo(p *Postcard) error {
+	fmt.Println("sent") // want `ApplyTo should not do I/O, but calls fmt.Println`
+
+	_, err := http.Get("https://example.com") // want `ApplyTo should not do I/O, but calls net/http.Get`
+	if err != nil {
+		return fmt.Errorf("notify: %w", err) // want `ApplyTo should only change the state and return nil, validate before recording the event`
+	}
+
+	p.sent = true
+	return nil
+}
+
+type Stamped struct {
+	Sent
+}
+
+func (*Stamped) EventName() string {
+	return "Stamped_v1"
+}
+
+func (e *Stamped) ApplyTo(p *Postcard) error {
+	validate := func() error {
+		return errors.New("not validated here")
+	}
+	_ = validate
+
+	return e.Sent.ApplyTo(p)
+}
+
+type Archived struct{}
+
+func (Archived) EventName() string {
+	return "Archived_v1"
+}
+
+func (Archived) ApplyTo(*Postcard) error {
+	_, _ = os.ReadFile("archive") // want `ApplyTo should not do I/O, but calls os.ReadFile`
+	return nil
+}
+
+// Notifier is not an event, so its ApplyTo method is not checked.
+type Notifier struct{}
+
+func (Notifier) ApplyTo(*Postcard) error {
+	fmt.Println("notified")
+	return errors.New("not an event")
+}
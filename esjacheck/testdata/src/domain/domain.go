@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"github.com/ThreeDotsLabs/esja"
+)
+
+type Postcard struct {
+	stream  *esja.Stream[Postcard]
+	content string
+	sent    bool
+}
+
+type Written struct {
+	Content string
+}
+
+func (Written) EventName() string {
+	return "Written_v1"
+}
+
+func (e Written) ApplyTo(p *Postcard) error {
+	p.content = e.Content
+	return nil
+}
+
+type Sent struct{}
+
+func (Sent) EventName() string {
+	return "Sent_v1"
+}
+
+func (Sent) ApplyTo(p *Postcard) error {
+	p.sent = true
+	return nil
+}
+
+type Stamped struct{}
+
+func (*Stamped) EventName() string {
+	return "Stamped_v1"
+}
+
+func (*Stamped) ApplyTo(*Postcard) error {
+	return nil
+}
+
+type Archived struct{}
+
+func (Archived) EventName() string {
+	return "Archived_v1"
+}
+
+func (Archived) ApplyTo(*Postcard) error {
+	return nil
+}
+
+// Note is not an event, since it has no ApplyTo method.
+type Note struct{}
+
+func (Note) EventName() string {
+	return "Note_v1"
+}
+
+type archived struct{}
+
+func (archived) EventName() string {
+	return "archived_v1"
+}
+
+func (archived) ApplyTo(*Postcard) error {
+	return nil
+}
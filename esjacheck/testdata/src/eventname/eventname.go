@@ -0,0 +1,52 @@
+package eventname
+
+type Postcard struct{}
+
+type Written struct{}
+
+func (Written) EventName() string {
+	return "Written_v1"
+}
+
+func (Written) ApplyTo(*Postcard) error {
+	return nil
+}
+
+type Sent struct{}
+
+func (Sent) EventName() string {
+	return "Sent" // want `event name "Sent" has no version suffix, like "Sent_v1"`
+}
+
+func (Sent) ApplyTo(*Postcard) error {
+	return nil
+}
+
+type Stamped struct{}
+
+func (*Stamped) EventName() string {
+	return "postcard.stamped.v2"
+}
+
+func (*Stamped) ApplyTo(*Postcard) error {
+	return nil
+}
+
+const archivedName = "Archived"
+
+type Archived struct{}
+
+func (Archived) EventName() string {
+	return archivedName // want `event name "Archived" has no version suffix, like "Archived_v1"`
+}
+
+func (Archived) ApplyTo(*Postcard) error {
+	return nil
+}
+
+// Note is not an event, since it has no ApplyTo method.
+type Note struct{}
+
+func (Note) EventName() string {
+	return "Note"
+}
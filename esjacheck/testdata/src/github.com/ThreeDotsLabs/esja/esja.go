@@ -0,0 +1,13 @@
+// Package esja is a stub of the esja package used by the analyzer tests.
+package esja
+
+type Event[T any] interface {
+	EventName() string
+	ApplyTo(*T) error
+}
+
+type Stream[T any] struct{}
+
+func (s *Stream[T]) Record(entity *T, event Event[T]) error {
+	return event.ApplyTo(entity)
+}
@@ -0,0 +1,57 @@
+// Package transport is a stub of the transport package used by the analyzer tests.
+package transport
+
+import "github.com/ThreeDotsLabs/esja"
+
+type Event[T any] interface {
+	StreamEventName() string
+	FromStreamEvent(esja.Event[T])
+	ToStreamEvent() esja.Event[T]
+}
+
+type DefaultMapper[T any] struct{}
+
+func NewDefaultMapper[T any](supportedEvents []Event[T]) DefaultMapper[T] {
+	return DefaultMapper[T]{}
+}
+
+type NoOpMapper[T any] struct{}
+
+func NewNoOpMapper[T any](supportedEvents []esja.Event[T]) NoOpMapper[T] {
+	return NoOpMapper[T]{}
+}
+
+func NewValidatedNoOpMapper[T any](supportedEvents []esja.Event[T]) (NoOpMapper[T], error) {
+	return NoOpMapper[T]{}, nil
+}
+
+type Registration[T any] struct{}
+
+func Register[T any, E esja.Event[T]]() Registration[T] {
+	return Registration[T]{}
+}
+
+type PointerEvent[T any, E any] interface {
+	*E
+	esja.Event[T]
+}
+
+func RegisterPointer[T any, E any, PE PointerEvent[T, E]]() Registration[T] {
+	return Registration[T]{}
+}
+
+type MappedEvent[T any, E esja.Event[T], TE any] interface {
+	*TE
+	FromEvent(event E)
+	ToEvent() E
+}
+
+func RegisterMapped[T any, E esja.Event[T], TE any, PTE MappedEvent[T, E, TE]]() Registration[T] {
+	return Registration[T]{}
+}
+
+type Registry[T any] struct{}
+
+func NewRegistry[T any](registrations ...Registration[T]) *Registry[T] {
+	return &Registry[T]{}
+}
@@ -0,0 +1,56 @@
+package record
+
+import (
+	"errors"
+
+	"github.com/ThreeDotsLabs/esja"
+)
+
+type Postcard struct {
+	stream  *esja.Stream[Postcard]
+	content string
+	sent    bool
+}
+
+func (p *Postcard) Write(content string) error {
+	if p.sent {
+		return errors.New("postcard already sent")
+	}
+	return p.stream.Record(p, Written{Content: content})
+}
+
+func (p Postcard) Send() error {
+	return p.stream.Record(&p, Sent{}) // want `Record applies the event to a copy of the entity, use a pointer receiver in Send`
+}
+
+func (p Postcard) Copy() *Postcard {
+	return &p
+}
+
+func (p Postcard) Stream() *esja.Stream[Postcard] {
+	return p.stream
+}
+
+type Written struct {
+	Content string
+}
+
+func (Written) EventName() string {
+	return "Written_v1"
+}
+
+func (e Written) ApplyTo(p *Postcard) error {
+	p.content = e.Content
+	return nil
+}
+
+type Sent struct{}
+
+func (Sent) EventName() string {
+	return "Sent_v1"
+}
+
+func (Sent) ApplyTo(p *Postcard) error {
+	p.sent = true
+	return nil
+}
@@ -0,0 +1,27 @@
+package registry
+
+import (
+	"domain"
+
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+func Registry() *transport.Registry[domain.Postcard] {
+	return transport.NewRegistry( // want `event domain.Sent of domain.Postcard is not registered`
+		transport.Register[domain.Postcard, domain.Written](),
+		transport.RegisterPointer[domain.Postcard, domain.Stamped](),
+		transport.RegisterMapped[domain.Postcard, domain.Archived, Archived](),
+	)
+}
+
+func SpreadRegistry(registrations []transport.Registration[domain.Postcard]) *transport.Registry[domain.Postcard] {
+	return transport.NewRegistry(registrations...)
+}
+
+type Archived struct{}
+
+func (e *Archived) FromEvent(domain.Archived) {}
+
+func (e *Archived) ToEvent() domain.Archived {
+	return domain.Archived{}
+}
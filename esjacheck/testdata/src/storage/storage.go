@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"domain"
+
+	"github.com/ThreeDotsLabs/esja"
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+func NoOpMapper() transport.NoOpMapper[domain.Postcard] {
+	return transport.NewNoOpMapper([]esja.Event[domain.Postcard]{ // want `event domain.Archived of domain.Postcard is not registered` `event domain.Stamped of domain.Postcard is not registered`
+		domain.Written{},
+		domain.Sent{},
+	})
+}
+
+func CompleteNoOpMapper() transport.NoOpMapper[domain.Postcard] {
+	return transport.NewNoOpMapper([]esja.Event[domain.Postcard]{
+		domain.Written{},
+		domain.Sent{},
+		&domain.Stamped{},
+		domain.Archived{},
+	})
+}
+
+func ValidatedNoOpMapper() (transport.NoOpMapper[domain.Postcard], error) {
+	return transport.NewValidatedNoOpMapper([]esja.Event[domain.Postcard]{ // want `event domain.Archived of domain.Postcard is not registered`
+		domain.Written{},
+		domain.Sent{},
+		(*domain.Stamped)(nil),
+	})
+}
+
+func DefaultMapper() transport.DefaultMapper[domain.Postcard] {
+	return transport.NewDefaultMapper(transportEvents()) // want `event domain.Stamped of domain.Postcard is not registered`
+}
+
+func UnknownEventsMapper(events []transport.Event[domain.Postcard]) transport.DefaultMapper[domain.Postcard] {
+	return transport.NewDefaultMapper(events)
+}
+
+func transportEvents() []transport.Event[domain.Postcard] {
+	return []transport.Event[domain.Postcard]{
+		&Written{},
+		&Sent{},
+		&Archived{},
+	}
+}
+
+type Written struct {
+	Content string `json:"content"`
+}
+
+func (e *Written) StreamEventName() string {
+	return domain.Written{}.EventName()
+}
+
+func (e *Written) FromStreamEvent(event esja.Event[domain.Postcard]) {
+	e.FromEvent(event.(domain.Written))
+}
+
+func (e *Written) ToStreamEvent() esja.Event[domain.Postcard] {
+	return e.ToEvent()
+}
+
+func (e *Written) FromEvent(event domain.Written) {
+	e.Content = event.Content
+}
+
+func (e *Written) ToEvent() domain.Written {
+	return domain.Written{Content: e.Content}
+}
+
+type Sent struct{}
+
+func (e *Sent) StreamEventName() string {
+	return domain.Sent{}.EventName()
+}
+
+func (e *Sent) FromStreamEvent(esja.Event[domain.Postcard]) {}
+
+func (e *Sent) ToStreamEvent() esja.Event[domain.Postcard] {
+	return domain.Sent{}
+}
+
+type Archived struct{}
+
+func (e *Archived) StreamEventName() string {
+	return domain.Archived{}.EventName()
+}
+
+func (e *Archived) FromStreamEvent(esja.Event[domain.Postcard]) {}
+
+func (e *Archived) ToStreamEvent() esja.Event[domain.Postcard] {
+	return &domain.Archived{}
+}
@@ -0,0 +1,210 @@
+package esjatest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ThreeDotsLabs/esja"
+)
+
+// Fixture is a Given/When/Then test fixture for esja.Entity types.
+//
+// Example:
+//
+//	esjatest.NewFixture[Postcard](t, "postcard-1").
+//		Given(Created{ID: "postcard-1"}).
+//		When(func(p *Postcard) error {
+//			return p.Write("content")
+//		}).
+//		Then(Written{Content: "content"})
+type Fixture[T esja.Entity[T]] struct {
+	t     testing.TB
+	id    string
+	given []esja.Event[T]
+}
+
+// NewFixture returns a new Fixture for the entity with the provided ID.
+func NewFixture[T esja.Entity[T]](t testing.TB, id string) *Fixture[T] {
+	return &Fixture[T]{
+		t:  t,
+		id: id,
+	}
+}
+
+// Given sets the events that happened to the entity before the command.
+func (f *Fixture[T]) Given(events ...esja.Event[T]) *Fixture[T] {
+	f.given = append(f.given, events...)
+	return f
+}
+
+// When builds the entity from the given events and runs the command on it.
+// At least one given event is required. For commands creating the entity, use WhenNew.
+func (f *Fixture[T]) When(command func(*T) error) *Result[T] {
+	f.t.Helper()
+
+	if len(f.given) == 0 {
+		f.t.Fatalf("no given events for entity %s, use WhenNew for commands creating the entity", f.id)
+		return &Result[T]{t: f.t, failed: true}
+	}
+
+	versioned := make([]esja.VersionedEvent[T], len(f.given))
+	for i, e := range f.given {
+		versioned[i] = esja.VersionedEvent[T]{
+			Event:         e,
+			StreamVersion: i + 1,
+		}
+	}
+
+	entity, err := esja.NewEntity(f.id, versioned)
+	if err != nil {
+		f.t.Fatalf("error applying given events to entity %s: %v", f.id, err)
+		return &Result[T]{t: f.t, failed: true}
+	}
+
+	err = command(entity)
+
+	return &Result[T]{
+		t:            f.t,
+		entity:       entity,
+		err:          err,
+		firstVersion: len(f.given) + 1,
+	}
+}
+
+// WhenNew runs the command creating a new entity.
+// Given events are ignored.
+func (f *Fixture[T]) WhenNew(command func() (*T, error)) *Result[T] {
+	f.t.Helper()
+
+	entity, err := command()
+
+	return &Result[T]{
+		t:            f.t,
+		entity:       entity,
+		err:          err,
+		firstVersion: 1,
+	}
+}
+
+// Result holds the outcome of the command run by the Fixture.
+type Result[T esja.Entity[T]] struct {
+	t            testing.TB
+	entity       *T
+	err          error
+	firstVersion int
+
+	// failed is set if the fixture failed before running the command, so there's nothing to assert.
+	failed bool
+}
+
+// Then asserts that the command succeeded and recorded exactly the expected events.
+func (r *Result[T]) Then(expected ...esja.Event[T]) *T {
+	r.t.Helper()
+
+	if r.failed {
+		return nil
+	}
+
+	if r.err != nil {
+		r.t.Fatalf("expected events, but the command failed: %v", r.err)
+		return nil
+	}
+	if r.entity == nil {
+		r.t.Fatalf("expected events, but the command returned a nil entity")
+		return nil
+	}
+
+	recorded := (*r.entity).Stream().PopEvents()
+
+	var actual []esja.Event[T]
+	for i, e := range recorded {
+		if e.StreamVersion != r.firstVersion+i {
+			r.t.Errorf(
+				"event %s recorded with stream version %d, expected %d",
+				e.EventName(),
+				e.StreamVersion,
+				r.firstVersion+i,
+			)
+		}
+		actual = append(actual, e.Event)
+	}
+
+	if expected == nil {
+		expected = []esja.Event[T]{}
+	}
+	if actual == nil {
+		actual = []esja.Event[T]{}
+	}
+
+	assert.Equal(
+		r.t,
+		expected,
+		actual,
+		"recorded events differ\nexpected: %s\nactual:   %s",
+		eventNames(expected),
+		eventNames(actual),
+	)
+
+	return r.entity
+}
+
+// ThenError asserts that the command failed with an error matching the expected one
+// (according to errors.Is) and that no events were recorded.
+func (r *Result[T]) ThenError(expected error) {
+	r.t.Helper()
+
+	if r.failed {
+		return
+	}
+
+	if !assert.ErrorIs(r.t, r.err, expected) {
+		return
+	}
+
+	r.assertNoEvents()
+}
+
+// ThenAnyError asserts that the command failed and no events were recorded.
+func (r *Result[T]) ThenAnyError() {
+	r.t.Helper()
+
+	if r.failed {
+		return
+	}
+
+	if !assert.Error(r.t, r.err) {
+		return
+	}
+
+	r.assertNoEvents()
+}
+
+// assertNoEvents pops the recorded events and reports them if there are any.
+func (r *Result[T]) assertNoEvents() {
+	r.t.Helper()
+
+	if r.entity == nil {
+		return
+	}
+
+	recorded := (*r.entity).Stream().PopEvents()
+	if len(recorded) > 0 {
+		var events []esja.Event[T]
+		for _, e := range recorded {
+			events = append(events, e.Event)
+		}
+		r.t.Errorf("expected no events on error, but got: %s", eventNames(events))
+	}
+}
+
+func eventNames[T any](events []esja.Event[T]) string {
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = e.EventName()
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(names, ", "))
+}
@@ -0,0 +1,199 @@
+package esjatest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ThreeDotsLabs/esja"
+	"github.com/ThreeDotsLabs/esja/esjatest"
+)
+
+var errAlreadyClosed = errors.New("ticket already closed")
+
+type Ticket struct {
+	stream *esja.Stream[Ticket]
+	id     string
+	closed bool
+}
+
+func NewTicket(id string) (*Ticket, error) {
+	s, err := esja.NewStream[Ticket](id)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Ticket{stream: s}
+	err = t.stream.Record(t, Opened{ID: id})
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *Ticket) Close() error {
+	if t.closed {
+		return errAlreadyClosed
+	}
+
+	return t.stream.Record(t, Closed{})
+}
+
+func (t Ticket) Stream() *esja.Stream[Ticket] {
+	return t.stream
+}
+
+func (t Ticket) NewWithStream(stream *esja.Stream[Ticket]) *Ticket {
+	return &Ticket{stream: stream}
+}
+
+type Opened struct {
+	ID string
+}
+
+func (Opened) EventName() string {
+	return "Opened_v1"
+}
+
+func (e Opened) ApplyTo(t *Ticket) error {
+	t.id = e.ID
+	return nil
+}
+
+type Closed struct{}
+
+func (Closed) EventName() string {
+	return "Closed_v1"
+}
+
+func (Closed) ApplyTo(t *Ticket) error {
+	t.closed = true
+	return nil
+}
+
+func TestFixture(t *testing.T) {
+	esjatest.NewFixture[Ticket](t, "ticket-1").
+		WhenNew(func() (*Ticket, error) {
+			return NewTicket("ticket-1")
+		}).
+		Then(Opened{ID: "ticket-1"})
+
+	esjatest.NewFixture[Ticket](t, "ticket-1").
+		Given(Opened{ID: "ticket-1"}).
+		When(func(t *Ticket) error {
+			return t.Close()
+		}).
+		Then(Closed{})
+
+	esjatest.NewFixture[Ticket](t, "ticket-1").
+		Given(Opened{ID: "ticket-1"}, Closed{}).
+		When(func(t *Ticket) error {
+			return t.Close()
+		}).
+		ThenError(errAlreadyClosed)
+}
+
+func TestFixture_reports_failures(t *testing.T) {
+	spy := &spyT{TB: t}
+
+	esjatest.NewFixture[Ticket](spy, "ticket-1").
+		Given(Opened{ID: "ticket-1"}).
+		When(func(t *Ticket) error {
+			return t.Close()
+		}).
+		Then(Opened{ID: "ticket-1"})
+
+	assert.Len(t, spy.errors, 1)
+	assert.Contains(t, spy.errors[0], "expected: [Opened_v1]")
+	assert.Contains(t, spy.errors[0], "actual:   [Closed_v1]")
+
+	spy = &spyT{TB: t}
+
+	esjatest.NewFixture[Ticket](spy, "ticket-1").
+		Given(Opened{ID: "ticket-1"}, Closed{}).
+		When(func(t *Ticket) error {
+			return t.Close()
+		}).
+		Then(Closed{})
+
+	assert.Len(t, spy.errors, 1)
+	assert.Contains(t, spy.errors[0], errAlreadyClosed.Error())
+}
+
+func TestFixture_When_without_Given(t *testing.T) {
+	spy := &spyT{TB: t}
+
+	assert.NotPanics(t, func() {
+		esjatest.NewFixture[Ticket](spy, "ticket-1").
+			When(func(t *Ticket) error {
+				return t.Close()
+			}).
+			Then(Closed{})
+	})
+
+	assert.Len(t, spy.errors, 1)
+	assert.Contains(t, spy.errors[0], "use WhenNew")
+}
+
+func TestFixture_ThenAnyError_reports_events(t *testing.T) {
+	spy := &spyT{TB: t}
+
+	esjatest.NewFixture[Ticket](spy, "ticket-1").
+		Given(Opened{ID: "ticket-1"}).
+		When(func(t *Ticket) error {
+			err := t.Close()
+			if err != nil {
+				return err
+			}
+			return errors.New("failed after recording")
+		}).
+		ThenAnyError()
+
+	assert.Len(t, spy.errors, 1)
+	assert.Contains(t, spy.errors[0], "expected no events on error, but got: [Closed_v1]")
+}
+
+func TestProjectionFixture(t *testing.T) {
+	closedTickets := map[string]int{}
+
+	handler := func(_ context.Context, streamID string, event esja.VersionedEvent[Ticket]) error {
+		if _, ok := event.Event.(Closed); ok {
+			closedTickets[streamID] = event.StreamVersion
+		}
+		return nil
+	}
+
+	esjatest.NewProjectionFixture[Ticket](t, handler).
+		Given("ticket-1", Opened{ID: "ticket-1"}).
+		Given("ticket-2", Opened{ID: "ticket-2"}, Closed{}).
+		Given("ticket-1", Closed{}).
+		ThenState(map[string]int{"ticket-1": 2, "ticket-2": 2}, func() any {
+			return closedTickets
+		})
+
+	errBroken := errors.New("broken")
+	esjatest.NewProjectionFixture[Ticket](t, func(context.Context, string, esja.VersionedEvent[Ticket]) error {
+		return errBroken
+	}).
+		Given("ticket-1", Opened{ID: "ticket-1"}).
+		ThenError(errBroken)
+}
+
+type spyT struct {
+	testing.TB
+	errors []string
+}
+
+func (s *spyT) Helper() {}
+
+func (s *spyT) Errorf(format string, args ...any) {
+	s.errors = append(s.errors, fmt.Sprintf(format, args...))
+}
+
+func (s *spyT) Fatalf(format string, args ...any) {
+	s.errors = append(s.errors, fmt.Sprintf(format, args...))
+}
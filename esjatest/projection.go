@@ -0,0 +1,98 @@
+package esjatest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ThreeDotsLabs/esja"
+)
+
+// ProjectionHandler handles a single event of the stream with the provided ID.
+type ProjectionHandler[T any] func(ctx context.Context, streamID string, event esja.VersionedEvent[T]) error
+
+// ProjectionFixture is a Given/Then test fixture for projections (read models)
+// built from esja events.
+//
+// Example:
+//
+//	esjatest.NewProjectionFixture[Postcard](t, readModel.Handle).
+//		Given("postcard-1", Created{ID: "postcard-1"}, Sent{}).
+//		ThenState([]string{"postcard-1"}, func() any {
+//			return readModel.SentPostcards()
+//		})
+type ProjectionFixture[T any] struct {
+	t        testing.TB
+	ctx      context.Context
+	handler  ProjectionHandler[T]
+	versions map[string]int
+	err      error
+}
+
+// NewProjectionFixture returns a new ProjectionFixture feeding events to the handler.
+func NewProjectionFixture[T any](t testing.TB, handler ProjectionHandler[T]) *ProjectionFixture[T] {
+	return &ProjectionFixture[T]{
+		t:        t,
+		ctx:      context.Background(),
+		handler:  handler,
+		versions: map[string]int{},
+	}
+}
+
+// WithContext sets the context passed to the handler.
+func (f *ProjectionFixture[T]) WithContext(ctx context.Context) *ProjectionFixture[T] {
+	f.ctx = ctx
+	return f
+}
+
+// Given feeds the events of the stream with the provided ID to the handler.
+// Stream versions continue from the previous Given call for the same stream.
+// Events are not handled after the handler returned an error.
+func (f *ProjectionFixture[T]) Given(streamID string, events ...esja.Event[T]) *ProjectionFixture[T] {
+	for _, e := range events {
+		if f.err != nil {
+			return f
+		}
+
+		f.versions[streamID]++
+
+		f.err = f.handler(f.ctx, streamID, esja.VersionedEvent[T]{
+			Event:         e,
+			StreamVersion: f.versions[streamID],
+		})
+	}
+
+	return f
+}
+
+// Then asserts that all events were handled and runs the provided assertions.
+func (f *ProjectionFixture[T]) Then(assertions func(t testing.TB)) {
+	f.t.Helper()
+
+	if f.err != nil {
+		f.t.Fatalf("expected events to be handled, but the handler failed: %v", f.err)
+		return
+	}
+
+	assertions(f.t)
+}
+
+// ThenState asserts that all events were handled and the state
+// returned by the provided function is equal to the expected one.
+func (f *ProjectionFixture[T]) ThenState(expected any, state func() any) {
+	f.t.Helper()
+
+	f.Then(func(t testing.TB) {
+		t.Helper()
+		assert.Equal(t, expected, state(), "projection state differs")
+	})
+}
+
+// ThenError asserts that the handler failed with an error matching the expected one
+// (according to errors.Is).
+func (f *ProjectionFixture[T]) ThenError(expected error) {
+	f.t.Helper()
+
+	assert.ErrorIs(f.t, f.err, expected)
+}
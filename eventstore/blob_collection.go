@@ -0,0 +1,286 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+const (
+	defaultBlobMinAge             = time.Hour
+	defaultBlobCollectionInterval = time.Hour
+)
+
+type BlobCollectionConfig struct {
+	// MinAge is the minimum age of the blobs removed. Defaults to one hour.
+	// Blobs are stored before their events are saved, so younger blobs
+	// could belong to events being saved while the collection runs.
+	MinAge time.Duration
+
+	// Interval is the time between the collections of RunBlobCollection. Defaults to one hour.
+	Interval time.Duration
+}
+
+func (c *BlobCollectionConfig) setDefaults() {
+	if c.MinAge == 0 {
+		c.MinAge = defaultBlobMinAge
+	}
+	if c.Interval == 0 {
+		c.Interval = defaultBlobCollectionInterval
+	}
+}
+
+func (c BlobCollectionConfig) validate() error {
+	if c.MinAge < 0 {
+		return fmt.Errorf("min age must not be negative")
+	}
+	if c.Interval < 0 {
+		return fmt.Errorf("interval must not be negative")
+	}
+	return nil
+}
+
+// BlobCollectionResult is the summary of CollectBlobs.
+type BlobCollectionResult struct {
+	// Referenced is the number of blobs referenced by the stored events.
+	Referenced int
+	// Deleted is the number of blobs removed.
+	Deleted int
+}
+
+// CollectBlobs removes the blobs of transport.ClaimCheckMarshaler no stored event refers to,
+// like the ones of deleted streams. The events of all tenants are scanned for the references.
+//
+// The blob store must be used only by the events table of the store,
+// as the blobs referenced by other tables would be removed.
+// Blobs put again after they were listed, like the ones of events saved during the collection, are kept.
+func (s SQLStore[T]) CollectBlobs(
+	ctx context.Context,
+	blobs transport.BlobStore,
+	config BlobCollectionConfig,
+) (BlobCollectionResult, error) {
+	return collectBlobs(ctx, blobs, config, s.referencedBlobs)
+}
+
+// RunBlobCollection calls CollectBlobs every BlobCollectionConfig.Interval,
+// so the blobs of deleted streams are removed without calling it after each DeleteStream.
+// It runs until the context is canceled or the collection fails.
+func (s SQLStore[T]) RunBlobCollection(
+	ctx context.Context,
+	blobs transport.BlobStore,
+	config BlobCollectionConfig,
+) error {
+	return runBlobCollection(ctx, blobs, config, s.CollectBlobs)
+}
+
+// CollectBlobs removes the blobs of transport.ClaimCheckMarshaler no event kept in memory refers to,
+// like the ones of deleted streams. The events of all tenants are scanned for the references.
+// It returns an error if the store keeps live events, as they have no payloads.
+//
+// The blob store must be used only by the store, as the blobs referenced elsewhere would be removed.
+func (i *InMemoryStore[T]) CollectBlobs(
+	ctx context.Context,
+	blobs transport.BlobStore,
+	config BlobCollectionConfig,
+) (BlobCollectionResult, error) {
+	return collectBlobs(ctx, blobs, config, i.referencedBlobs)
+}
+
+// RunBlobCollection calls CollectBlobs every BlobCollectionConfig.Interval.
+// It runs until the context is canceled or the collection fails.
+func (i *InMemoryStore[T]) RunBlobCollection(
+	ctx context.Context,
+	blobs transport.BlobStore,
+	config BlobCollectionConfig,
+) error {
+	return runBlobCollection(ctx, blobs, config, i.CollectBlobs)
+}
+
+// CollectBlobs removes the blobs of transport.ClaimCheckMarshaler no stream of the store refers to,
+// like the ones of deleted streams. Records of the deleted streams stay in the segment files,
+// but their references aren't counted.
+//
+// The blob store must be used only by the store, as the blobs referenced elsewhere would be removed.
+func (s *FileStore[T]) CollectBlobs(
+	ctx context.Context,
+	blobs transport.BlobStore,
+	config BlobCollectionConfig,
+) (BlobCollectionResult, error) {
+	return collectBlobs(ctx, blobs, config, s.referencedBlobs)
+}
+
+// RunBlobCollection calls CollectBlobs every BlobCollectionConfig.Interval.
+// It runs until the context is canceled or the collection fails.
+func (s *FileStore[T]) RunBlobCollection(
+	ctx context.Context,
+	blobs transport.BlobStore,
+	config BlobCollectionConfig,
+) error {
+	return runBlobCollection(ctx, blobs, config, s.CollectBlobs)
+}
+
+// collectBlobs removes the blobs older than BlobCollectionConfig.MinAge and missing from the referenced ones.
+func collectBlobs(
+	ctx context.Context,
+	blobs transport.BlobStore,
+	config BlobCollectionConfig,
+	referencedBlobs func(ctx context.Context) (map[string]struct{}, error),
+) (BlobCollectionResult, error) {
+	if blobs == nil {
+		return BlobCollectionResult{}, fmt.Errorf("blob store is nil")
+	}
+
+	config.setDefaults()
+	err := config.validate()
+	if err != nil {
+		return BlobCollectionResult{}, fmt.Errorf("invalid config: %w", err)
+	}
+
+	referenced, err := referencedBlobs(ctx)
+	if err != nil {
+		return BlobCollectionResult{}, err
+	}
+
+	// Blobs are listed after the events are scanned, so the blobs put for the events
+	// saved during the scan are younger than MinAge and kept.
+	stored, err := blobs.List(ctx)
+	if err != nil {
+		return BlobCollectionResult{}, fmt.Errorf("error listing blobs: %w", err)
+	}
+
+	result := BlobCollectionResult{
+		Referenced: len(referenced),
+	}
+
+	cutoff := time.Now().Add(-config.MinAge)
+
+	for _, b := range stored {
+		if _, ok := referenced[b.Key]; ok {
+			continue
+		}
+		if b.StoredAt.After(cutoff) {
+			continue
+		}
+
+		// The blob could have been put again for an event saved after the scan,
+		// so it's deleted only if it's still older than the cutoff.
+		deleted, err := blobs.DeleteIfStoredBefore(ctx, b.Key, cutoff)
+		if err != nil {
+			return result, fmt.Errorf("error deleting blob %s: %w", b.Key, err)
+		}
+
+		if deleted {
+			result.Deleted++
+		}
+	}
+
+	return result, nil
+}
+
+// runBlobCollection calls collect every BlobCollectionConfig.Interval until the context is canceled.
+func runBlobCollection(
+	ctx context.Context,
+	blobs transport.BlobStore,
+	config BlobCollectionConfig,
+	collect func(context.Context, transport.BlobStore, BlobCollectionConfig) (BlobCollectionResult, error),
+) error {
+	config.setDefaults()
+	err := config.validate()
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	for {
+		_, err = collect(ctx, blobs, config)
+		if err != nil {
+			return fmt.Errorf("error collecting blobs: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(config.Interval):
+		}
+	}
+}
+
+// referencedBlobs returns the keys of the blobs referenced by the stored events.
+func (s SQLStore[T]) referencedBlobs(ctx context.Context) (map[string]struct{}, error) {
+	referenced := map[string]struct{}{}
+
+	var afterID int64
+	for {
+		query, args, err := s.config.SchemaAdapter.SelectAllPayloadsQuery(ctx, afterID, readBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("error building select payloads query: %w", err)
+		}
+
+		batch, err := s.queryPayloads(ctx, query, args)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			return referenced, nil
+		}
+
+		for _, p := range batch {
+			afterID = p.id
+
+			if key, ok := transport.BlobKey(p.payload); ok {
+				referenced[key] = struct{}{}
+			}
+		}
+	}
+}
+
+// referencedBlobs returns the keys of the blobs referenced by the events kept in memory.
+func (i *InMemoryStore[T]) referencedBlobs(context.Context) (map[string]struct{}, error) {
+	if !i.config.serializing() {
+		return nil, fmt.Errorf("store keeps live events, so it has no payloads referring to blobs")
+	}
+
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	referenced := map[string]struct{}{}
+	for _, stream := range i.streams {
+		for _, e := range stream.events {
+			if key, ok := transport.BlobKey(e.payload); ok {
+				referenced[key] = struct{}{}
+			}
+		}
+	}
+
+	return referenced, nil
+}
+
+// referencedBlobs returns the keys of the blobs referenced by the streams in the index.
+func (s *FileStore[T]) referencedBlobs(context.Context) (map[string]struct{}, error) {
+	s.lock.RLock()
+	ids := make([]string, 0, len(s.streams))
+	for id := range s.streams {
+		ids = append(ids, id)
+	}
+	s.lock.RUnlock()
+
+	referenced := map[string]struct{}{}
+	for _, id := range ids {
+		// Streams deleted since the IDs were taken have no records.
+		batches, err := s.readStream(id, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, batch := range batches {
+			for _, e := range batch.Events {
+				if key, ok := transport.BlobKey(e.EventPayload); ok {
+					referenced[key] = struct{}{}
+				}
+			}
+		}
+	}
+
+	return referenced, nil
+}
@@ -0,0 +1,118 @@
+package eventstore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja/eventstore"
+	"github.com/ThreeDotsLabs/esja/eventstore/eventstoretest"
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+type blobCollectingStore interface {
+	eventstoretest.DeleterStore
+	CollectBlobs(
+		ctx context.Context,
+		blobs transport.BlobStore,
+		config eventstore.BlobCollectionConfig,
+	) (eventstore.BlobCollectionResult, error)
+}
+
+func TestCollectBlobs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		newStore func(t *testing.T, marshaler transport.Marshaler) blobCollectingStore
+	}{
+		{
+			name: "in_memory",
+			newStore: func(t *testing.T, marshaler transport.Marshaler) blobCollectingStore {
+				config := eventstore.NewInMemoryConfig[eventstoretest.Entity](eventstoretest.SupportedEvents())
+				config.Marshaler = marshaler
+
+				store, err := eventstore.NewInMemoryStoreWithConfig(config)
+				require.NoError(t, err)
+				return store
+			},
+		},
+		{
+			name: "file",
+			newStore: func(t *testing.T, marshaler transport.Marshaler) blobCollectingStore {
+				config := eventstore.NewFileConfig(eventstoretest.SupportedEvents())
+				config.Marshaler = marshaler
+
+				return newFileStore(t, t.TempDir(), config)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			blobs := transport.NewMemoryBlobStore()
+
+			marshaler, err := transport.NewClaimCheckMarshaler(transport.JSONMarshaler{}, blobs, transport.ClaimCheckConfig{
+				Threshold: 1,
+			})
+			require.NoError(t, err)
+
+			store := tc.newStore(t, marshaler)
+
+			kept, err := eventstoretest.NewEntity(eventstoretest.NewID())
+			require.NoError(t, err)
+			err = kept.Update("kept")
+			require.NoError(t, err)
+			err = store.Save(ctx, kept)
+			require.NoError(t, err)
+
+			keptBlobs, err := blobs.List(ctx)
+			require.NoError(t, err)
+			require.NotEmpty(t, keptBlobs)
+
+			deleted, err := eventstoretest.NewEntity(eventstoretest.NewID())
+			require.NoError(t, err)
+			err = deleted.Update("deleted")
+			require.NoError(t, err)
+			err = store.Save(ctx, deleted)
+			require.NoError(t, err)
+
+			err = store.DeleteStream(ctx, deleted.ID())
+			require.NoError(t, err)
+
+			result, err := store.CollectBlobs(ctx, blobs, eventstore.BlobCollectionConfig{})
+			require.NoError(t, err)
+			assert.Equal(t, 0, result.Deleted, "blobs younger than MinAge should be kept")
+
+			result, err = store.CollectBlobs(ctx, blobs, eventstore.BlobCollectionConfig{MinAge: time.Nanosecond})
+			require.NoError(t, err)
+			assert.Equal(t, len(keptBlobs), result.Referenced)
+			assert.Equal(t, 2, result.Deleted)
+
+			listed, err := blobs.List(ctx)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, blobKeys(keptBlobs), blobKeys(listed))
+
+			loaded, err := store.Load(ctx, kept.ID())
+			require.NoError(t, err)
+			assert.Equal(t, "kept", loaded.Value())
+		})
+	}
+}
+
+func TestInMemoryStore_CollectBlobs_live_events(t *testing.T) {
+	store := eventstore.NewInMemoryStore[eventstoretest.Entity]()
+
+	_, err := store.CollectBlobs(context.Background(), transport.NewMemoryBlobStore(), eventstore.BlobCollectionConfig{})
+	assert.ErrorContains(t, err, "store keeps live events")
+}
+
+func blobKeys(blobs []transport.BlobInfo) []string {
+	keys := make([]string, 0, len(blobs))
+	for _, b := range blobs {
+		keys = append(keys, b.Key)
+	}
+	return keys
+}
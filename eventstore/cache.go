@@ -0,0 +1,393 @@
+package eventstore
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/esja"
+)
+
+const defaultCacheSize = 1000
+
+// CacheableEventStore is an EventStore which can be wrapped with CachingStore.
+type CacheableEventStore[T esja.Entity[T]] interface {
+	EventStore[T]
+	EventsLoader[T]
+}
+
+type CacheConfig struct {
+	// Size is the maximum number of cached entities.
+	// The least recently used entities are evicted first.
+	// Defaults to 1000.
+	Size int
+
+	// TTL is the time after which a cached entity is dropped and loaded from scratch.
+	// Zero means cached entities don't expire.
+	TTL time.Duration
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+func (c *CacheConfig) setDefaults() {
+	if c.Size == 0 {
+		c.Size = defaultCacheSize
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+}
+
+func (c CacheConfig) validate() error {
+	if c.Size < 0 {
+		return fmt.Errorf("size must not be negative")
+	}
+	if c.TTL < 0 {
+		return fmt.Errorf("TTL must not be negative")
+	}
+	return nil
+}
+
+// CacheStats holds the CachingStore counters.
+type CacheStats struct {
+	// Hits is the number of loads served from the cache.
+	Hits uint64
+	// Misses is the number of loads which replayed all events from the wrapped store.
+	Misses uint64
+	// RefreshedEvents is the number of events loaded on top of the cached ones.
+	RefreshedEvents uint64
+	// Evictions is the number of entities dropped because the cache was full.
+	Evictions uint64
+	// Expirations is the number of entities dropped because of the TTL.
+	Expirations uint64
+	// Invalidations is the number of entities dropped on Save and DeleteStream.
+	Invalidations uint64
+	// Entries is the current number of cached entities.
+	Entries int
+}
+
+// cacheKey identifies the cached stream. Streams of different tenants can have the same ID.
+type cacheKey struct {
+	tenantID string
+	streamID string
+}
+
+func newCacheKey(ctx context.Context, streamID string) cacheKey {
+	tenantID, _ := TenantIDFromContext(ctx)
+
+	return cacheKey{
+		tenantID: tenantID,
+		streamID: streamID,
+	}
+}
+
+type cacheEntry[T any] struct {
+	key        cacheKey
+	streamType string
+	events     []esja.VersionedEvent[T]
+	// version includes the skipped events stored after the cached ones.
+	version  int
+	cachedAt time.Time
+}
+
+// pendingLoad tracks the loads of a stream in progress.
+// Its generation changes when the stream is invalidated, so the events loaded before aren't cached.
+type pendingLoad struct {
+	loads      int
+	generation uint64
+}
+
+// CachingStore is a wrapper to any CacheableEventStore instance.
+// CachingStore keeps the events of recently loaded entities in memory.
+// On Load, only events stored after the cached version are loaded
+// from the wrapped store and a new instance of the entity is built,
+// so entities are never shared between callers.
+// Cached events are deep-copied, so changes to the loaded events don't change the cache.
+// Cached entities are invalidated on Save and DeleteStream.
+// Streams are cached per tenant ID from the context, so multi-tenant stores can be wrapped.
+//
+// AfterLoad and Stage hooks of the wrapped store's config are called on each Load, including the cached ones.
+type CachingStore[T esja.Entity[T]] struct {
+	store  CacheableEventStore[T]
+	config CacheConfig
+
+	lock    sync.Mutex
+	entries map[cacheKey]*list.Element
+	lru     *list.List
+	pending map[cacheKey]*pendingLoad
+	stats   CacheStats
+}
+
+// NewCachingStore returns a new instance of CachingStore.
+func NewCachingStore[T esja.Entity[T]](
+	store CacheableEventStore[T],
+	config CacheConfig,
+) (*CachingStore[T], error) {
+	if store == nil {
+		return nil, fmt.Errorf("store must not be nil")
+	}
+
+	config.setDefaults()
+
+	err := config.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return &CachingStore[T]{
+		store:   store,
+		config:  config,
+		entries: map[cacheKey]*list.Element{},
+		lru:     list.New(),
+		pending: map[cacheKey]*pendingLoad{},
+	}, nil
+}
+
+// Load returns a new instance of the entity built from the cached events
+// and the events stored after the cached version.
+func (c *CachingStore[T]) Load(ctx context.Context, id string) (*T, error) {
+	events, err := c.LoadEvents(ctx, id, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events.Events) == 0 {
+		return nil, ErrEntityNotFound
+	}
+
+	var hooks Hooks[T]
+	if h, ok := c.store.(hooksProvider[T]); ok {
+		hooks = h.hooks()
+	}
+
+	entity, err := newEntity(ctx, hooks, id, events)
+	if err != nil {
+		return nil, err
+	}
+
+	err = hooks.afterLoad(ctx, entity, events)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+// LoadEvents returns the stream's events with versions greater than afterVersion,
+// using the cached events where possible.
+func (c *CachingStore[T]) LoadEvents(ctx context.Context, id string, afterVersion int) (StreamEvents[T], error) {
+	key := newCacheKey(ctx, id)
+
+	generation := c.startLoad(key)
+	defer c.finishLoad(key)
+
+	cached, ok := c.get(key)
+
+	var (
+		events StreamEvents[T]
+		err    error
+	)
+	if ok {
+		events, err = c.store.LoadEvents(ctx, id, cached.version)
+		if err != nil {
+			return StreamEvents[T]{}, err
+		}
+
+		c.lock.Lock()
+		c.stats.Hits++
+		c.stats.RefreshedEvents += uint64(len(events.Events))
+		c.lock.Unlock()
+
+		if events.StreamType == "" {
+			events.StreamType = cached.streamType
+		}
+		if events.Version < cached.version {
+			events.Version = cached.version
+		}
+		events.Events = append(cached.events, events.Events...)
+	} else {
+		events, err = c.store.LoadEvents(ctx, id, 0)
+		if err != nil {
+			return StreamEvents[T]{}, err
+		}
+
+		c.lock.Lock()
+		c.stats.Misses++
+		c.lock.Unlock()
+	}
+
+	if len(events.Events) > 0 {
+		c.put(key, generation, events)
+	}
+
+	result := StreamEvents[T]{
+		StreamID:   id,
+		StreamType: events.StreamType,
+		Events:     []esja.VersionedEvent[T]{},
+	}
+	if version := events.version(); version > afterVersion {
+		result.Version = version
+	}
+	for _, e := range events.Events {
+		if e.StreamVersion > afterVersion {
+			result.Events = append(result.Events, e)
+		}
+	}
+
+	return result, nil
+}
+
+// Save saves the entity in the wrapped store and invalidates its cached events.
+func (c *CachingStore[T]) Save(ctx context.Context, t *T) error {
+	if t != nil {
+		c.invalidate(newCacheKey(ctx, (*t).Stream().ID()))
+	}
+
+	return c.store.Save(ctx, t)
+}
+
+// DeleteStream deletes the stream in the wrapped store and invalidates its cached events.
+// The wrapped store must implement StreamDeleter.
+func (c *CachingStore[T]) DeleteStream(ctx context.Context, id string) error {
+	deleter, ok := c.store.(StreamDeleter)
+	if !ok {
+		return fmt.Errorf("wrapped store doesn't support deleting streams")
+	}
+
+	err := deleter.DeleteStream(ctx, id)
+
+	// Invalidated after deleting, as the events loaded before the deletion could be cached during it.
+	// Loads still in progress don't cache their events after the invalidation.
+	c.invalidate(newCacheKey(ctx, id))
+
+	return err
+}
+
+// Stats returns the current cache counters.
+func (c *CachingStore[T]) Stats() CacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+
+	return stats
+}
+
+func (c *CachingStore[T]) get(key cacheKey) (cacheEntry[T], bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return cacheEntry[T]{}, false
+	}
+
+	entry := element.Value.(cacheEntry[T])
+
+	if c.config.TTL > 0 && c.config.Now().Sub(entry.cachedAt) >= c.config.TTL {
+		c.remove(element)
+		c.stats.Expirations++
+		return cacheEntry[T]{}, false
+	}
+
+	c.lru.MoveToFront(element)
+
+	return cacheEntry[T]{
+		key:        entry.key,
+		streamType: entry.streamType,
+		events:     deepCopyEvents(entry.events),
+		version:    entry.version,
+		cachedAt:   entry.cachedAt,
+	}, true
+}
+
+// startLoad registers a load of the stream and returns the stream's generation.
+func (c *CachingStore[T]) startLoad(key cacheKey) uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	pending, ok := c.pending[key]
+	if !ok {
+		pending = &pendingLoad{}
+		c.pending[key] = pending
+	}
+	pending.loads++
+
+	return pending.generation
+}
+
+func (c *CachingStore[T]) finishLoad(key cacheKey) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	pending := c.pending[key]
+	pending.loads--
+	if pending.loads == 0 {
+		delete(c.pending, key)
+	}
+}
+
+// put caches the events, unless the stream was invalidated since the load of the given generation started.
+func (c *CachingStore[T]) put(key cacheKey, generation uint64, events StreamEvents[T]) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.pending[key].generation != generation {
+		return
+	}
+
+	entry := cacheEntry[T]{
+		key:        key,
+		streamType: events.StreamType,
+		events:     deepCopyEvents(events.Events),
+		version:    events.version(),
+		cachedAt:   c.config.Now(),
+	}
+
+	if element, ok := c.entries[key]; ok {
+		current := element.Value.(cacheEntry[T])
+		if current.version > entry.version {
+			// A concurrent load has cached a newer version already.
+			return
+		}
+
+		entry.cachedAt = current.cachedAt
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+
+	for c.lru.Len() > c.config.Size {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *CachingStore[T]) invalidate(key cacheKey) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if pending, ok := c.pending[key]; ok {
+		pending.generation++
+	}
+
+	element, ok := c.entries[key]
+	if !ok {
+		return
+	}
+
+	c.remove(element)
+	c.stats.Invalidations++
+}
+
+func (c *CachingStore[T]) remove(element *list.Element) {
+	entry := element.Value.(cacheEntry[T])
+	delete(c.entries, entry.key)
+	c.lru.Remove(element)
+}
@@ -0,0 +1,313 @@
+package eventstore_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja"
+	"github.com/ThreeDotsLabs/esja/eventstore"
+	"github.com/ThreeDotsLabs/esja/eventstore/eventstoretest"
+)
+
+func TestCachingStore(t *testing.T) {
+	eventstoretest.TestEventStore(t, func(t *testing.T) eventstore.EventStore[eventstoretest.Entity] {
+		store, err := eventstore.NewCachingStore[eventstoretest.Entity](
+			eventstore.NewInMemoryStore[eventstoretest.Entity](),
+			eventstore.CacheConfig{},
+		)
+		require.NoError(t, err)
+		return store
+	})
+}
+
+func TestCachingStore_stream_deletion(t *testing.T) {
+	store, err := eventstore.NewCachingStore[eventstoretest.Entity](
+		eventstore.NewInMemoryStore[eventstoretest.Entity](),
+		eventstore.CacheConfig{},
+	)
+	require.NoError(t, err)
+
+	eventstoretest.TestStreamDeletion(t, store)
+	assert.Equal(t, uint64(1), store.Stats().Invalidations, "deleted stream should be dropped from the cache")
+}
+
+func TestCachingStore_stream_deletion_during_load(t *testing.T) {
+	ctx := context.Background()
+
+	inner := &blockingStore{
+		InMemoryStore: eventstore.NewInMemoryStore[eventstoretest.Entity](),
+		loaded:        make(chan struct{}),
+		release:       make(chan struct{}),
+	}
+	cache, err := eventstore.NewCachingStore[eventstoretest.Entity](inner, eventstore.CacheConfig{})
+	require.NoError(t, err)
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = cache.Save(ctx, entity)
+	require.NoError(t, err)
+
+	loadErr := make(chan error)
+	go func() {
+		_, err := cache.Load(ctx, entity.ID())
+		loadErr <- err
+	}()
+
+	// The stream is deleted after the load has read its events, but before they are cached.
+	<-inner.loaded
+	err = cache.DeleteStream(ctx, entity.ID())
+	require.NoError(t, err)
+	close(inner.release)
+
+	require.NoError(t, <-loadErr)
+
+	_, err = cache.Load(ctx, entity.ID())
+	assert.ErrorIs(t, err, eventstore.ErrEntityNotFound, "deleted stream should not be cached by the load in progress")
+	assert.Equal(t, 0, cache.Stats().Entries)
+}
+
+// blockingStore blocks the first LoadEvents after reading the events, until release is closed.
+type blockingStore struct {
+	*eventstore.InMemoryStore[eventstoretest.Entity]
+
+	loaded  chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (s *blockingStore) LoadEvents(
+	ctx context.Context,
+	id string,
+	afterVersion int,
+) (eventstore.StreamEvents[eventstoretest.Entity], error) {
+	events, err := s.InMemoryStore.LoadEvents(ctx, id, afterVersion)
+
+	s.once.Do(func() {
+		close(s.loaded)
+		<-s.release
+	})
+
+	return events, err
+}
+
+func TestCachingStore_refresh(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	inner := eventstore.NewInMemoryStore[eventstoretest.Entity]()
+	cache, err := eventstore.NewCachingStore[eventstoretest.Entity](inner, eventstore.CacheConfig{
+		Size: 2,
+		TTL:  time.Minute,
+		Now: func() time.Time {
+			return now
+		},
+	})
+	require.NoError(t, err)
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = cache.Save(ctx, entity)
+	require.NoError(t, err)
+
+	first, err := cache.Load(ctx, entity.ID())
+	require.NoError(t, err)
+
+	// Saved directly in the wrapped store, bypassing the cache.
+	err = first.Update("updated elsewhere")
+	require.NoError(t, err)
+	err = inner.Save(ctx, first)
+	require.NoError(t, err)
+
+	second, err := cache.Load(ctx, entity.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "updated elsewhere", second.Value(), "cached entity should be refreshed")
+	assert.NotSame(t, first, second)
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.RefreshedEvents)
+
+	now = now.Add(time.Minute)
+
+	_, err = cache.Load(ctx, entity.ID())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), cache.Stats().Expirations)
+
+	for i := 0; i < 2; i++ {
+		other, err := eventstoretest.NewEntity(eventstoretest.NewID())
+		require.NoError(t, err)
+		err = cache.Save(ctx, other)
+		require.NoError(t, err)
+		_, err = cache.Load(ctx, other.ID())
+		require.NoError(t, err)
+	}
+
+	stats = cache.Stats()
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.Entries)
+
+	loaded, err := cache.Load(ctx, entity.ID())
+	require.NoError(t, err)
+	err = loaded.Update("saved")
+	require.NoError(t, err)
+	err = cache.Save(ctx, loaded)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), cache.Stats().Invalidations)
+}
+
+func TestCachingStore_copies_events(t *testing.T) {
+	ctx := context.Background()
+
+	cache, err := eventstore.NewCachingStore[taggedEntity](
+		eventstore.NewInMemoryStore[taggedEntity](),
+		eventstore.CacheConfig{},
+	)
+	require.NoError(t, err)
+
+	entity, err := newTaggedEntity(eventstoretest.NewID(), []string{"first"})
+	require.NoError(t, err)
+	err = cache.Save(ctx, entity)
+	require.NoError(t, err)
+
+	loaded, err := cache.Load(ctx, entity.stream.ID())
+	require.NoError(t, err)
+	loaded.tags[0] = "changed"
+
+	events, err := cache.LoadEvents(ctx, entity.stream.ID(), 0)
+	require.NoError(t, err)
+	events.Events[0].Event.(tagsSet).Tags[0] = "changed"
+
+	loaded, err = cache.Load(ctx, entity.stream.ID())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first"}, loaded.tags)
+	assert.Equal(t, uint64(2), cache.Stats().Hits)
+}
+
+func TestCachingStore_copies_unexported_fields(t *testing.T) {
+	ctx := context.Background()
+
+	cache, err := eventstore.NewCachingStore[taggedEntity](
+		eventstore.NewInMemoryStore[taggedEntity](),
+		eventstore.CacheConfig{},
+	)
+	require.NoError(t, err)
+
+	entity, err := newTaggedEntity(eventstoretest.NewID(), nil)
+	require.NoError(t, err)
+
+	setAt := time.Now()
+	err = entity.stream.Record(entity, labelsSet{labels: []string{"first"}, setAt: setAt})
+	require.NoError(t, err)
+
+	err = cache.Save(ctx, entity)
+	require.NoError(t, err)
+
+	events, err := cache.LoadEvents(ctx, entity.stream.ID(), 0)
+	require.NoError(t, err)
+	events.Events[1].Event.(labelsSet).labels[0] = "changed"
+
+	loaded, err := cache.Load(ctx, entity.stream.ID())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first"}, loaded.tags)
+
+	events, err = cache.LoadEvents(ctx, entity.stream.ID(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first"}, events.Events[1].Event.(labelsSet).labels)
+	assert.True(t, setAt.Equal(events.Events[1].Event.(labelsSet).setAt))
+	assert.Same(t, setAt.Location(), events.Events[1].Event.(labelsSet).setAt.Location())
+}
+
+func TestCachingStore_calls_after_load_hooks(t *testing.T) {
+	ctx := context.Background()
+
+	var loads []int
+	inner, err := eventstore.NewInMemoryStoreWithConfig(eventstore.InMemoryConfig[eventstoretest.Entity]{
+		Hooks: eventstore.Hooks[eventstoretest.Entity]{
+			AfterLoad: []eventstore.AfterLoadHook[eventstoretest.Entity]{
+				func(ctx context.Context, entity *eventstoretest.Entity, events eventstore.StreamEvents[eventstoretest.Entity]) error {
+					loads = append(loads, len(events.Events))
+					return nil
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	cache, err := eventstore.NewCachingStore[eventstoretest.Entity](inner, eventstore.CacheConfig{})
+	require.NoError(t, err)
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = cache.Save(ctx, entity)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = cache.Load(ctx, entity.ID())
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []int{1, 1}, loads)
+	assert.Equal(t, uint64(1), cache.Stats().Hits)
+}
+
+type taggedEntity struct {
+	stream *esja.Stream[taggedEntity]
+	tags   []string
+}
+
+func newTaggedEntity(id string, tags []string) (*taggedEntity, error) {
+	s, err := esja.NewStream[taggedEntity](id)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &taggedEntity{stream: s}
+
+	err = e.stream.Record(e, tagsSet{Tags: tags})
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (e taggedEntity) Stream() *esja.Stream[taggedEntity] {
+	return e.stream
+}
+
+func (e taggedEntity) NewWithStream(stream *esja.Stream[taggedEntity]) *taggedEntity {
+	return &taggedEntity{stream: stream}
+}
+
+type tagsSet struct {
+	Tags []string
+}
+
+func (tagsSet) EventName() string {
+	return "TagsSet_v1"
+}
+
+func (e tagsSet) ApplyTo(entity *taggedEntity) error {
+	entity.tags = e.Tags
+	return nil
+}
+
+type labelsSet struct {
+	labels []string
+	setAt  time.Time
+}
+
+func (labelsSet) EventName() string {
+	return "LabelsSet_v1"
+}
+
+func (e labelsSet) ApplyTo(entity *taggedEntity) error {
+	entity.tags = append([]string(nil), e.labels...)
+	return nil
+}
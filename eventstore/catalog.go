@@ -0,0 +1,130 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const defaultStreamQueryLimit = 100
+
+// StreamCatalog lists the streams kept in the store.
+type StreamCatalog interface {
+	// ListStreams returns a page of streams ordered by the stream ID.
+	ListStreams(ctx context.Context, query StreamQuery) (StreamPage, error)
+}
+
+// StreamQuery filters and pages the streams listed by StreamCatalog.
+type StreamQuery struct {
+	// StreamType limits the streams to the type. All streams are listed if empty.
+	StreamType string
+
+	// Payload limits the streams to the ones with at least one event matching all predicates.
+	// See ErrPayloadQueryNotSupported.
+	Payload []PayloadPredicate
+
+	// Cursor is StreamPage.NextCursor of the previous page. Empty for the first page.
+	Cursor string
+
+	// Limit is the maximum number of streams on the page. Defaults to 100.
+	Limit int
+}
+
+func (q StreamQuery) limit() int {
+	if q.Limit == 0 {
+		return defaultStreamQueryLimit
+	}
+	return q.Limit
+}
+
+func (q StreamQuery) validate() error {
+	if q.Limit < 0 {
+		return fmt.Errorf("limit must not be negative")
+	}
+	return nil
+}
+
+// StreamPage is a single page of streams listed by StreamCatalog.
+type StreamPage struct {
+	Streams []StreamInfo
+
+	// NextCursor is the cursor of the next page. Empty if there are no more streams.
+	NextCursor string
+}
+
+// StreamInfo describes a stream kept in the store.
+type StreamInfo struct {
+	StreamID   string
+	StreamType string
+
+	// Version is the version of the last event in the stream.
+	Version    int
+	EventCount int
+
+	FirstStoredAt time.Time
+	LastStoredAt  time.Time
+}
+
+// newStreamPage returns the page of at most limit streams.
+// The streams should contain one more stream than the limit if there's the next page.
+func newStreamPage(streams []StreamInfo, limit int) StreamPage {
+	if len(streams) <= limit {
+		return StreamPage{
+			Streams: streams,
+		}
+	}
+
+	streams = streams[:limit]
+
+	return StreamPage{
+		Streams:    streams,
+		NextCursor: streams[len(streams)-1].StreamID,
+	}
+}
+
+// sqlTime scans timestamps returned as strings by aggregate functions in SQLite.
+type sqlTime struct {
+	time.Time
+}
+
+var sqlTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	time.RFC3339Nano,
+}
+
+func (t *sqlTime) Scan(value any) error {
+	switch v := value.(type) {
+	case time.Time:
+		t.Time = v
+		return nil
+	case string:
+		return t.parse(v)
+	case []byte:
+		return t.parse(string(v))
+	case nil:
+		t.Time = time.Time{}
+		return nil
+	default:
+		return fmt.Errorf("unsupported time value type %T", value)
+	}
+}
+
+func (t *sqlTime) parse(value string) error {
+	for _, layout := range sqlTimeLayouts {
+		parsed, err := time.Parse(layout, value)
+		if err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unsupported time format %q", value)
+}
+
+var _ sql.Scanner = &sqlTime{}
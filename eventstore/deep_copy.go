@@ -0,0 +1,106 @@
+package eventstore
+
+import (
+	"reflect"
+	"time"
+	"unsafe"
+
+	"github.com/ThreeDotsLabs/esja"
+)
+
+var locationType = reflect.TypeOf((*time.Location)(nil))
+
+// deepCopyEvents returns copies of the events sharing no pointers, slices or maps with them,
+// so changing the returned events doesn't change the original ones.
+func deepCopyEvents[T any](events []esja.VersionedEvent[T]) []esja.VersionedEvent[T] {
+	copied := make([]esja.VersionedEvent[T], len(events))
+	for i, e := range events {
+		copied[i] = esja.VersionedEvent[T]{
+			StreamVersion: e.StreamVersion,
+		}
+
+		if e.Event != nil {
+			copied[i].Event = deepCopy(reflect.ValueOf(e.Event), map[uintptr]reflect.Value{}).Interface().(esja.Event[T])
+		}
+	}
+
+	return copied
+}
+
+// deepCopy returns a copy of v, including the unexported struct fields.
+// Channels, functions and time locations (immutable and compared by pointer) are shared.
+func deepCopy(v reflect.Value, pointers map[uintptr]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || v.Type() == locationType {
+			return v
+		}
+
+		// Keeps the pointers to the same value pointing to a single copy.
+		if copied, ok := pointers[v.Pointer()]; ok && copied.Type() == v.Type() {
+			return copied
+		}
+
+		copied := reflect.New(v.Type().Elem())
+		pointers[v.Pointer()] = copied
+		copied.Elem().Set(deepCopy(v.Elem(), pointers))
+
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(deepCopy(v.Elem(), pointers))
+
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+
+		for i := 0; i < v.NumField(); i++ {
+			field := copied.Field(i)
+			if !v.Type().Field(i).IsExported() {
+				// Reflection doesn't allow setting unexported fields, so the field is accessed by its address.
+				field = reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+			}
+
+			field.Set(deepCopy(field, pointers))
+		}
+
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopy(v.Index(i), pointers))
+		}
+
+		return copied
+	case reflect.Array:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopy(v.Index(i), pointers))
+		}
+
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(deepCopy(iter.Key(), pointers), deepCopy(iter.Value(), pointers))
+		}
+
+		return copied
+	default:
+		return v
+	}
+}
@@ -0,0 +1,104 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const defaultEventQueryLimit = 100
+
+// EventQuerier queries events across all streams.
+type EventQuerier[T any] interface {
+	// QueryEvents returns a page of events matching the query, in the order of positions.
+	QueryEvents(ctx context.Context, query EventQuery) (EventPage[T], error)
+}
+
+// EventQuery filters and pages the events returned by EventQuerier.
+type EventQuery struct {
+	// EventNames limits the events to the names. Events of all names are returned if empty.
+	EventNames []string
+
+	// StoredFrom limits the events to the ones stored at or after the time. Ignored if zero.
+	StoredFrom time.Time
+	// StoredTo limits the events to the ones stored before the time. Ignored if zero.
+	// SQLite stores times with the precision of one millisecond.
+	StoredTo time.Time
+
+	// Payload limits the events to the ones matching all predicates.
+	// See ErrPayloadQueryNotSupported.
+	Payload []PayloadPredicate
+
+	// Cursor is EventPage.NextCursor of the previous page. Zero for the first page.
+	Cursor int64
+
+	// Limit is the maximum number of events on the page. Defaults to 100.
+	Limit int
+}
+
+func (q EventQuery) limit() int {
+	if q.Limit == 0 {
+		return defaultEventQueryLimit
+	}
+	return q.Limit
+}
+
+func (q EventQuery) validate() error {
+	if q.Limit < 0 {
+		return fmt.Errorf("limit must not be negative")
+	}
+	if q.Cursor < 0 {
+		return fmt.Errorf("cursor must not be negative")
+	}
+	if !q.StoredFrom.IsZero() && !q.StoredTo.IsZero() && q.StoredTo.Before(q.StoredFrom) {
+		return fmt.Errorf("stored to must not be before stored from")
+	}
+	return nil
+}
+
+func (q EventQuery) matches(eventName string, storedAt time.Time) bool {
+	if len(q.EventNames) > 0 && !containsString(q.EventNames, eventName) {
+		return false
+	}
+	if !q.StoredFrom.IsZero() && storedAt.Before(q.StoredFrom) {
+		return false
+	}
+	if !q.StoredTo.IsZero() && !storedAt.Before(q.StoredTo) {
+		return false
+	}
+	return true
+}
+
+// EventPage is a single page of events returned by EventQuerier.
+type EventPage[T any] struct {
+	Events []RecordedEvent[T]
+
+	// NextCursor is the cursor of the next page. Zero if there are no more events.
+	NextCursor int64
+}
+
+// newEventPage returns the page of at most limit events.
+// The events should contain one more event than the limit if there's the next page.
+func newEventPage[T any](events []RecordedEvent[T], limit int) EventPage[T] {
+	if len(events) <= limit {
+		return EventPage[T]{
+			Events: events,
+		}
+	}
+
+	events = events[:limit]
+
+	return EventPage[T]{
+		Events:     events,
+		NextCursor: events[len(events)-1].Position,
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -17,3 +17,37 @@ type EventStore[T esja.Entity[T]] interface {
 	// Save saves events recorded in the entity's stream.
 	Save(ctx context.Context, entity *T) error
 }
+
+// StreamEvents are the events of a single stream.
+type StreamEvents[T any] struct {
+	StreamID   string
+	StreamType string
+	Events     []esja.VersionedEvent[T]
+
+	// Version is the version of the last stored event read, including the events
+	// left out with UnknownEventSkip. Zero means the version of the last event in Events.
+	Version int
+}
+
+// version returns the version of the stream after the events.
+func (e StreamEvents[T]) version() int {
+	if len(e.Events) > 0 && e.Events[len(e.Events)-1].StreamVersion > e.Version {
+		return e.Events[len(e.Events)-1].StreamVersion
+	}
+
+	return e.Version
+}
+
+// EventsLoader loads the stored events of a stream without building the entity.
+type EventsLoader[T any] interface {
+	// LoadEvents returns events of the stream with versions greater than afterVersion.
+	// Events are empty if there are no such events.
+	LoadEvents(ctx context.Context, id string, afterVersion int) (StreamEvents[T], error)
+}
+
+// StreamDeleter deletes stored streams.
+type StreamDeleter interface {
+	// DeleteStream deletes all events of the stream.
+	// It returns ErrEntityNotFound if the stream has no events.
+	DeleteStream(ctx context.Context, id string) error
+}
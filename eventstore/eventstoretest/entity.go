@@ -0,0 +1,97 @@
+package eventstoretest
+
+import (
+	"github.com/ThreeDotsLabs/esja"
+)
+
+// Entity is the esja.Entity used by the test suite.
+// Stores under test must be configured to support its events.
+type Entity struct {
+	stream *esja.Stream[Entity]
+
+	id      string
+	value   string
+	updates int
+}
+
+// NewEntity creates a new Entity with the provided ID.
+func NewEntity(id string) (*Entity, error) {
+	s, err := esja.NewStreamWithType[Entity](id, "Entity")
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Entity{
+		stream: s,
+	}
+
+	err = e.stream.Record(e, Created{
+		ID: id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (e *Entity) Update(value string) error {
+	return e.stream.Record(e, Updated{
+		Value: value,
+	})
+}
+
+func (e Entity) Stream() *esja.Stream[Entity] {
+	return e.stream
+}
+
+func (e Entity) NewWithStream(stream *esja.Stream[Entity]) *Entity {
+	return &Entity{stream: stream}
+}
+
+func (e Entity) ID() string {
+	return e.id
+}
+
+func (e Entity) Value() string {
+	return e.value
+}
+
+func (e Entity) Updates() int {
+	return e.updates
+}
+
+type Created struct {
+	ID string
+}
+
+func (Created) EventName() string {
+	return "Created_v1"
+}
+
+func (e Created) ApplyTo(entity *Entity) error {
+	entity.id = e.ID
+	return nil
+}
+
+type Updated struct {
+	Value string
+}
+
+func (Updated) EventName() string {
+	return "Updated_v1"
+}
+
+func (e Updated) ApplyTo(entity *Entity) error {
+	entity.value = e.Value
+	entity.updates++
+	return nil
+}
+
+// SupportedEvents returns all events of the Entity.
+func SupportedEvents() []esja.Event[Entity] {
+	return []esja.Event[Entity]{
+		Created{},
+		Updated{},
+	}
+}
@@ -0,0 +1,707 @@
+// Package eventstoretest provides a conformance test suite
+// for eventstore.EventStore implementations.
+//
+// Example:
+//
+//	func TestMyStore(t *testing.T) {
+//		eventstoretest.TestEventStore(t, func(t *testing.T) eventstore.EventStore[eventstoretest.Entity] {
+//			return NewMyStore[eventstoretest.Entity](eventstoretest.SupportedEvents())
+//		})
+//	}
+package eventstoretest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja/eventstore"
+)
+
+// LargeBatchSize is the number of events saved at once in the large batch test.
+const LargeBatchSize = 1000
+
+// ConcurrentSaves is the number of concurrent saves in the concurrency test.
+const ConcurrentSaves = 10
+
+// StoreFactory returns the EventStore under test.
+// It is called once for each test of the suite.
+type StoreFactory func(t *testing.T) eventstore.EventStore[Entity]
+
+// TestEventStore runs all conformance tests against stores returned by newStore.
+func TestEventStore(t *testing.T, newStore StoreFactory) {
+	t.Run("round_trip", func(t *testing.T) {
+		TestRoundTrip(t, newStore(t))
+	})
+	t.Run("entity_not_found", func(t *testing.T) {
+		TestEntityNotFound(t, newStore(t))
+	})
+	t.Run("empty_save", func(t *testing.T) {
+		TestEmptySave(t, newStore(t))
+	})
+	t.Run("version_conflict", func(t *testing.T) {
+		TestVersionConflict(t, newStore(t))
+	})
+	t.Run("concurrent_saves", func(t *testing.T) {
+		TestConcurrentSaves(t, newStore(t))
+	})
+	t.Run("large_batch", func(t *testing.T) {
+		TestLargeBatch(t, newStore(t))
+	})
+}
+
+// TestRoundTrip checks that saved events are loaded back in order.
+func TestRoundTrip(t *testing.T, store eventstore.EventStore[Entity]) {
+	ctx := context.Background()
+	id := NewID()
+
+	entity, err := NewEntity(id)
+	require.NoError(t, err)
+
+	err = entity.Update("first")
+	require.NoError(t, err)
+
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	loaded, err := store.Load(ctx, id)
+	require.NoError(t, err)
+
+	assert.Equal(t, id, loaded.ID())
+	assert.Equal(t, "first", loaded.Value())
+	assert.Equal(t, 1, loaded.Updates())
+	assert.Equal(t, entity.Stream().Type(), loaded.Stream().Type())
+	assert.Equal(t, 2, loaded.Stream().Version())
+	assert.False(t, loaded.Stream().HasEvents(), "loaded entity should have no queued events")
+
+	err = loaded.Update("second")
+	require.NoError(t, err)
+	err = loaded.Update("third")
+	require.NoError(t, err)
+
+	err = store.Save(ctx, loaded)
+	require.NoError(t, err)
+
+	loaded, err = store.Load(ctx, id)
+	require.NoError(t, err)
+
+	assert.Equal(t, id, loaded.ID())
+	assert.Equal(t, "third", loaded.Value())
+	assert.Equal(t, 3, loaded.Updates())
+}
+
+// TestEntityNotFound checks that loading an unknown ID returns eventstore.ErrEntityNotFound.
+func TestEntityNotFound(t *testing.T, store eventstore.EventStore[Entity]) {
+	_, err := store.Load(context.Background(), NewID())
+	assert.ErrorIs(t, err, eventstore.ErrEntityNotFound)
+}
+
+// TestEmptySave checks that saving an entity without queued events
+// or a nil entity fails.
+func TestEmptySave(t *testing.T, store eventstore.EventStore[Entity]) {
+	ctx := context.Background()
+	id := NewID()
+
+	err := store.Save(ctx, nil)
+	assert.Error(t, err, "saving nil entity should fail")
+
+	entity, err := NewEntity(id)
+	require.NoError(t, err)
+
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	err = store.Save(ctx, entity)
+	assert.Error(t, err, "saving entity without events should fail")
+
+	loaded, err := store.Load(ctx, id)
+	require.NoError(t, err)
+
+	err = store.Save(ctx, loaded)
+	assert.Error(t, err, "saving loaded entity without events should fail")
+}
+
+// TestVersionConflict checks that saving events on top of an outdated version fails
+// and leaves the stored stream intact.
+func TestVersionConflict(t *testing.T, store eventstore.EventStore[Entity]) {
+	ctx := context.Background()
+	id := NewID()
+
+	entity, err := NewEntity(id)
+	require.NoError(t, err)
+
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	first, err := store.Load(ctx, id)
+	require.NoError(t, err)
+
+	second, err := store.Load(ctx, id)
+	require.NoError(t, err)
+
+	err = first.Update("first")
+	require.NoError(t, err)
+
+	err = store.Save(ctx, first)
+	require.NoError(t, err)
+
+	err = second.Update("second")
+	require.NoError(t, err)
+	err = second.Update("second again")
+	require.NoError(t, err)
+
+	err = store.Save(ctx, second)
+	assert.Error(t, err, "saving an outdated version should fail")
+
+	loaded, err := store.Load(ctx, id)
+	require.NoError(t, err)
+
+	assert.Equal(t, "first", loaded.Value())
+	assert.Equal(t, 1, loaded.Updates())
+}
+
+// TestConcurrentSaves checks that only one of many concurrent saves
+// of the same entity version succeeds.
+func TestConcurrentSaves(t *testing.T, store eventstore.EventStore[Entity]) {
+	ctx := context.Background()
+	id := NewID()
+
+	entity, err := NewEntity(id)
+	require.NoError(t, err)
+
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	entities := make([]*Entity, ConcurrentSaves)
+	for i := range entities {
+		entities[i], err = store.Load(ctx, id)
+		require.NoError(t, err)
+
+		err = entities[i].Update(fmt.Sprintf("value-%d", i))
+		require.NoError(t, err)
+	}
+
+	errs := make([]error, ConcurrentSaves)
+
+	wg := sync.WaitGroup{}
+	start := make(chan struct{})
+	for i := range entities {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = store.Save(ctx, entities[i])
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+
+	succeeded := -1
+	for i, err := range errs {
+		if err == nil {
+			assert.Equal(t, -1, succeeded, "more than one concurrent save succeeded")
+			succeeded = i
+		}
+	}
+	require.NotEqual(t, -1, succeeded, "none of the concurrent saves succeeded")
+
+	loaded, err := store.Load(ctx, id)
+	require.NoError(t, err)
+
+	assert.Equal(t, fmt.Sprintf("value-%d", succeeded), loaded.Value())
+	assert.Equal(t, 1, loaded.Updates())
+}
+
+// TestLargeBatch checks that many events can be saved at once.
+func TestLargeBatch(t *testing.T, store eventstore.EventStore[Entity]) {
+	ctx := context.Background()
+	id := NewID()
+
+	entity, err := NewEntity(id)
+	require.NoError(t, err)
+
+	for i := 0; i < LargeBatchSize; i++ {
+		err = entity.Update(fmt.Sprintf("value-%d", i))
+		require.NoError(t, err)
+	}
+
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	loaded, err := store.Load(ctx, id)
+	require.NoError(t, err)
+
+	assert.Equal(t, fmt.Sprintf("value-%d", LargeBatchSize-1), loaded.Value())
+	assert.Equal(t, LargeBatchSize, loaded.Updates())
+}
+
+// NewID returns a random stream ID, so the suite can run against a shared database.
+func NewID() string {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+
+	return "eventstoretest-" + hex.EncodeToString(b)
+}
+
+// TestMultiTenancy checks that streams of different tenants are isolated.
+// The store must be multi-tenant.
+func TestMultiTenancy(t *testing.T, store eventstore.EventStore[Entity]) {
+	ctx := context.Background()
+	id := NewID()
+
+	tenantA := eventstore.WithTenantID(ctx, "tenant-a-"+NewID())
+	tenantB := eventstore.WithTenantID(ctx, "tenant-b-"+NewID())
+
+	entity, err := NewEntity(id)
+	require.NoError(t, err)
+
+	err = store.Save(ctx, entity)
+	assert.ErrorIs(t, err, eventstore.ErrTenantIDRequired)
+
+	_, err = store.Load(ctx, id)
+	assert.ErrorIs(t, err, eventstore.ErrTenantIDRequired)
+
+	entity, err = NewEntity(id)
+	require.NoError(t, err)
+	err = entity.Update("a")
+	require.NoError(t, err)
+
+	err = store.Save(tenantA, entity)
+	require.NoError(t, err)
+
+	_, err = store.Load(tenantB, id)
+	assert.ErrorIs(t, err, eventstore.ErrEntityNotFound, "stream should not be visible to other tenants")
+
+	// The same stream ID can be used by another tenant.
+	entity, err = NewEntity(id)
+	require.NoError(t, err)
+	err = entity.Update("b")
+	require.NoError(t, err)
+
+	err = store.Save(tenantB, entity)
+	require.NoError(t, err)
+
+	loaded, err := store.Load(tenantA, id)
+	require.NoError(t, err)
+	assert.Equal(t, "a", loaded.Value())
+
+	loaded, err = store.Load(tenantB, id)
+	require.NoError(t, err)
+	assert.Equal(t, "b", loaded.Value())
+
+	// Loaded again, so caching stores serve the streams from the cache.
+	err = loaded.Update("b2")
+	require.NoError(t, err)
+	err = store.Save(tenantB, loaded)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		loaded, err = store.Load(tenantA, id)
+		require.NoError(t, err)
+		assert.Equal(t, "a", loaded.Value(), "cached stream should not be visible to other tenants")
+
+		loaded, err = store.Load(tenantB, id)
+		require.NoError(t, err)
+		assert.Equal(t, "b2", loaded.Value(), "cached stream should not be visible to other tenants")
+	}
+}
+
+// TestIdempotency checks that saving the same events with the same idempotency key succeeds
+// without saving them again, and saving different events or versions with the key fails.
+// The store must support idempotency keys.
+func TestIdempotency(t *testing.T, store eventstore.EventStore[Entity]) {
+	ctx := context.Background()
+	id := NewID()
+
+	first := eventstore.WithIdempotencyKey(ctx, "first-"+NewID())
+	second := eventstore.WithIdempotencyKey(ctx, "second-"+NewID())
+
+	newEntity := func() *Entity {
+		entity, err := NewEntity(id)
+		require.NoError(t, err)
+		err = entity.Update("first")
+		require.NoError(t, err)
+		return entity
+	}
+
+	err := store.Save(first, newEntity())
+	require.NoError(t, err)
+
+	// A retry of the same command.
+	err = store.Save(first, newEntity())
+	require.NoError(t, err, "saving the same events with the same key should succeed")
+
+	// The entity loaded again produces only the Updated event, which is not the saved batch.
+	loaded, err := store.Load(ctx, id)
+	require.NoError(t, err)
+	err = loaded.Update("first")
+	require.NoError(t, err)
+	err = store.Save(first, loaded)
+	require.Error(t, err, "different events should not be saved with the same key")
+
+	var conflictErr eventstore.IdempotencyKeyConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, id, conflictErr.StreamID)
+
+	loaded, err = store.Load(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, loaded.Updates(), "events should be saved once")
+
+	err = loaded.Update("second")
+	require.NoError(t, err)
+	err = store.Save(second, loaded)
+	require.NoError(t, err)
+
+	loaded, err = store.Load(ctx, id)
+	require.NoError(t, err)
+	err = loaded.Update("second")
+	require.NoError(t, err)
+	err = store.Save(second, loaded)
+	require.ErrorAs(t, err, &conflictErr, "the same events with the same key on a newer version should not be saved")
+
+	loaded, err = store.Load(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "second", loaded.Value())
+	assert.Equal(t, 2, loaded.Updates())
+}
+
+// CatalogStore is an EventStore implementing eventstore.StreamCatalog.
+type CatalogStore interface {
+	eventstore.EventStore[Entity]
+	eventstore.StreamCatalog
+}
+
+// TestStreamCatalog checks that saved streams are listed page by page.
+// Other streams may exist in the store.
+func TestStreamCatalog(t *testing.T, store CatalogStore) {
+	ctx := context.Background()
+
+	const streams = 5
+	const pageSize = 2
+
+	ids := map[string]int{}
+	for i := 0; i < streams; i++ {
+		id := NewID()
+
+		entity, err := NewEntity(id)
+		require.NoError(t, err)
+		for j := 0; j < i; j++ {
+			err = entity.Update(fmt.Sprintf("value-%d", j))
+			require.NoError(t, err)
+		}
+
+		err = store.Save(ctx, entity)
+		require.NoError(t, err)
+
+		ids[id] = i + 1
+	}
+
+	query := eventstore.StreamQuery{
+		StreamType: "Entity",
+		Limit:      pageSize,
+	}
+
+	listed := map[string]eventstore.StreamInfo{}
+	lastID := ""
+	for {
+		page, err := store.ListStreams(ctx, query)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page.Streams), pageSize)
+
+		for _, info := range page.Streams {
+			assert.Greater(t, info.StreamID, lastID, "streams should be ordered by ID")
+			lastID = info.StreamID
+			listed[info.StreamID] = info
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+
+		require.NotEmpty(t, page.Streams)
+		query.Cursor = page.NextCursor
+	}
+
+	for id, events := range ids {
+		info, ok := listed[id]
+		if !assert.True(t, ok, "stream %s should be listed", id) {
+			continue
+		}
+
+		assert.Equal(t, "Entity", info.StreamType)
+		assert.Equal(t, events, info.Version)
+		assert.Equal(t, events, info.EventCount)
+		assert.False(t, info.FirstStoredAt.IsZero())
+		assert.False(t, info.LastStoredAt.Before(info.FirstStoredAt))
+	}
+
+	page, err := store.ListStreams(ctx, eventstore.StreamQuery{StreamType: "Other"})
+	require.NoError(t, err)
+	for _, info := range page.Streams {
+		assert.NotContains(t, ids, info.StreamID)
+	}
+
+	_, err = store.ListStreams(ctx, eventstore.StreamQuery{Limit: -1})
+	assert.Error(t, err)
+}
+
+// StreamTypeReaderStore is an EventStore implementing eventstore.StreamTypeReader.
+type StreamTypeReaderStore interface {
+	eventstore.EventStore[Entity]
+	eventstore.StreamTypeReader[Entity]
+}
+
+// TestReadByStreamType checks that events of all streams of the type are read in the order of positions.
+// Other streams may exist in the store.
+func TestReadByStreamType(t *testing.T, store StreamTypeReaderStore) {
+	ctx := context.Background()
+
+	first, err := NewEntity(NewID())
+	require.NoError(t, err)
+	second, err := NewEntity(NewID())
+	require.NoError(t, err)
+
+	err = store.Save(ctx, first)
+	require.NoError(t, err)
+	err = store.Save(ctx, second)
+	require.NoError(t, err)
+
+	err = first.Update("first")
+	require.NoError(t, err)
+	err = store.Save(ctx, first)
+	require.NoError(t, err)
+
+	type savedEvent struct {
+		streamID      string
+		streamVersion int
+		eventName     string
+	}
+
+	expected := []savedEvent{
+		{first.ID(), 1, Created{}.EventName()},
+		{second.ID(), 1, Created{}.EventName()},
+		{first.ID(), 2, Updated{}.EventName()},
+	}
+
+	var read []savedEvent
+	var position int64
+	for {
+		events, err := store.ReadByStreamType(ctx, "Entity", position)
+		require.NoError(t, err)
+
+		if len(events) == 0 {
+			break
+		}
+
+		for _, e := range events {
+			assert.Greater(t, e.Position, position, "events should be ordered by position")
+			position = e.Position
+
+			assert.Equal(t, "Entity", e.StreamType)
+			assert.False(t, e.StoredAt.IsZero())
+
+			if e.StreamID == first.ID() || e.StreamID == second.ID() {
+				read = append(read, savedEvent{e.StreamID, e.StreamVersion, e.EventName()})
+				assert.WithinDuration(t, time.Now(), e.StoredAt, time.Minute, "stored time should not depend on the time zone")
+			}
+		}
+	}
+
+	assert.Equal(t, expected, read)
+
+	events, err := store.ReadByStreamType(ctx, "Other", 0)
+	require.NoError(t, err)
+	for _, e := range events {
+		assert.NotEqual(t, first.ID(), e.StreamID)
+		assert.NotEqual(t, second.ID(), e.StreamID)
+	}
+}
+
+// EventQuerierStore is an EventStore implementing eventstore.EventQuerier.
+type EventQuerierStore interface {
+	eventstore.EventStore[Entity]
+	eventstore.EventQuerier[Entity]
+}
+
+// TestQueryEvents checks that events are queried by names and the time they were stored, page by page.
+// Other streams may exist in the store.
+func TestQueryEvents(t *testing.T, store EventQuerierStore) {
+	ctx := context.Background()
+
+	// Some stores keep the time with the precision of one millisecond.
+	start := time.Now().Add(-time.Second)
+
+	first, err := NewEntity(NewID())
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		err = first.Update(fmt.Sprintf("first-%d", i))
+		require.NoError(t, err)
+	}
+
+	second, err := NewEntity(NewID())
+	require.NoError(t, err)
+	err = second.Update("second")
+	require.NoError(t, err)
+
+	err = store.Save(ctx, first)
+	require.NoError(t, err)
+	err = store.Save(ctx, second)
+	require.NoError(t, err)
+
+	// Other events may exist in the store.
+	streams := map[string]bool{first.ID(): true, second.ID(): true}
+
+	queryAll := func(query eventstore.EventQuery) []eventstore.RecordedEvent[Entity] {
+		var events []eventstore.RecordedEvent[Entity]
+		var position int64
+
+		for {
+			page, err := store.QueryEvents(ctx, query)
+			require.NoError(t, err)
+
+			if query.Limit > 0 {
+				require.LessOrEqual(t, len(page.Events), query.Limit)
+			}
+
+			for _, e := range page.Events {
+				assert.Greater(t, e.Position, position, "events should be ordered by position")
+				position = e.Position
+
+				if len(query.EventNames) > 0 {
+					assert.Contains(t, query.EventNames, e.EventName())
+				}
+
+				if streams[e.StreamID] {
+					events = append(events, e)
+				}
+			}
+
+			if page.NextCursor == 0 {
+				return events
+			}
+
+			query.Cursor = page.NextCursor
+		}
+	}
+
+	updated := queryAll(eventstore.EventQuery{
+		EventNames: []string{Updated{}.EventName()},
+		StoredFrom: start,
+		Limit:      2,
+	})
+
+	var values []string
+	for _, e := range updated {
+		// Depending on the mapper, loaded events may be pointers.
+		switch event := e.Event.(type) {
+		case Updated:
+			values = append(values, event.Value)
+		case *Updated:
+			values = append(values, event.Value)
+		}
+	}
+	assert.Equal(t, []string{"first-0", "first-1", "first-2", "second"}, values)
+
+	all := queryAll(eventstore.EventQuery{
+		EventNames: []string{Created{}.EventName(), Updated{}.EventName()},
+		StoredFrom: start,
+	})
+	assert.Len(t, all, 6)
+
+	beforeStart := queryAll(eventstore.EventQuery{
+		StoredFrom: start.Add(-time.Hour),
+		StoredTo:   start.Add(-time.Minute),
+	})
+	assert.Empty(t, beforeStart)
+
+	// Stored times are compared with sub-second precision.
+	time.Sleep(50 * time.Millisecond)
+	between := time.Now()
+	time.Sleep(50 * time.Millisecond)
+
+	third, err := NewEntity(NewID())
+	require.NoError(t, err)
+	err = store.Save(ctx, third)
+	require.NoError(t, err)
+	streams[third.ID()] = true
+
+	var afterBetween []string
+	for _, e := range queryAll(eventstore.EventQuery{StoredFrom: between}) {
+		afterBetween = append(afterBetween, e.StreamID)
+	}
+	assert.Equal(t, []string{third.ID()}, afterBetween, "events stored before the time should not be returned")
+
+	var beforeBetween []string
+	for _, e := range queryAll(eventstore.EventQuery{StoredFrom: start, StoredTo: between}) {
+		beforeBetween = append(beforeBetween, e.StreamID)
+	}
+	assert.Len(t, beforeBetween, 6)
+
+	_, err = store.QueryEvents(ctx, eventstore.EventQuery{Limit: -1})
+	assert.Error(t, err)
+}
+
+// DeleterStore is an EventStore implementing eventstore.StreamDeleter.
+type DeleterStore interface {
+	eventstore.EventStore[Entity]
+	eventstore.StreamDeleter
+}
+
+// TestStreamDeletion checks that deleted streams are not found, other streams are kept,
+// and the ID of a deleted stream can be used again.
+func TestStreamDeletion(t *testing.T, store DeleterStore) {
+	ctx := context.Background()
+
+	deleted, err := NewEntity(NewID())
+	require.NoError(t, err)
+	err = deleted.Update("deleted")
+	require.NoError(t, err)
+
+	kept, err := NewEntity(NewID())
+	require.NoError(t, err)
+
+	for _, e := range []*Entity{deleted, kept} {
+		err = store.Save(ctx, e)
+		require.NoError(t, err)
+	}
+
+	_, err = store.Load(ctx, deleted.ID())
+	require.NoError(t, err)
+
+	err = store.DeleteStream(ctx, deleted.ID())
+	require.NoError(t, err)
+
+	_, err = store.Load(ctx, deleted.ID())
+	assert.ErrorIs(t, err, eventstore.ErrEntityNotFound)
+
+	err = store.DeleteStream(ctx, deleted.ID())
+	assert.ErrorIs(t, err, eventstore.ErrEntityNotFound)
+
+	err = store.DeleteStream(ctx, NewID())
+	assert.ErrorIs(t, err, eventstore.ErrEntityNotFound)
+
+	_, err = store.Load(ctx, kept.ID())
+	assert.NoError(t, err)
+
+	recreated, err := NewEntity(deleted.ID())
+	require.NoError(t, err)
+
+	err = store.Save(ctx, recreated)
+	require.NoError(t, err)
+
+	loaded, err := store.Load(ctx, deleted.ID())
+	require.NoError(t, err)
+	assert.Equal(t, 1, loaded.Stream().Version())
+	assert.Empty(t, loaded.Value())
+}
@@ -0,0 +1,668 @@
+package eventstore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ThreeDotsLabs/esja"
+)
+
+const (
+	fileSegmentPattern  = "segment-%020d.log"
+	fileSegmentGlob     = "segment-*.log"
+	fileFrameHeaderSize = 8
+	fileLockName        = "lock"
+)
+
+var fileCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrFileStoreLocked is returned by NewFileStore if the directory is used by another FileStore.
+var ErrFileStoreLocked = errors.New("directory is locked by another file store")
+
+var (
+	errFileStoreClosed = errors.New("file store is closed")
+	errTornFrame       = errors.New("torn frame")
+)
+
+// fileBatch is a single record in the segment file holding all events saved at once.
+// A record with Deleted set and no events marks the deletion of the stream.
+type fileBatch struct {
+	StreamID   string      `json:"stream_id"`
+	StreamType string      `json:"stream_type"`
+	StoredAt   time.Time   `json:"stored_at"`
+	Events     []fileEvent `json:"events"`
+	Deleted    bool        `json:"deleted,omitempty"`
+}
+
+type fileEvent struct {
+	StreamVersion int    `json:"stream_version"`
+	EventName     string `json:"event_name"`
+	EventPayload  []byte `json:"event_payload"`
+}
+
+type fileSegment struct {
+	number int
+	file   *os.File
+}
+
+// fileLocation points to a record in one of the segments.
+type fileLocation struct {
+	segment     int
+	offset      int64
+	size        int64
+	lastVersion int
+}
+
+type fileStream struct {
+	version int
+	batches []fileLocation
+}
+
+// FileStore is an implementation of the EventStore interface
+// keeping events in append-only segment files in a directory.
+//
+// Events saved at once are written as a single checksummed record,
+// so a batch is either stored completely or not at all.
+// Records torn by a crash are truncated when the store is opened,
+// while corrupted records fail opening the store.
+//
+// The directory is locked while the store is open, so NewFileStore returns ErrFileStoreLocked
+// if it's used by another FileStore, in this or another process.
+// The lock is not taken on platforms without file locks, like Plan 9 or WebAssembly.
+type FileStore[T esja.Entity[T]] struct {
+	dir     string
+	config  FileConfig[T]
+	dirLock io.Closer
+
+	lock       sync.RWMutex
+	segments   []fileSegment
+	activeSize int64
+	streams    map[string]*fileStream
+	closed     bool
+}
+
+// NewFileStore opens the FileStore in the provided directory, creating it if needed.
+// The index of streams is rebuilt from the segment files.
+func NewFileStore[T esja.Entity[T]](
+	dir string,
+	config FileConfig[T],
+) (*FileStore[T], error) {
+	config.setDefaults()
+
+	err := config.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("error creating directory: %w", err)
+	}
+
+	dirLock, err := lockFile(filepath.Join(dir, fileLockName))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &FileStore[T]{
+		dir:     dir,
+		config:  config,
+		dirLock: dirLock,
+		streams: map[string]*fileStream{},
+	}
+
+	err = s.open()
+	if err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Close closes the segment files. The store can't be used afterwards.
+func (s *FileStore[T]) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.closed = true
+
+	var errs []error
+	for _, segment := range s.segments {
+		err := segment.file.Close()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	s.segments = nil
+
+	if s.dirLock != nil {
+		err := s.dirLock.Close()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		s.dirLock = nil
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("error closing segments: %v", errs)
+	}
+
+	return nil
+}
+
+// Load loads the entity from the events stored in the segment files.
+func (s *FileStore[T]) Load(ctx context.Context, id string) (*T, error) {
+	events, err := s.LoadEvents(ctx, id, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events.Events) == 0 {
+		return nil, ErrEntityNotFound
+	}
+
+	entity, err := newEntity(ctx, s.config.Hooks, id, events)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.config.Hooks.afterLoad(ctx, entity, events)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+func (s *FileStore[T]) hooks() Hooks[T] {
+	return s.config.Hooks
+}
+
+// LoadEvents loads the stream's events with versions greater than afterVersion.
+func (s *FileStore[T]) LoadEvents(ctx context.Context, id string, afterVersion int) (StreamEvents[T], error) {
+	batches, err := s.readStream(id, afterVersion)
+	if err != nil {
+		return StreamEvents[T]{}, err
+	}
+
+	loaded := StreamEvents[T]{
+		StreamID: id,
+		Events:   []esja.VersionedEvent[T]{},
+	}
+
+	decoder := s.config.eventDecoder()
+
+	for _, b := range batches {
+		loaded.StreamType = b.StreamType
+
+		for _, e := range b.Events {
+			if e.StreamVersion <= afterVersion {
+				continue
+			}
+
+			loaded.Version = e.StreamVersion
+
+			event, ok, err := decoder.decode(ctx, b.StreamID, e.StreamVersion, e.EventName, e.EventPayload)
+			if err != nil {
+				return StreamEvents[T]{}, err
+			}
+			if !ok {
+				continue
+			}
+
+			loaded.Events = append(loaded.Events, esja.VersionedEvent[T]{
+				Event:         event,
+				StreamVersion: e.StreamVersion,
+			})
+		}
+	}
+
+	return loaded, nil
+}
+
+// Save appends the entity's queued events to the active segment file.
+func (s *FileStore[T]) Save(ctx context.Context, t *T) error {
+	if t == nil {
+		return errors.New("target to save must not be nil")
+	}
+
+	stm := *t
+
+	events := stm.Stream().PopEvents()
+	if len(events) == 0 {
+		return errors.New("no events to save")
+	}
+
+	toSave := StreamEvents[T]{
+		StreamID:   stm.Stream().ID(),
+		StreamType: stm.Stream().Type(),
+		Events:     events,
+	}
+
+	err := s.config.Hooks.beforeSave(ctx, t, &toSave)
+	if err != nil {
+		return err
+	}
+
+	batch := fileBatch{
+		StreamID:   toSave.StreamID,
+		StreamType: toSave.StreamType,
+		StoredAt:   time.Now().UTC(),
+		Events:     make([]fileEvent, len(toSave.Events)),
+	}
+
+	for i, event := range toSave.Events {
+		payload, err := marshalEvent(
+			ctx,
+			s.config.Mapper,
+			s.config.Marshaler,
+			toSave.StreamID,
+			event.Event,
+		)
+		if err != nil {
+			return err
+		}
+
+		batch.Events[i] = fileEvent{
+			StreamVersion: event.StreamVersion,
+			EventName:     event.EventName(),
+			EventPayload:  payload,
+		}
+	}
+
+	frame, err := encodeFileFrame(batch)
+	if err != nil {
+		return err
+	}
+
+	err = s.write(batch, frame)
+	if err != nil {
+		return err
+	}
+
+	return s.config.Hooks.afterSave(ctx, t, toSave)
+}
+
+// DeleteStream appends the record marking the stream as deleted and removes it from the index,
+// so its ID can be used again. It returns ErrEntityNotFound if the stream has no events.
+// Records of the deleted events stay in the segment files.
+// Blobs of transport.ClaimCheckMarshaler are kept until they're removed by CollectBlobs.
+func (s *FileStore[T]) DeleteStream(_ context.Context, id string) error {
+	frame, err := encodeFileFrame(fileBatch{
+		StreamID: id,
+		StoredAt: time.Now().UTC(),
+		Deleted:  true,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return errFileStoreClosed
+	}
+
+	if _, ok := s.streams[id]; !ok {
+		return ErrEntityNotFound
+	}
+
+	_, err = s.append(frame)
+	if err != nil {
+		return err
+	}
+
+	delete(s.streams, id)
+
+	return nil
+}
+
+func (s *FileStore[T]) write(batch fileBatch, frame []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return errFileStoreClosed
+	}
+
+	err := s.checkVersion(batch)
+	if err != nil {
+		return err
+	}
+
+	location, err := s.append(frame)
+	if err != nil {
+		return err
+	}
+
+	s.index(batch, location)
+
+	return nil
+}
+
+// readStream reads the stream's records containing events with versions greater than afterVersion.
+func (s *FileStore[T]) readStream(id string, afterVersion int) ([]fileBatch, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.closed {
+		return nil, errFileStoreClosed
+	}
+
+	stream, ok := s.streams[id]
+	if !ok {
+		return nil, nil
+	}
+
+	var batches []fileBatch
+	for _, location := range stream.batches {
+		if location.lastVersion <= afterVersion {
+			continue
+		}
+
+		frame := make([]byte, location.size)
+		_, err := s.segments[location.segment].file.ReadAt(frame, location.offset)
+		if err != nil {
+			return nil, fmt.Errorf("error reading events: %w", err)
+		}
+
+		batch, _, err := decodeFileFrame(frame)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding events: %w", err)
+		}
+
+		batches = append(batches, batch)
+	}
+
+	return batches, nil
+}
+
+func (s *FileStore[T]) open() error {
+	names, err := filepath.Glob(filepath.Join(s.dir, fileSegmentGlob))
+	if err != nil {
+		return fmt.Errorf("error listing segments: %w", err)
+	}
+
+	// Segment numbers are zero-padded, so the names sort in order.
+	sort.Strings(names)
+
+	for i, name := range names {
+		var number int
+		_, err = fmt.Sscanf(filepath.Base(name), fileSegmentPattern, &number)
+		if err != nil {
+			return fmt.Errorf("invalid segment file name %s: %w", name, err)
+		}
+
+		f, err := os.OpenFile(name, os.O_RDWR, 0)
+		if err != nil {
+			return fmt.Errorf("error opening segment: %w", err)
+		}
+
+		s.segments = append(s.segments, fileSegment{
+			number: number,
+			file:   f,
+		})
+
+		size, err := s.recover(len(s.segments)-1, i == len(names)-1)
+		if err != nil {
+			return err
+		}
+
+		s.activeSize = size
+	}
+
+	if len(s.segments) == 0 {
+		return s.rotate()
+	}
+
+	return nil
+}
+
+// recover indexes all records of the segment and returns the segment's size.
+// A torn record at the end of the last segment is truncated.
+func (s *FileStore[T]) recover(segment int, last bool) (int64, error) {
+	f := s.segments[segment].file
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("error reading segment info: %w", err)
+	}
+
+	fileSize := info.Size()
+	r := bufio.NewReader(io.NewSectionReader(f, 0, fileSize))
+
+	var offset int64
+	for offset < fileSize {
+		batch, size, err := readFileFrame(r, fileSize-offset)
+		if errors.Is(err, errTornFrame) && last {
+			err = f.Truncate(offset)
+			if err != nil {
+				return 0, fmt.Errorf("error truncating torn record: %w", err)
+			}
+
+			// The truncation must be durable before new records are appended after it.
+			err = f.Sync()
+			if err != nil {
+				return 0, fmt.Errorf("error syncing truncated segment: %w", err)
+			}
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("segment %s corrupted at offset %d: %w", f.Name(), offset, err)
+		}
+
+		if batch.Deleted {
+			delete(s.streams, batch.StreamID)
+			offset += size
+			continue
+		}
+
+		err = s.checkVersion(batch)
+		if err != nil {
+			return 0, fmt.Errorf("segment %s corrupted at offset %d: %w", f.Name(), offset, err)
+		}
+
+		s.index(batch, fileLocation{
+			segment: segment,
+			offset:  offset,
+			size:    size,
+		})
+
+		offset += size
+	}
+
+	return offset, nil
+}
+
+func (s *FileStore[T]) checkVersion(batch fileBatch) error {
+	var version int
+	if stream, ok := s.streams[batch.StreamID]; ok {
+		version = stream.version
+	}
+
+	for i, e := range batch.Events {
+		if e.StreamVersion != version+i+1 {
+			return fmt.Errorf(
+				"stream version conflict: expected version %d, got %d",
+				version+i+1,
+				e.StreamVersion,
+			)
+		}
+	}
+
+	return nil
+}
+
+func (s *FileStore[T]) index(batch fileBatch, location fileLocation) {
+	stream, ok := s.streams[batch.StreamID]
+	if !ok {
+		stream = &fileStream{}
+		s.streams[batch.StreamID] = stream
+	}
+
+	stream.version = batch.Events[len(batch.Events)-1].StreamVersion
+
+	location.lastVersion = stream.version
+	stream.batches = append(stream.batches, location)
+}
+
+// append writes the record to the active segment, starting a new one if the record doesn't fit.
+func (s *FileStore[T]) append(frame []byte) (fileLocation, error) {
+	if s.activeSize > 0 && s.activeSize+int64(len(frame)) > s.config.SegmentSize {
+		err := s.rotate()
+		if err != nil {
+			return fileLocation{}, err
+		}
+	}
+
+	segment := len(s.segments) - 1
+	f := s.segments[segment].file
+
+	_, err := f.WriteAt(frame, s.activeSize)
+	if err == nil && s.config.Sync == FileSyncAlways {
+		err = f.Sync()
+	}
+	if err != nil {
+		// Don't leave a partial record behind, so the following writes are readable.
+		_ = f.Truncate(s.activeSize)
+		return fileLocation{}, fmt.Errorf("error writing events: %w", err)
+	}
+
+	location := fileLocation{
+		segment: segment,
+		offset:  s.activeSize,
+		size:    int64(len(frame)),
+	}
+
+	s.activeSize += int64(len(frame))
+
+	return location, nil
+}
+
+func (s *FileStore[T]) rotate() error {
+	number := 1
+	if len(s.segments) > 0 {
+		number = s.segments[len(s.segments)-1].number + 1
+	}
+
+	name := filepath.Join(s.dir, fmt.Sprintf(fileSegmentPattern, number))
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("error creating segment: %w", err)
+	}
+
+	if s.config.Sync == FileSyncAlways {
+		err = syncDir(s.dir)
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+
+	s.segments = append(s.segments, fileSegment{
+		number: number,
+		file:   f,
+	})
+	s.activeSize = 0
+
+	return nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("error opening directory: %w", err)
+	}
+
+	defer func() {
+		_ = d.Close()
+	}()
+
+	err = d.Sync()
+	if err != nil {
+		return fmt.Errorf("error syncing directory: %w", err)
+	}
+
+	return nil
+}
+
+// encodeFileFrame encodes the batch as a record:
+// 4 bytes of body length, 4 bytes of body checksum and the JSON body.
+func encodeFileFrame(batch fileBatch) ([]byte, error) {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding events: %w", err)
+	}
+
+	frame := make([]byte, fileFrameHeaderSize+len(body))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(body)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.Checksum(body, fileCRCTable))
+	copy(frame[fileFrameHeaderSize:], body)
+
+	return frame, nil
+}
+
+func decodeFileFrame(frame []byte) (fileBatch, int64, error) {
+	return readFileFrame(bytes.NewReader(frame), int64(len(frame)))
+}
+
+// readFileFrame reads a single record from r, with at most remaining bytes left in the segment.
+// It returns errTornFrame if the record is shorter than its header says, as only the end of the record
+// could have been lost. A zero length is torn as well: records are never empty,
+// so it's the zero-filled tail a crash can leave behind.
+// A complete record with a checksum mismatch is corrupted, not torn.
+func readFileFrame(r io.Reader, remaining int64) (fileBatch, int64, error) {
+	if remaining < fileFrameHeaderSize {
+		return fileBatch{}, 0, errTornFrame
+	}
+
+	header := make([]byte, fileFrameHeaderSize)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return fileBatch{}, 0, err
+	}
+
+	length := int64(binary.BigEndian.Uint32(header[0:4]))
+	checksum := binary.BigEndian.Uint32(header[4:8])
+	size := fileFrameHeaderSize + length
+
+	if length == 0 || size > remaining {
+		return fileBatch{}, 0, errTornFrame
+	}
+
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	if err != nil {
+		return fileBatch{}, 0, err
+	}
+
+	if crc32.Checksum(body, fileCRCTable) != checksum {
+		return fileBatch{}, 0, errors.New("checksum mismatch")
+	}
+
+	var batch fileBatch
+	err = json.Unmarshal(body, &batch)
+	if err != nil {
+		return fileBatch{}, 0, fmt.Errorf("error decoding record: %w", err)
+	}
+
+	if len(batch.Events) == 0 && !batch.Deleted {
+		return fileBatch{}, 0, errors.New("empty record")
+	}
+
+	return batch, size, nil
+}
@@ -0,0 +1,99 @@
+package eventstore
+
+import (
+	"fmt"
+
+	"github.com/ThreeDotsLabs/esja"
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+const defaultFileSegmentSize = 64 * 1024 * 1024
+
+// FileSync defines when FileStore flushes written events to the disk.
+type FileSync int
+
+const (
+	// FileSyncAlways calls fsync after each saved batch of events.
+	// Saved events survive the machine crash.
+	FileSyncAlways FileSync = iota
+	// FileSyncNever leaves flushing to the operating system.
+	// Saved events survive the process crash, but may be lost on the machine crash.
+	FileSyncNever
+)
+
+type FileConfig[T any] struct {
+	Mapper    transport.Mapper[T]
+	Marshaler transport.Marshaler
+	Hooks     Hooks[T]
+
+	// SegmentSize is the size in bytes after which a new segment file is started.
+	// Defaults to 64 MiB.
+	SegmentSize int64
+
+	// Sync defines when written events are flushed to the disk.
+	// Defaults to FileSyncAlways.
+	Sync FileSync
+
+	// UnknownEventPolicy defines how the events that can't be decoded are loaded.
+	// Defaults to UnknownEventFail.
+	UnknownEventPolicy UnknownEventPolicy
+	// OnUnknownEvent is called for each event skipped or replaced with UnknownEvent.
+	OnUnknownEvent UnknownEventHandler
+}
+
+func (c *FileConfig[T]) setDefaults() {
+	if c.SegmentSize == 0 {
+		c.SegmentSize = defaultFileSegmentSize
+	}
+}
+
+func (c FileConfig[T]) validate() error {
+	if c.Mapper == nil {
+		return fmt.Errorf("mapper is nil")
+	}
+	if c.Marshaler == nil {
+		return fmt.Errorf("marshaler is nil")
+	}
+	err := transport.ValidateMapper(c.Mapper)
+	if err != nil {
+		return fmt.Errorf("invalid mapper: %w", err)
+	}
+	if c.SegmentSize < 0 {
+		return fmt.Errorf("segment size must not be negative")
+	}
+	if c.Sync != FileSyncAlways && c.Sync != FileSyncNever {
+		return fmt.Errorf("unknown sync mode %d", c.Sync)
+	}
+	err = c.UnknownEventPolicy.validate()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c FileConfig[T]) eventDecoder() eventDecoder[T] {
+	return eventDecoder[T]{
+		mapper:         c.Mapper,
+		marshaler:      c.Marshaler,
+		policy:         c.UnknownEventPolicy,
+		onUnknownEvent: c.OnUnknownEvent,
+	}
+}
+
+func NewFileConfig[T any](
+	supportedEvents []esja.Event[T],
+) FileConfig[T] {
+	return FileConfig[T]{
+		Mapper:    transport.NewNoOpMapper[T](supportedEvents),
+		Marshaler: transport.JSONMarshaler{},
+	}
+}
+
+func NewMappingFileConfig[T any](
+	supportedEvents []transport.Event[T],
+) FileConfig[T] {
+	return FileConfig[T]{
+		Mapper:    transport.NewDefaultMapper[T](supportedEvents),
+		Marshaler: transport.JSONMarshaler{},
+	}
+}
@@ -0,0 +1,41 @@
+//go:build windows
+
+package eventstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+)
+
+// errorSharingViolation is ERROR_SHARING_VIOLATION, missing in the syscall package.
+const errorSharingViolation syscall.Errno = 32
+
+// lockFile opens the file without sharing, so no other process can open it
+// until the returned closer is closed or the process exits.
+func lockFile(path string) (io.Closer, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := syscall.CreateFile(
+		name,
+		syscall.GENERIC_READ|syscall.GENERIC_WRITE,
+		0,
+		nil,
+		syscall.OPEN_ALWAYS,
+		syscall.FILE_ATTRIBUTE_NORMAL,
+		0,
+	)
+	if err != nil {
+		if errors.Is(err, errorSharingViolation) {
+			return nil, ErrFileStoreLocked
+		}
+		return nil, fmt.Errorf("error opening lock file: %w", err)
+	}
+
+	return os.NewFile(uintptr(handle), path), nil
+}
@@ -0,0 +1,247 @@
+package eventstore_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja/eventstore"
+	"github.com/ThreeDotsLabs/esja/eventstore/eventstoretest"
+)
+
+func TestFileStore(t *testing.T) {
+	eventstoretest.TestEventStore(t, func(t *testing.T) eventstore.EventStore[eventstoretest.Entity] {
+		return newFileStore(t, t.TempDir(), eventstore.NewFileConfig(eventstoretest.SupportedEvents()))
+	})
+}
+
+func TestFileStore_reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	config := eventstore.NewFileConfig(eventstoretest.SupportedEvents())
+	config.SegmentSize = 256
+
+	store := newFileStore(t, dir, config)
+
+	var ids []string
+	for i := 0; i < 10; i++ {
+		entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+		require.NoError(t, err)
+		err = entity.Update("value")
+		require.NoError(t, err)
+
+		err = store.Save(ctx, entity)
+		require.NoError(t, err)
+
+		ids = append(ids, entity.ID())
+	}
+
+	err := store.Close()
+	require.NoError(t, err)
+
+	segments, err := filepath.Glob(filepath.Join(dir, "segment-*.log"))
+	require.NoError(t, err)
+	assert.Greater(t, len(segments), 1, "expected segments to rotate")
+
+	store = newFileStore(t, dir, config)
+
+	for _, id := range ids {
+		loaded, err := store.Load(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "value", loaded.Value())
+
+		err = loaded.Update("new value")
+		require.NoError(t, err)
+
+		err = store.Save(ctx, loaded)
+		require.NoError(t, err)
+	}
+}
+
+func TestFileStore_stream_deletion(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	config := eventstore.NewFileConfig(eventstoretest.SupportedEvents())
+
+	store := newFileStore(t, dir, config)
+	eventstoretest.TestStreamDeletion(t, store)
+
+	deleted, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = store.Save(ctx, deleted)
+	require.NoError(t, err)
+
+	err = store.DeleteStream(ctx, deleted.ID())
+	require.NoError(t, err)
+
+	err = store.Close()
+	require.NoError(t, err)
+
+	store = newFileStore(t, dir, config)
+
+	_, err = store.Load(ctx, deleted.ID())
+	assert.ErrorIs(t, err, eventstore.ErrEntityNotFound, "deletion should survive reopening the store")
+}
+
+func TestFileStore_truncates_torn_writes(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	config := eventstore.NewFileConfig(eventstoretest.SupportedEvents())
+
+	store := newFileStore(t, dir, config)
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	err = entity.Update("torn")
+	require.NoError(t, err)
+
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	err = store.Close()
+	require.NoError(t, err)
+
+	segment := filepath.Join(dir, "segment-00000000000000000001.log")
+	info, err := os.Stat(segment)
+	require.NoError(t, err)
+
+	// Simulate a crash in the middle of writing the second record.
+	err = os.Truncate(segment, info.Size()-5)
+	require.NoError(t, err)
+
+	store = newFileStore(t, dir, config)
+
+	loaded, err := store.Load(ctx, entity.ID())
+	require.NoError(t, err)
+	assert.Equal(t, 0, loaded.Updates(), "torn record should be dropped")
+
+	err = loaded.Update("after recovery")
+	require.NoError(t, err)
+
+	err = store.Save(ctx, loaded)
+	require.NoError(t, err)
+
+	loaded, err = store.Load(ctx, entity.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "after recovery", loaded.Value())
+}
+
+func TestFileStore_truncates_zero_filled_tail(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	config := eventstore.NewFileConfig(eventstoretest.SupportedEvents())
+
+	store := newFileStore(t, dir, config)
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	err = store.Close()
+	require.NoError(t, err)
+
+	segment := filepath.Join(dir, "segment-00000000000000000001.log")
+	info, err := os.Stat(segment)
+	require.NoError(t, err)
+
+	// Simulate a crash leaving the space allocated for the next record filled with zeros.
+	f, err := os.OpenFile(segment, os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = f.Write(make([]byte, 4096))
+	require.NoError(t, err)
+	err = f.Close()
+	require.NoError(t, err)
+
+	store = newFileStore(t, dir, config)
+
+	truncated, err := os.Stat(segment)
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), truncated.Size(), "zero-filled tail should be truncated")
+
+	loaded, err := store.Load(ctx, entity.ID())
+	require.NoError(t, err)
+
+	err = loaded.Update("after recovery")
+	require.NoError(t, err)
+
+	err = store.Save(ctx, loaded)
+	require.NoError(t, err)
+
+	loaded, err = store.Load(ctx, entity.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "after recovery", loaded.Value())
+}
+
+func TestFileStore_fails_on_corrupted_record(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	config := eventstore.NewFileConfig(eventstoretest.SupportedEvents())
+
+	store := newFileStore(t, dir, config)
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	err = store.Close()
+	require.NoError(t, err)
+
+	segment := filepath.Join(dir, "segment-00000000000000000001.log")
+	data, err := os.ReadFile(segment)
+	require.NoError(t, err)
+
+	// A complete record with a changed byte is corrupted, not torn by a crash.
+	data[len(data)-2] ^= 1
+	err = os.WriteFile(segment, data, 0o644)
+	require.NoError(t, err)
+
+	_, err = eventstore.NewFileStore(dir, config)
+	assert.ErrorContains(t, err, "checksum mismatch")
+
+	info, err := os.Stat(segment)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), info.Size(), "corrupted record should not be truncated")
+}
+
+func TestFileStore_locks_directory(t *testing.T) {
+	dir := t.TempDir()
+	config := eventstore.NewFileConfig(eventstoretest.SupportedEvents())
+
+	store := newFileStore(t, dir, config)
+
+	_, err := eventstore.NewFileStore(dir, config)
+	assert.ErrorIs(t, err, eventstore.ErrFileStoreLocked)
+
+	err = store.Close()
+	require.NoError(t, err)
+
+	newFileStore(t, dir, config)
+}
+
+func newFileStore(
+	t *testing.T,
+	dir string,
+	config eventstore.FileConfig[eventstoretest.Entity],
+) *eventstore.FileStore[eventstoretest.Entity] {
+	store, err := eventstore.NewFileStore(dir, config)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+
+	return store
+}
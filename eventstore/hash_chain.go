@@ -0,0 +1,320 @@
+package eventstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+)
+
+const minHashChainKeySize = 32
+
+// IntegrityError is returned when the stored event doesn't match its hash,
+// or the hash of the previous event doesn't match (see SchemaConfig.HashChain).
+type IntegrityError struct {
+	// TenantID is empty without SchemaConfig.MultiTenant.
+	TenantID      string
+	StreamID      string
+	StreamVersion int
+
+	// StoredHash is the hash kept with the event.
+	StoredHash string
+	// ComputedHash is the hash computed from the stored event and the previous event's hash.
+	ComputedHash string
+}
+
+func (e IntegrityError) Error() string {
+	if e.TenantID != "" {
+		return fmt.Sprintf(
+			"integrity check failed for event %d of stream %q of tenant %q: stored hash %s, computed %s",
+			e.StreamVersion,
+			e.StreamID,
+			e.TenantID,
+			e.StoredHash,
+			e.ComputedHash,
+		)
+	}
+
+	return fmt.Sprintf(
+		"integrity check failed for event %d of stream %q: stored hash %s, computed %s",
+		e.StreamVersion,
+		e.StreamID,
+		e.StoredHash,
+		e.ComputedHash,
+	)
+}
+
+// IntegrityReport is the summary of VerifyIntegrity.
+type IntegrityReport struct {
+	// Streams is the number of streams checked.
+	Streams int
+	// Events is the number of events checked.
+	Events int
+	// Errors are the events failing the check.
+	Errors []IntegrityError
+}
+
+// eventHash returns the hex-encoded SHA-256 of the event and the previous event's hash,
+// or HMAC-SHA-256 if the key is set.
+// The first event of the stream has the empty previous hash.
+// Fields are prefixed with their lengths, so they can't be shifted between each other.
+func eventHash(
+	key []byte,
+	previousHash string,
+	tenantID string,
+	streamID string,
+	streamVersion int,
+	eventName string,
+	contentType string,
+	payload []byte,
+) string {
+	var h hash.Hash
+	if key != nil {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+
+	writeField := func(b []byte) {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(b)))
+		h.Write(length[:])
+		h.Write(b)
+	}
+
+	var version [8]byte
+	binary.BigEndian.PutUint64(version[:], uint64(streamVersion))
+
+	writeField([]byte(previousHash))
+	writeField([]byte(tenantID))
+	writeField([]byte(streamID))
+	writeField(version[:])
+	writeField([]byte(eventName))
+	writeField([]byte(contentType))
+	writeField(payload)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// chainEvents sets the hashes of the events being saved, chained to the last saved event.
+func (s SQLStore[T]) chainEvents(ctx context.Context, events []storageEvent[T]) error {
+	first := events[0]
+
+	previousHash := ""
+	if first.StreamVersion > 1 {
+		var found bool
+		var err error
+		previousHash, found, err = s.storedEventHash(ctx, first.streamID, first.StreamVersion-1)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("previous event %d of stream %q not found", first.StreamVersion-1, first.streamID)
+		}
+	}
+
+	tenantID := s.hashedTenantID(ctx)
+
+	for i := range events {
+		e := &events[i]
+		e.hash = eventHash(
+			s.config.HashChainKey,
+			previousHash,
+			tenantID,
+			e.streamID,
+			e.StreamVersion,
+			e.EventName(),
+			s.hashedContentType(e.contentType),
+			e.payload,
+		)
+		previousHash = e.hash
+	}
+
+	return nil
+}
+
+// verifyHashChain checks the loaded events of a single stream, ordered by version.
+// Each event not following the previous loaded one is checked against the stored previous event's hash,
+// so selections not covering the whole stream are checked as well.
+func (s SQLStore[T]) verifyHashChain(ctx context.Context, events []event) error {
+	tenantID := s.hashedTenantID(ctx)
+
+	previousHash := ""
+	previousVersion := 0
+	for i, e := range events {
+		if (i == 0 || e.streamVersion != previousVersion+1) && e.streamVersion > 1 {
+			// A missing previous event fails the check of the event.
+			var err error
+			previousHash, _, err = s.storedEventHash(ctx, e.streamID, e.streamVersion-1)
+			if err != nil {
+				return err
+			}
+		}
+
+		e.tenantID = tenantID
+		if integrityErr := s.checkEventHash(previousHash, e); integrityErr != nil {
+			return *integrityErr
+		}
+
+		previousHash = e.eventHash
+		previousVersion = e.streamVersion
+	}
+
+	return nil
+}
+
+// hashedTenantID returns the tenant ID covered by the hash, empty without SchemaConfig.MultiTenant.
+func (s SQLStore[T]) hashedTenantID(ctx context.Context) string {
+	if !s.config.SchemaAdapter.schemaConfig().MultiTenant {
+		return ""
+	}
+
+	tenantID, _ := TenantIDFromContext(ctx)
+	return tenantID
+}
+
+// hashedContentType returns the content type covered by the hash, empty without SchemaConfig.ContentType,
+// as it's not stored then.
+func (s SQLStore[T]) hashedContentType(contentType string) string {
+	if !s.config.SchemaAdapter.schemaConfig().ContentType {
+		return ""
+	}
+
+	return contentType
+}
+
+func (s SQLStore[T]) checkEventHash(previousHash string, e event) *IntegrityError {
+	computed := eventHash(s.config.HashChainKey, previousHash, e.tenantID, e.streamID, e.streamVersion, e.eventName, e.contentType, e.eventPayload)
+	if computed == e.eventHash {
+		return nil
+	}
+
+	return &IntegrityError{
+		TenantID:      e.tenantID,
+		StreamID:      e.streamID,
+		StreamVersion: e.streamVersion,
+		StoredHash:    e.eventHash,
+		ComputedHash:  computed,
+	}
+}
+
+func (s SQLStore[T]) storedEventHash(ctx context.Context, streamID string, streamVersion int) (string, bool, error) {
+	query, args, err := s.config.SchemaAdapter.SelectEventHashQuery(ctx, streamID, streamVersion)
+	if err != nil {
+		return "", false, fmt.Errorf("error building select event hash query: %w", err)
+	}
+
+	results, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return "", false, fmt.Errorf("error retrieving row for event hash: %w", err)
+	}
+
+	defer func() {
+		_ = results.Close()
+	}()
+
+	if !results.Next() {
+		return "", false, results.Err()
+	}
+
+	var hash string
+	err = results.Scan(&hash)
+	if err != nil {
+		return "", false, fmt.Errorf("error reading event hash: %w", err)
+	}
+
+	return hash, true, nil
+}
+
+// VerifyIntegrity checks the hash chains of all streams (see SchemaConfig.HashChain).
+// The events failing the check are reported in IntegrityReport.Errors;
+// the returned error is set only if the check couldn't be completed.
+// With SchemaConfig.MultiTenant, the streams of all tenants are checked.
+//
+// Removing the last events of a stream can't be detected, as no event refers to them.
+func (s SQLStore[T]) VerifyIntegrity(ctx context.Context) (IntegrityReport, error) {
+	report := IntegrityReport{}
+
+	var previous event
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		query, args, err := s.config.SchemaAdapter.SelectHashChainQuery(
+			ctx,
+			previous.tenantID,
+			previous.streamID,
+			previous.streamVersion,
+			readBatchSize,
+		)
+		if err != nil {
+			return report, fmt.Errorf("error building select hash chain query: %w", err)
+		}
+
+		batch, err := s.queryHashChain(ctx, query, args)
+		if err != nil {
+			return report, err
+		}
+		if len(batch) == 0 {
+			return report, nil
+		}
+
+		for _, e := range batch {
+			previousHash := previous.eventHash
+			if e.tenantID != previous.tenantID || e.streamID != previous.streamID || report.Events == 0 {
+				report.Streams++
+				previousHash = ""
+			}
+
+			report.Events++
+
+			if integrityErr := s.checkEventHash(previousHash, e); integrityErr != nil {
+				report.Errors = append(report.Errors, *integrityErr)
+			}
+
+			previous = e
+		}
+	}
+}
+
+func (s SQLStore[T]) queryHashChain(ctx context.Context, query string, args []any) ([]event, error) {
+	results, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving rows for hash chain: %w", err)
+	}
+
+	defer func() {
+		_ = results.Close()
+	}()
+
+	var events []event
+	for results.Next() {
+		e := event{}
+
+		err = results.Scan(
+			&e.tenantID,
+			&e.streamID,
+			&e.streamVersion,
+			&e.eventName,
+			&e.eventPayload,
+			&e.contentType,
+			&e.eventHash,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error reading row result: %w", err)
+		}
+
+		events = append(events, e)
+	}
+
+	err = results.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error reading rows: %w", err)
+	}
+
+	return events, nil
+}
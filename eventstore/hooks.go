@@ -0,0 +1,135 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/esja"
+)
+
+// BeforeSaveHook is called before the events are stored.
+// It can validate the events and reject the save by returning an error,
+// or modify them in place.
+type BeforeSaveHook[T any] func(ctx context.Context, entity *T, events *StreamEvents[T]) error
+
+// AfterSaveHook is called after the events are stored.
+// If it returns an error, the error is returned from Save,
+// but the events stay stored.
+type AfterSaveHook[T any] func(ctx context.Context, entity *T, events StreamEvents[T]) error
+
+// AfterLoadHook is called after the entity is built from the loaded events.
+// If it returns an error, the error is returned from Load instead of the entity.
+type AfterLoadHook[T any] func(ctx context.Context, entity *T, events StreamEvents[T]) error
+
+// Stage is a part of Load or Save reported to StageHook.
+type Stage string
+
+const (
+	// StageQuery reads the stored events.
+	StageQuery Stage = "query"
+	// StageDecode unmarshals the stored events and maps them back.
+	StageDecode Stage = "decode"
+	// StageApply builds the entity from the events.
+	StageApply Stage = "apply"
+	// StageEncode maps the events to save and marshals them.
+	StageEncode Stage = "encode"
+	// StageWrite stores the events.
+	StageWrite Stage = "write"
+)
+
+// StageHook is called when a stage of Load or Save starts, e.g. to trace or measure it.
+// The returned context is used during the stage, and the returned function
+// is called with the stage's error when the stage ends.
+//
+// SQLStore reports all stages. InMemoryStore, FileStore and CachingStore report only StageApply.
+type StageHook func(ctx context.Context, stage Stage) (context.Context, func(err error))
+
+// Hooks define the middleware chain called by the event stores around Save and Load.
+// Hooks of each kind are called in order, until one of them returns an error.
+type Hooks[T any] struct {
+	BeforeSave []BeforeSaveHook[T]
+	AfterSave  []AfterSaveHook[T]
+	AfterLoad  []AfterLoadHook[T]
+	Stage      []StageHook
+}
+
+// hooksProvider is implemented by the stores configured with Hooks,
+// so the wrappers can call them.
+type hooksProvider[T any] interface {
+	hooks() Hooks[T]
+}
+
+func (h Hooks[T]) beforeSave(ctx context.Context, entity *T, events *StreamEvents[T]) error {
+	for _, hook := range h.BeforeSave {
+		err := hook(ctx, entity, events)
+		if err != nil {
+			return fmt.Errorf("before save hook: %w", err)
+		}
+	}
+
+	if len(events.Events) == 0 {
+		return fmt.Errorf("before save hook: no events to save left")
+	}
+
+	return nil
+}
+
+func (h Hooks[T]) afterSave(ctx context.Context, entity *T, events StreamEvents[T]) error {
+	for _, hook := range h.AfterSave {
+		err := hook(ctx, entity, events)
+		if err != nil {
+			return fmt.Errorf("after save hook: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (h Hooks[T]) afterLoad(ctx context.Context, entity *T, events StreamEvents[T]) error {
+	for _, hook := range h.AfterLoad {
+		err := hook(ctx, entity, events)
+		if err != nil {
+			return fmt.Errorf("after load hook: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// startStage calls the Stage hooks and returns the context of the stage and the function ending it.
+func (h Hooks[T]) startStage(ctx context.Context, stage Stage) (context.Context, func(error)) {
+	ends := make([]func(error), 0, len(h.Stage))
+	for _, hook := range h.Stage {
+		var end func(error)
+		ctx, end = hook(ctx, stage)
+		ends = append(ends, end)
+	}
+
+	return ctx, func(err error) {
+		// Stages are ended in reverse order, like nested spans.
+		for i := len(ends) - 1; i >= 0; i-- {
+			if ends[i] != nil {
+				ends[i](err)
+			}
+		}
+	}
+}
+
+// newEntity builds the entity from the events within StageApply.
+func newEntity[T esja.Entity[T]](ctx context.Context, hooks Hooks[T], id string, events StreamEvents[T]) (*T, error) {
+	_, end := hooks.startStage(ctx, StageApply)
+
+	versioned := events.Events
+	if version := events.version(); len(versioned) > 0 && version > versioned[len(versioned)-1].StreamVersion {
+		// Keeps the version of the skipped events, so the entity can be saved after them.
+		versioned = append(versioned[:len(versioned):len(versioned)], esja.VersionedEvent[T]{
+			Event:         skippedEvent[T]{},
+			StreamVersion: version,
+		})
+	}
+
+	entity, err := esja.NewEntityWithStreamType(id, events.StreamType, versioned)
+	end(err)
+
+	return entity, err
+}
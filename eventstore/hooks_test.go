@@ -0,0 +1,116 @@
+package eventstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja/eventstore"
+	"github.com/ThreeDotsLabs/esja/eventstore/eventstoretest"
+)
+
+var errFrozen = errors.New("stream is frozen")
+
+func TestHooks(t *testing.T) {
+	testCases := []struct {
+		name     string
+		newStore func(t *testing.T, hooks eventstore.Hooks[eventstoretest.Entity]) eventstore.EventStore[eventstoretest.Entity]
+	}{
+		{
+			name: "in_memory",
+			newStore: func(t *testing.T, hooks eventstore.Hooks[eventstoretest.Entity]) eventstore.EventStore[eventstoretest.Entity] {
+				config := eventstore.NewInMemoryConfig(eventstoretest.SupportedEvents())
+				config.Hooks = hooks
+
+				store, err := eventstore.NewInMemoryStoreWithConfig(config)
+				require.NoError(t, err)
+				return store
+			},
+		},
+		{
+			name: "file",
+			newStore: func(t *testing.T, hooks eventstore.Hooks[eventstoretest.Entity]) eventstore.EventStore[eventstoretest.Entity] {
+				config := eventstore.NewFileConfig(eventstoretest.SupportedEvents())
+				config.Hooks = hooks
+
+				return newFileStore(t, t.TempDir(), config)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			frozen := map[string]bool{}
+			var audit []string
+
+			store := tc.newStore(t, eventstore.Hooks[eventstoretest.Entity]{
+				BeforeSave: []eventstore.BeforeSaveHook[eventstoretest.Entity]{
+					func(_ context.Context, _ *eventstoretest.Entity, events *eventstore.StreamEvents[eventstoretest.Entity]) error {
+						if frozen[events.StreamID] {
+							return errFrozen
+						}
+						return nil
+					},
+					func(_ context.Context, _ *eventstoretest.Entity, events *eventstore.StreamEvents[eventstoretest.Entity]) error {
+						for i, e := range events.Events {
+							if updated, ok := e.Event.(eventstoretest.Updated); ok {
+								updated.Value = "enriched " + updated.Value
+								events.Events[i].Event = updated
+							}
+						}
+						return nil
+					},
+				},
+				AfterSave: []eventstore.AfterSaveHook[eventstoretest.Entity]{
+					func(_ context.Context, entity *eventstoretest.Entity, events eventstore.StreamEvents[eventstoretest.Entity]) error {
+						for _, e := range events.Events {
+							audit = append(audit, "saved "+events.StreamType+" "+e.EventName())
+						}
+						return nil
+					},
+				},
+				AfterLoad: []eventstore.AfterLoadHook[eventstoretest.Entity]{
+					func(_ context.Context, entity *eventstoretest.Entity, events eventstore.StreamEvents[eventstoretest.Entity]) error {
+						audit = append(audit, "loaded "+entity.ID())
+						return nil
+					},
+				},
+			})
+
+			entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+			require.NoError(t, err)
+			err = entity.Update("value")
+			require.NoError(t, err)
+
+			err = store.Save(ctx, entity)
+			require.NoError(t, err)
+
+			loaded, err := store.Load(ctx, entity.ID())
+			require.NoError(t, err)
+			assert.Equal(t, "enriched value", loaded.Value())
+
+			assert.Equal(t, []string{
+				"saved Entity Created_v1",
+				"saved Entity Updated_v1",
+				"loaded " + entity.ID(),
+			}, audit)
+
+			frozen[entity.ID()] = true
+
+			err = loaded.Update("rejected")
+			require.NoError(t, err)
+
+			err = store.Save(ctx, loaded)
+			assert.ErrorIs(t, err, errFrozen)
+
+			loaded, err = store.Load(ctx, entity.ID())
+			require.NoError(t, err)
+			assert.Equal(t, "enriched value", loaded.Value())
+		})
+	}
+}
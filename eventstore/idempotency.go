@@ -0,0 +1,72 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/ThreeDotsLabs/esja"
+)
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a context carrying the idempotency key,
+// usually the ID of the command or the request that produced the events.
+//
+// The key is stored with the batch of events saved with this context.
+// Saving events again with the same key succeeds without saving anything,
+// as long as the events and their stream versions are the same.
+// Otherwise, Save returns IdempotencyKeyConflictError.
+// AfterSave hooks are not called if nothing was saved.
+//
+// Idempotency keys are supported by InMemoryStore
+// and SQLStore with SchemaConfig.IdempotencyKeys enabled. Other stores ignore them.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key set with WithIdempotencyKey.
+func IdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(idempotencyKeyContextKey{}).(string)
+	if !ok || key == "" {
+		return "", false
+	}
+
+	return key, true
+}
+
+// IdempotencyKeyConflictError is returned by Save if the stream already has events
+// saved with the same idempotency key, but they differ from the events being saved.
+type IdempotencyKeyConflictError struct {
+	StreamID       string
+	IdempotencyKey string
+}
+
+func (e IdempotencyKeyConflictError) Error() string {
+	return fmt.Sprintf(
+		"events saved with idempotency key %q in stream %q differ from the events being saved",
+		e.IdempotencyKey,
+		e.StreamID,
+	)
+}
+
+// sameEvents checks if both lists contain equal events with the same stream versions in the same order.
+func sameEvents[T any](saved []esja.VersionedEvent[T], toSave []esja.VersionedEvent[T]) bool {
+	if len(saved) != len(toSave) {
+		return false
+	}
+
+	for i := range saved {
+		if saved[i].StreamVersion != toSave[i].StreamVersion {
+			return false
+		}
+		if saved[i].EventName() != toSave[i].EventName() {
+			return false
+		}
+		if !reflect.DeepEqual(saved[i].Event, toSave[i].Event) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -3,39 +3,136 @@ package eventstore
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/ThreeDotsLabs/esja"
 )
 
+type inMemoryEvent[T any] struct {
+	streamVersion int
+	eventName     string
+
+	// event is set when the store keeps live events.
+	event esja.Event[T]
+	// payload is set when the store serializes events.
+	payload []byte
+
+	idempotencyKey string
+	storedAt       time.Time
+	position       int64
+}
+
+type inMemoryStream[T any] struct {
+	streamID   string
+	streamType string
+	events     []inMemoryEvent[T]
+}
+
 type InMemoryStore[T esja.Entity[T]] struct {
-	lock   sync.RWMutex
-	events map[string][]esja.VersionedEvent[T]
+	lock         sync.RWMutex
+	streams      map[string]*inMemoryStream[T]
+	lastPosition int64
+	config       InMemoryConfig[T]
 }
 
+// NewInMemoryStore creates a new InMemoryStore keeping live events in memory.
 func NewInMemoryStore[T esja.Entity[T]]() *InMemoryStore[T] {
 	return &InMemoryStore[T]{
-		lock:   sync.RWMutex{},
-		events: map[string][]esja.VersionedEvent[T]{},
+		lock:    sync.RWMutex{},
+		streams: map[string]*inMemoryStream[T]{},
 	}
 }
 
-func (i *InMemoryStore[T]) Load(_ context.Context, id string) (*T, error) {
+// NewInMemoryStoreWithConfig creates a new InMemoryStore with the provided config.
+func NewInMemoryStoreWithConfig[T esja.Entity[T]](config InMemoryConfig[T]) (*InMemoryStore[T], error) {
+	err := config.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	s := NewInMemoryStore[T]()
+	s.config = config
+
+	return s, nil
+}
+
+func (i *InMemoryStore[T]) Load(ctx context.Context, id string) (*T, error) {
+	events, err := i.LoadEvents(ctx, id, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events.Events) == 0 {
+		return nil, ErrEntityNotFound
+	}
+
+	entity, err := newEntity(ctx, i.config.Hooks, id, events)
+	if err != nil {
+		return nil, err
+	}
+
+	err = i.config.Hooks.afterLoad(ctx, entity, events)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+func (i *InMemoryStore[T]) hooks() Hooks[T] {
+	return i.config.Hooks
+}
+
+func (i *InMemoryStore[T]) LoadEvents(ctx context.Context, id string, afterVersion int) (StreamEvents[T], error) {
 	i.lock.RLock()
 	defer i.lock.RUnlock()
 
-	events, ok := i.events[id]
+	key, err := i.streamKey(ctx, id)
+	if err != nil {
+		return StreamEvents[T]{}, err
+	}
+
+	loaded := StreamEvents[T]{
+		StreamID: id,
+		Events:   []esja.VersionedEvent[T]{},
+	}
+
+	stream, ok := i.streams[key]
 	if !ok {
-		return nil, ErrEntityNotFound
+		return loaded, nil
 	}
 
-	return esja.NewEntity(id, events)
-}
+	loaded.StreamType = stream.streamType
 
-func (i *InMemoryStore[T]) Save(_ context.Context, t *T) error {
-	i.lock.Lock()
-	defer i.lock.Unlock()
+	for _, e := range stream.events {
+		if e.streamVersion <= afterVersion {
+			continue
+		}
+
+		loaded.Version = e.streamVersion
 
+		event, ok, err := i.load(ctx, id, e)
+		if err != nil {
+			return StreamEvents[T]{}, err
+		}
+		if !ok {
+			continue
+		}
+
+		loaded.Events = append(loaded.Events, esja.VersionedEvent[T]{
+			Event:         event,
+			StreamVersion: e.streamVersion,
+		})
+	}
+
+	return loaded, nil
+}
+
+func (i *InMemoryStore[T]) Save(ctx context.Context, t *T) error {
 	if t == nil {
 		return errors.New("target to save must not be nil")
 	}
@@ -47,18 +144,366 @@ func (i *InMemoryStore[T]) Save(_ context.Context, t *T) error {
 		return errors.New("no events to save")
 	}
 
-	if priorEvents, ok := i.events[stm.Stream().ID()]; !ok {
-		i.events[stm.Stream().ID()] = events
-	} else {
-		for _, event := range events {
-			if len(priorEvents) > 0 {
-				if priorEvents[len(priorEvents)-1].StreamVersion >= event.StreamVersion {
-					return errors.New("stream version duplicate")
-				}
-			}
-			i.events[stm.Stream().ID()] = append(i.events[stm.Stream().ID()], event)
+	toSave := StreamEvents[T]{
+		StreamID:   stm.Stream().ID(),
+		StreamType: stm.Stream().Type(),
+		Events:     events,
+	}
+
+	key, err := i.streamKey(ctx, toSave.StreamID)
+	if err != nil {
+		return err
+	}
+
+	err = i.config.Hooks.beforeSave(ctx, t, &toSave)
+	if err != nil {
+		return err
+	}
+
+	idempotencyKey, _ := IdempotencyKeyFromContext(ctx)
+
+	encoded := make([]inMemoryEvent[T], len(toSave.Events))
+	for j, event := range toSave.Events {
+		e, err := i.encode(ctx, toSave.StreamID, event)
+		if err != nil {
+			return err
 		}
+
+		e.idempotencyKey = idempotencyKey
+		encoded[j] = e
+	}
+
+	appended, err := i.append(ctx, key, toSave, encoded)
+	if err != nil {
+		return err
+	}
+	if !appended {
+		return nil
+	}
+
+	return i.config.Hooks.afterSave(ctx, t, toSave)
+}
+
+// DeleteStream deletes all events of the stream.
+// It returns ErrEntityNotFound if the stream has no events.
+// Blobs of transport.ClaimCheckMarshaler are kept until they're removed by CollectBlobs.
+func (i *InMemoryStore[T]) DeleteStream(ctx context.Context, id string) error {
+	key, err := i.streamKey(ctx, id)
+	if err != nil {
+		return err
 	}
 
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if _, ok := i.streams[key]; !ok {
+		return ErrEntityNotFound
+	}
+
+	delete(i.streams, key)
+
 	return nil
 }
+
+// ListStreams returns a page of streams kept in memory.
+func (i *InMemoryStore[T]) ListStreams(ctx context.Context, query StreamQuery) (StreamPage, error) {
+	err := query.validate()
+	if err != nil {
+		return StreamPage{}, fmt.Errorf("invalid query: %w", err)
+	}
+
+	if len(query.Payload) > 0 {
+		return StreamPage{}, ErrPayloadQueryNotSupported
+	}
+
+	// The prefix of keys of the tenant's streams. Empty if the store isn't multi-tenant.
+	tenantPrefix, err := i.streamKey(ctx, "")
+	if err != nil {
+		return StreamPage{}, err
+	}
+
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	streams := []StreamInfo{}
+	for key, stream := range i.streams {
+		if !strings.HasPrefix(key, tenantPrefix) {
+			continue
+		}
+		if stream.streamID <= query.Cursor {
+			continue
+		}
+		if query.StreamType != "" && stream.streamType != query.StreamType {
+			continue
+		}
+
+		streams = append(streams, StreamInfo{
+			StreamID:      stream.streamID,
+			StreamType:    stream.streamType,
+			Version:       stream.events[len(stream.events)-1].streamVersion,
+			EventCount:    len(stream.events),
+			FirstStoredAt: stream.events[0].storedAt,
+			LastStoredAt:  stream.events[len(stream.events)-1].storedAt,
+		})
+	}
+
+	sort.Slice(streams, func(a, b int) bool {
+		return streams[a].StreamID < streams[b].StreamID
+	})
+
+	if len(streams) > query.limit()+1 {
+		streams = streams[:query.limit()+1]
+	}
+
+	return newStreamPage(streams, query.limit()), nil
+}
+
+// ReadByStreamType returns events of streams of the type with positions greater than fromPosition.
+func (i *InMemoryStore[T]) ReadByStreamType(ctx context.Context, streamType string, fromPosition int64) ([]RecordedEvent[T], error) {
+	return i.recordedEvents(ctx, fromPosition, readBatchSize, func(stream *inMemoryStream[T], _ inMemoryEvent[T]) bool {
+		return stream.streamType == streamType
+	})
+}
+
+// QueryEvents returns a page of events matching the query.
+func (i *InMemoryStore[T]) QueryEvents(ctx context.Context, query EventQuery) (EventPage[T], error) {
+	err := query.validate()
+	if err != nil {
+		return EventPage[T]{}, fmt.Errorf("invalid query: %w", err)
+	}
+
+	if len(query.Payload) > 0 {
+		return EventPage[T]{}, ErrPayloadQueryNotSupported
+	}
+
+	events, err := i.recordedEvents(ctx, query.Cursor, query.limit()+1, func(_ *inMemoryStream[T], e inMemoryEvent[T]) bool {
+		return query.matches(e.eventName, e.storedAt)
+	})
+	if err != nil {
+		return EventPage[T]{}, err
+	}
+
+	return newEventPage(events, query.limit()), nil
+}
+
+// recordedEvents returns at most limit matching events with positions greater than fromPosition.
+func (i *InMemoryStore[T]) recordedEvents(
+	ctx context.Context,
+	fromPosition int64,
+	limit int,
+	match func(stream *inMemoryStream[T], e inMemoryEvent[T]) bool,
+) ([]RecordedEvent[T], error) {
+	tenantPrefix, err := i.streamKey(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	type streamEvent struct {
+		stream *inMemoryStream[T]
+		event  inMemoryEvent[T]
+	}
+
+	var found []streamEvent
+	for key, stream := range i.streams {
+		if !strings.HasPrefix(key, tenantPrefix) {
+			continue
+		}
+
+		for _, e := range stream.events {
+			if e.position > fromPosition && match(stream, e) {
+				found = append(found, streamEvent{stream: stream, event: e})
+			}
+		}
+	}
+
+	sort.Slice(found, func(a, b int) bool {
+		return found[a].event.position < found[b].event.position
+	})
+
+	if len(found) > limit {
+		found = found[:limit]
+	}
+
+	events := make([]RecordedEvent[T], len(found))
+	for j, f := range found {
+		event, err := i.loadRecorded(ctx, f.stream.streamID, f.event)
+		if err != nil {
+			return nil, err
+		}
+
+		events[j] = RecordedEvent[T]{
+			VersionedEvent: esja.VersionedEvent[T]{
+				Event:         event,
+				StreamVersion: f.event.streamVersion,
+			},
+			Position:   f.event.position,
+			StreamID:   f.stream.streamID,
+			StreamType: f.stream.streamType,
+			StoredAt:   f.event.storedAt,
+		}
+	}
+
+	return events, nil
+}
+
+// append appends the events to the stream.
+// It returns false if the events were already saved with the same idempotency key.
+func (i *InMemoryStore[T]) append(
+	ctx context.Context,
+	key string,
+	toSave StreamEvents[T],
+	encoded []inMemoryEvent[T],
+) (bool, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	stream, ok := i.streams[key]
+	if !ok {
+		stream = &inMemoryStream[T]{}
+	}
+
+	if encoded[0].idempotencyKey != "" {
+		saved, err := i.savedWithIdempotencyKey(ctx, toSave.StreamID, stream, encoded)
+		if err != nil || saved {
+			return false, err
+		}
+	}
+
+	if len(stream.events) > 0 {
+		lastVersion := stream.events[len(stream.events)-1].streamVersion
+		if lastVersion >= encoded[0].streamVersion {
+			return false, errors.New("stream version duplicate")
+		}
+	}
+
+	storedAt := time.Now()
+	for j := range encoded {
+		i.lastPosition++
+		encoded[j].position = i.lastPosition
+		encoded[j].storedAt = storedAt
+	}
+
+	stream.streamID = toSave.StreamID
+	stream.streamType = toSave.StreamType
+	stream.events = append(stream.events, encoded...)
+	i.streams[key] = stream
+
+	return true, nil
+}
+
+// savedWithIdempotencyKey checks if the events were already saved with the same idempotency key.
+// It returns IdempotencyKeyConflictError if other events were saved with the key.
+func (i *InMemoryStore[T]) savedWithIdempotencyKey(
+	ctx context.Context,
+	streamID string,
+	stream *inMemoryStream[T],
+	encoded []inMemoryEvent[T],
+) (bool, error) {
+	idempotencyKey := encoded[0].idempotencyKey
+
+	var saved []esja.VersionedEvent[T]
+	for _, e := range stream.events {
+		if e.idempotencyKey != idempotencyKey {
+			continue
+		}
+
+		event, ok, err := i.load(ctx, streamID, e)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			continue
+		}
+
+		saved = append(saved, esja.VersionedEvent[T]{Event: event, StreamVersion: e.streamVersion})
+	}
+
+	if len(saved) == 0 {
+		return false, nil
+	}
+
+	toSave := make([]esja.VersionedEvent[T], len(encoded))
+	for j, e := range encoded {
+		event, err := i.decode(ctx, streamID, e)
+		if err != nil {
+			return false, err
+		}
+
+		toSave[j] = esja.VersionedEvent[T]{Event: event, StreamVersion: e.streamVersion}
+	}
+
+	if !sameEvents(saved, toSave) {
+		return false, IdempotencyKeyConflictError{
+			StreamID:       streamID,
+			IdempotencyKey: idempotencyKey,
+		}
+	}
+
+	return true, nil
+}
+
+// streamKey returns the key of the stream in the streams map,
+// prefixed with the tenant ID if the store is multi-tenant.
+func (i *InMemoryStore[T]) streamKey(ctx context.Context, id string) (string, error) {
+	if !i.config.MultiTenant {
+		return id, nil
+	}
+
+	tenantID, err := requireTenantID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return tenantID + "\x00" + id, nil
+}
+
+func (i *InMemoryStore[T]) encode(ctx context.Context, streamID string, event esja.VersionedEvent[T]) (inMemoryEvent[T], error) {
+	e := inMemoryEvent[T]{
+		streamVersion: event.StreamVersion,
+		eventName:     event.EventName(),
+	}
+
+	if !i.config.serializing() {
+		e.event = event.Event
+		return e, nil
+	}
+
+	payload, err := marshalEvent(ctx, i.config.Mapper, i.config.Marshaler, streamID, event.Event)
+	if err != nil {
+		return inMemoryEvent[T]{}, err
+	}
+
+	e.payload = payload
+
+	return e, nil
+}
+
+func (i *InMemoryStore[T]) decode(ctx context.Context, streamID string, e inMemoryEvent[T]) (esja.Event[T], error) {
+	if !i.config.serializing() {
+		return e.event, nil
+	}
+
+	return unmarshalEvent(ctx, i.config.Mapper, i.config.Marshaler, streamID, e.eventName, e.payload)
+}
+
+// load decodes the stored event, applying the unknown event policy.
+// It returns false if the event should be skipped.
+func (i *InMemoryStore[T]) load(ctx context.Context, streamID string, e inMemoryEvent[T]) (esja.Event[T], bool, error) {
+	if !i.config.serializing() {
+		return e.event, true, nil
+	}
+
+	return i.config.eventDecoder().decode(ctx, streamID, e.streamVersion, e.eventName, e.payload)
+}
+
+// loadRecorded works like load, but returns UnknownEvent instead of skipping the event.
+func (i *InMemoryStore[T]) loadRecorded(ctx context.Context, streamID string, e inMemoryEvent[T]) (esja.Event[T], error) {
+	if !i.config.serializing() {
+		return e.event, nil
+	}
+
+	return i.config.eventDecoder().decodeRecorded(ctx, streamID, e.streamVersion, e.eventName, e.payload)
+}
@@ -0,0 +1,78 @@
+package eventstore
+
+import (
+	"fmt"
+
+	"github.com/ThreeDotsLabs/esja"
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+// InMemoryConfig configures the InMemoryStore.
+//
+// If Mapper and Marshaler are set, events are mapped and marshaled on Save,
+// and unmarshaled and mapped back on Load, exactly like in SQLStore.
+// It lets unit tests catch events that can't survive serialization.
+type InMemoryConfig[T any] struct {
+	Mapper    transport.Mapper[T]
+	Marshaler transport.Marshaler
+	Hooks     Hooks[T]
+
+	// MultiTenant partitions streams by the tenant ID taken from the context (see WithTenantID),
+	// the same way SchemaConfig.MultiTenant does for SQLStore.
+	MultiTenant bool
+
+	// UnknownEventPolicy defines how the events that can't be decoded are loaded.
+	// It's used only if the events are serialized. Defaults to UnknownEventFail.
+	UnknownEventPolicy UnknownEventPolicy
+	// OnUnknownEvent is called for each event skipped or replaced with UnknownEvent.
+	OnUnknownEvent UnknownEventHandler
+}
+
+func (c InMemoryConfig[T]) validate() error {
+	if c.Mapper == nil && c.Marshaler != nil {
+		return fmt.Errorf("mapper is nil")
+	}
+	if c.Marshaler == nil && c.Mapper != nil {
+		return fmt.Errorf("marshaler is nil")
+	}
+	if c.Mapper != nil {
+		err := transport.ValidateMapper(c.Mapper)
+		if err != nil {
+			return fmt.Errorf("invalid mapper: %w", err)
+		}
+	}
+	return c.UnknownEventPolicy.validate()
+}
+
+func (c InMemoryConfig[T]) eventDecoder() eventDecoder[T] {
+	return eventDecoder[T]{
+		mapper:         c.Mapper,
+		marshaler:      c.Marshaler,
+		policy:         c.UnknownEventPolicy,
+		onUnknownEvent: c.OnUnknownEvent,
+	}
+}
+
+func (c InMemoryConfig[T]) serializing() bool {
+	return c.Mapper != nil && c.Marshaler != nil
+}
+
+// NewInMemoryConfig returns a config serializing events to JSON the same way NewPostgresSQLConfig does.
+func NewInMemoryConfig[T any](
+	supportedEvents []esja.Event[T],
+) InMemoryConfig[T] {
+	return InMemoryConfig[T]{
+		Mapper:    transport.NewNoOpMapper[T](supportedEvents),
+		Marshaler: transport.JSONMarshaler{},
+	}
+}
+
+// NewMappingInMemoryConfig returns a config serializing events to JSON the same way NewMappingPostgresSQLConfig does.
+func NewMappingInMemoryConfig[T any](
+	supportedEvents []transport.Event[T],
+) InMemoryConfig[T] {
+	return InMemoryConfig[T]{
+		Mapper:    transport.NewDefaultMapper[T](supportedEvents),
+		Marshaler: transport.JSONMarshaler{},
+	}
+}
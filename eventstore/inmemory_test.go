@@ -0,0 +1,139 @@
+package eventstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja"
+	"github.com/ThreeDotsLabs/esja/eventstore"
+	"github.com/ThreeDotsLabs/esja/eventstore/eventstoretest"
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+func TestInMemoryStore(t *testing.T) {
+	eventstoretest.TestEventStore(t, func(t *testing.T) eventstore.EventStore[eventstoretest.Entity] {
+		return eventstore.NewInMemoryStore[eventstoretest.Entity]()
+	})
+}
+
+func TestInMemoryStore_serializing(t *testing.T) {
+	eventstoretest.TestEventStore(t, func(t *testing.T) eventstore.EventStore[eventstoretest.Entity] {
+		store, err := eventstore.NewInMemoryStoreWithConfig(
+			eventstore.NewInMemoryConfig[eventstoretest.Entity](eventstoretest.SupportedEvents()),
+		)
+		require.NoError(t, err)
+		return store
+	})
+}
+
+func TestInMemoryStore_serializing_catches_lossy_events(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := eventstore.NewInMemoryStoreWithConfig(
+		eventstore.InMemoryConfig[lossyEntity]{
+			Mapper:    transport.NewNoOpMapper([]esja.Event[lossyEntity]{lossyEvent{}}),
+			Marshaler: transport.JSONMarshaler{},
+		},
+	)
+	require.NoError(t, err)
+
+	stream, err := esja.NewStream[lossyEntity]("lossy-1")
+	require.NoError(t, err)
+
+	entity := &lossyEntity{stream: stream}
+	err = stream.Record(entity, lossyEvent{value: "lost"})
+	require.NoError(t, err)
+	assert.Equal(t, "lost", entity.value)
+
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	loaded, err := store.Load(ctx, "lossy-1")
+	require.NoError(t, err)
+	assert.Empty(t, loaded.value, "unexported field should not survive JSON serialization")
+}
+
+func TestNewInMemoryStoreWithConfig_invalid(t *testing.T) {
+	_, err := eventstore.NewInMemoryStoreWithConfig(
+		eventstore.InMemoryConfig[lossyEntity]{
+			Marshaler: transport.JSONMarshaler{},
+		},
+	)
+	assert.Error(t, err)
+
+	_, err = eventstore.NewInMemoryStoreWithConfig(
+		eventstore.NewInMemoryConfig(append(eventstoretest.SupportedEvents(), eventstoretest.Created{})),
+	)
+	assert.ErrorContains(t, err, "event name 'Created_v1' is registered by both")
+}
+
+type lossyEntity struct {
+	stream *esja.Stream[lossyEntity]
+	value  string
+}
+
+func (e lossyEntity) Stream() *esja.Stream[lossyEntity] {
+	return e.stream
+}
+
+func (e lossyEntity) NewWithStream(stream *esja.Stream[lossyEntity]) *lossyEntity {
+	return &lossyEntity{stream: stream}
+}
+
+type lossyEvent struct {
+	value string
+}
+
+func (lossyEvent) EventName() string {
+	return "LossyEvent_v1"
+}
+
+func (e lossyEvent) ApplyTo(entity *lossyEntity) error {
+	entity.value = e.value
+	return nil
+}
+
+func TestInMemoryStore_idempotency(t *testing.T) {
+	t.Run("live_events", func(t *testing.T) {
+		eventstoretest.TestIdempotency(t, eventstore.NewInMemoryStore[eventstoretest.Entity]())
+	})
+
+	t.Run("serializing", func(t *testing.T) {
+		store, err := eventstore.NewInMemoryStoreWithConfig(
+			eventstore.NewInMemoryConfig(eventstoretest.SupportedEvents()),
+		)
+		require.NoError(t, err)
+
+		eventstoretest.TestIdempotency(t, store)
+	})
+}
+
+func TestInMemoryStore_stream_catalog(t *testing.T) {
+	eventstoretest.TestStreamCatalog(t, eventstore.NewInMemoryStore[eventstoretest.Entity]())
+}
+
+func TestInMemoryStore_stream_deletion(t *testing.T) {
+	eventstoretest.TestStreamDeletion(t, eventstore.NewInMemoryStore[eventstoretest.Entity]())
+}
+
+func TestInMemoryStore_read_by_stream_type(t *testing.T) {
+	eventstoretest.TestReadByStreamType(t, eventstore.NewInMemoryStore[eventstoretest.Entity]())
+}
+
+func TestInMemoryStore_query_events(t *testing.T) {
+	eventstoretest.TestQueryEvents(t, eventstore.NewInMemoryStore[eventstoretest.Entity]())
+}
+
+func TestInMemoryStore_payload_query_not_supported(t *testing.T) {
+	store := eventstore.NewInMemoryStore[eventstoretest.Entity]()
+	payload := []eventstore.PayloadPredicate{eventstore.PayloadEquals("Value", "value")}
+
+	_, err := store.QueryEvents(context.Background(), eventstore.EventQuery{Payload: payload})
+	assert.ErrorIs(t, err, eventstore.ErrPayloadQueryNotSupported)
+
+	_, err = store.ListStreams(context.Background(), eventstore.StreamQuery{Payload: payload})
+	assert.ErrorIs(t, err, eventstore.ErrPayloadQueryNotSupported)
+}
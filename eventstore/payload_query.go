@@ -0,0 +1,95 @@
+package eventstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrPayloadQueryNotSupported is returned when a query with payload predicates
+// is used with a store that doesn't keep payloads as queryable JSON.
+// Payload predicates are supported only by SQLStore with the Postgres schema adapter.
+var ErrPayloadQueryNotSupported = errors.New("payload predicates are not supported by the store")
+
+type payloadOperator int
+
+const (
+	payloadEquals payloadOperator = iota
+	payloadExists
+	payloadLike
+)
+
+// PayloadPredicate is a condition on the JSON payload of the stored event.
+//
+// Paths are dot-separated keys of the marshaled transport event, e.g. "addressee.name".
+type PayloadPredicate struct {
+	operator payloadOperator
+	path     string
+	value    any
+}
+
+// PayloadEquals matches events with the value at the path equal to the value marshaled to JSON.
+// It's answered with the JSONB containment operator, so it can use the GIN index on payloads.
+func PayloadEquals(path string, value any) PayloadPredicate {
+	return PayloadPredicate{
+		operator: payloadEquals,
+		path:     path,
+		value:    value,
+	}
+}
+
+// PayloadExists matches events with any value at the path.
+func PayloadExists(path string) PayloadPredicate {
+	return PayloadPredicate{
+		operator: payloadExists,
+		path:     path,
+	}
+}
+
+// PayloadLike matches events with the text value at the path matching the SQL LIKE pattern.
+func PayloadLike(path string, pattern string) PayloadPredicate {
+	return PayloadPredicate{
+		operator: payloadLike,
+		path:     path,
+		value:    pattern,
+	}
+}
+
+func (p PayloadPredicate) keys() ([]string, error) {
+	keys := strings.Split(p.path, ".")
+	for _, k := range keys {
+		if k == "" {
+			return nil, fmt.Errorf("invalid payload path %q", p.path)
+		}
+	}
+
+	return keys, nil
+}
+
+// containment returns the JSON document containing the value at the path.
+func (p PayloadPredicate) containment(keys []string) ([]byte, error) {
+	var document any = p.value
+	for i := len(keys) - 1; i >= 0; i-- {
+		document = map[string]any{keys[i]: document}
+	}
+
+	b, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling value of payload path %q: %w", p.path, err)
+	}
+
+	return b, nil
+}
+
+// textArray returns the keys as a Postgres text array literal.
+func textArray(keys []string) string {
+	quoted := make([]string, len(keys))
+	for i, k := range keys {
+		k = strings.ReplaceAll(k, `\`, `\\`)
+		k = strings.ReplaceAll(k, `"`, `\"`)
+		quoted[i] = `"` + k + `"`
+	}
+
+	return "{" + strings.Join(quoted, ",") + "}"
+}
@@ -0,0 +1,127 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+)
+
+// PayloadRewriter returns the new payload of the stored event, or false if it should be kept.
+// It's called only with the payloads read with the default marshaler, see RewritePayloads.
+// transport.EncryptingMarshaler.Reencrypt is a PayloadRewriter; wrap it with
+// transport.ClaimCheckMarshaler.RewriteBlobs to rewrite the offloaded payloads too.
+type PayloadRewriter func(payload []byte) ([]byte, bool, error)
+
+// RewriteResult is the summary of RewritePayloads.
+type RewriteResult struct {
+	// Scanned is the number of events read.
+	Scanned int
+	// Rewritten is the number of events with the payload updated.
+	Rewritten int
+}
+
+// RewritePayloads updates the payloads of the stored events in batches, like re-encrypting them
+// with the current key after the key rotation. Event names, versions and positions are kept.
+//
+// Events are updated one by one, so the job can be stopped and started again.
+// The rewriter should skip already rewritten payloads to make the next runs cheap.
+// With SchemaConfig.MultiTenant, only the events of the tenant from the context are rewritten.
+// With SQLConfig.Marshalers, only the events read with the default marshaler are rewritten,
+// as the rewritten payloads keep their content type. Events of other content types are scanned, but kept.
+func (s SQLStore[T]) RewritePayloads(ctx context.Context, rewrite PayloadRewriter) (RewriteResult, error) {
+	if rewrite == nil {
+		return RewriteResult{}, fmt.Errorf("rewriter is nil")
+	}
+
+	result := RewriteResult{}
+
+	var afterID int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		batch, err := s.selectPayloads(ctx, afterID)
+		if err != nil {
+			return result, err
+		}
+		if len(batch) == 0 {
+			return result, nil
+		}
+
+		for _, p := range batch {
+			result.Scanned++
+			afterID = p.id
+
+			if !s.config.isDefaultContentType(p.contentType) {
+				continue
+			}
+
+			payload, ok, err := rewrite(p.payload)
+			if err != nil {
+				return result, fmt.Errorf("error rewriting payload of event %d: %w", p.id, err)
+			}
+			if !ok {
+				continue
+			}
+
+			query, args, err := s.config.SchemaAdapter.UpdatePayloadQuery(ctx, p.id, payload)
+			if err != nil {
+				return result, fmt.Errorf("error building update payload query: %w", err)
+			}
+
+			_, err = s.db.ExecContext(ctx, query, args...)
+			if err != nil {
+				return result, fmt.Errorf("error updating payload of event %d: %w", p.id, err)
+			}
+
+			result.Rewritten++
+		}
+	}
+}
+
+type storedPayload struct {
+	id          int64
+	payload     []byte
+	contentType string
+}
+
+// selectPayloads reads the whole batch before any updates,
+// as databases like SQLite can't update rows while reading them.
+func (s SQLStore[T]) selectPayloads(ctx context.Context, afterID int64) ([]storedPayload, error) {
+	query, args, err := s.config.SchemaAdapter.SelectPayloadsQuery(ctx, afterID, readBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("error building select payloads query: %w", err)
+	}
+
+	return s.queryPayloads(ctx, query, args)
+}
+
+func (s SQLStore[T]) queryPayloads(ctx context.Context, query string, args []any) ([]storedPayload, error) {
+	results, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving rows for payloads: %w", err)
+	}
+
+	defer func() {
+		_ = results.Close()
+	}()
+
+	var payloads []storedPayload
+	for results.Next() {
+		p := storedPayload{}
+
+		err = results.Scan(&p.id, &p.payload, &p.contentType)
+		if err != nil {
+			return nil, fmt.Errorf("error reading row result: %w", err)
+		}
+
+		payloads = append(payloads, p)
+	}
+
+	err = results.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error reading rows: %w", err)
+	}
+
+	return payloads, nil
+}
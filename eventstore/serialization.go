@@ -0,0 +1,58 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/esja"
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+// marshalEvent maps the event to its transport model and marshals it.
+func marshalEvent[T any](
+	ctx context.Context,
+	mapper transport.Mapper[T],
+	marshaler transport.Marshaler,
+	streamID string,
+	event esja.Event[T],
+) ([]byte, error) {
+	mapped, err := mapper.ToTransport(ctx, streamID, event)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing event: %w", err)
+	}
+
+	payload, err := transport.MarshalContext(ctx, marshaler, mapped)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling event payload: %w", err)
+	}
+
+	return payload, nil
+}
+
+// unmarshalEvent unmarshals the payload into the transport model
+// corresponding to the event name and maps it back to the event.
+func unmarshalEvent[T any](
+	ctx context.Context,
+	mapper transport.Mapper[T],
+	marshaler transport.Marshaler,
+	streamID string,
+	eventName string,
+	payload []byte,
+) (esja.Event[T], error) {
+	event, err := mapper.New(eventName)
+	if err != nil {
+		return nil, fmt.Errorf("error creating new event instance: %w", err)
+	}
+
+	err = transport.UnmarshalContext(ctx, marshaler, payload, event)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling event payload of %s: %w", eventName, err)
+	}
+
+	mappedEvent, err := mapper.FromTransport(ctx, streamID, event)
+	if err != nil {
+		return nil, fmt.Errorf("error deserializing event: %w", err)
+	}
+
+	return mappedEvent, nil
+}
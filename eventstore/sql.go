@@ -17,14 +17,29 @@ type ContextExecutor interface {
 
 type storageEvent[A any] struct {
 	esja.VersionedEvent[A]
-	streamID string
-	payload  []byte
+	streamID    string
+	payload     []byte
+	contentType string
+	hash        string
 }
 
 type schemaAdapter[A any] interface {
 	InitializeSchemaQuery() string
-	SelectQuery(streamID string) (string, []any, error)
-	InsertQuery(streamType string, events []storageEvent[A]) (string, []any, error)
+	SelectQuery(ctx context.Context, streamID string, afterVersion int) (string, []any, error)
+	SelectByIdempotencyKeyQuery(ctx context.Context, streamID string, idempotencyKey string) (string, []any, error)
+	ListStreamsQuery(ctx context.Context, query StreamQuery) (string, []any, error)
+	ReadByStreamTypeQuery(ctx context.Context, streamType string, fromPosition int64, limit int) (string, []any, error)
+	QueryEventsQuery(ctx context.Context, query EventQuery, limit int) (string, []any, error)
+	InsertQuery(ctx context.Context, streamType string, events []storageEvent[A]) (string, []any, error)
+	SelectPayloadsQuery(ctx context.Context, afterID int64, limit int) (string, []any, error)
+	UpdatePayloadQuery(ctx context.Context, id int64, payload []byte) (string, []any, error)
+	SelectAllPayloadsQuery(ctx context.Context, afterID int64, limit int) (string, []any, error)
+	DeleteStreamQuery(ctx context.Context, streamID string) (string, []any, error)
+	SelectEventHashQuery(ctx context.Context, streamID string, streamVersion int) (string, []any, error)
+	SelectHashChainQuery(ctx context.Context, afterTenantID string, afterStreamID string, afterVersion int, limit int) (string, []any, error)
+
+	schemaConfig() SchemaConfig
+	validate() error
 }
 
 // SQLStore is an implementation of the EventStore interface using an SQLStore database.
@@ -71,19 +86,73 @@ func (s SQLStore[T]) initializeSchema(ctx context.Context) error {
 }
 
 type event struct {
+	tenantID      string
 	streamID      string
 	streamVersion int
+	streamType    string
 	eventName     string
 	eventPayload  []byte
+	contentType   string
+	eventHash     string
 }
 
 // Load loads the entity from the database events.
 func (s SQLStore[T]) Load(ctx context.Context, id string) (*T, error) {
-	query, args, err := s.config.SchemaAdapter.SelectQuery(id)
+	events, err := s.LoadEvents(ctx, id, 0)
 	if err != nil {
-		return nil, fmt.Errorf("error building select query: %w", err)
+		return nil, err
 	}
 
+	if len(events.Events) == 0 {
+		return nil, ErrEntityNotFound
+	}
+
+	entity, err := newEntity(ctx, s.config.Hooks, id, events)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.config.Hooks.afterLoad(ctx, entity, events)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
+
+func (s SQLStore[T]) hooks() Hooks[T] {
+	return s.config.Hooks
+}
+
+// LoadEvents loads the stream's events with versions greater than afterVersion.
+func (s SQLStore[T]) LoadEvents(ctx context.Context, id string, afterVersion int) (StreamEvents[T], error) {
+	query, args, err := s.config.SchemaAdapter.SelectQuery(ctx, id, afterVersion)
+	if err != nil {
+		return StreamEvents[T]{}, fmt.Errorf("error building select query: %w", err)
+	}
+
+	return s.queryEvents(ctx, id, query, args)
+}
+
+func (s SQLStore[T]) queryEvents(ctx context.Context, id string, query string, args []any) (StreamEvents[T], error) {
+	stageCtx, end := s.config.Hooks.startStage(ctx, StageQuery)
+	dbEvents, err := s.readEvents(stageCtx, query, args)
+	end(err)
+	if err != nil {
+		return StreamEvents[T]{}, err
+	}
+
+	stageCtx, end = s.config.Hooks.startStage(ctx, StageDecode)
+	loaded, err := s.decodeEvents(stageCtx, id, dbEvents)
+	end(err)
+	if err != nil {
+		return StreamEvents[T]{}, err
+	}
+
+	return loaded, nil
+}
+
+func (s SQLStore[T]) readEvents(ctx context.Context, query string, args []any) ([]event, error) {
 	results, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving rows for events: %w", err)
@@ -97,7 +166,7 @@ func (s SQLStore[T]) Load(ctx context.Context, id string) (*T, error) {
 	for results.Next() {
 		e := event{}
 
-		err = results.Scan(&e.streamID, &e.streamVersion, &e.eventName, &e.eventPayload)
+		err = results.Scan(&e.streamID, &e.streamVersion, &e.streamType, &e.eventName, &e.eventPayload, &e.contentType, &e.eventHash)
 		if err != nil {
 			return nil, fmt.Errorf("error reading row result: %w", err)
 		}
@@ -105,34 +174,204 @@ func (s SQLStore[T]) Load(ctx context.Context, id string) (*T, error) {
 		dbEvents = append(dbEvents, e)
 	}
 
-	if len(dbEvents) == 0 {
-		return nil, ErrEntityNotFound
+	err = results.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error reading rows: %w", err)
 	}
 
-	var events []esja.VersionedEvent[T]
-	for _, e := range dbEvents {
-		event, err := s.config.Mapper.New(e.eventName)
+	if s.config.SchemaAdapter.schemaConfig().HashChain {
+		err = s.verifyHashChain(ctx, dbEvents)
 		if err != nil {
-			return nil, fmt.Errorf("error creating new event instance: %w", err)
+			return nil, err
 		}
+	}
+
+	return dbEvents, nil
+}
 
-		err = s.config.Marshaler.Unmarshal(e.eventPayload, event)
+func (s SQLStore[T]) decodeEvents(ctx context.Context, id string, dbEvents []event) (StreamEvents[T], error) {
+	loaded := StreamEvents[T]{
+		StreamID: id,
+		Events:   []esja.VersionedEvent[T]{},
+	}
+
+	for _, e := range dbEvents {
+		loaded.StreamType = e.streamType
+		loaded.Version = e.streamVersion
+
+		decoder, err := s.config.eventDecoder(e.contentType)
 		if err != nil {
-			return nil, fmt.Errorf("error unmarshaling event payload: %w", err)
+			return StreamEvents[T]{}, err
 		}
 
-		mappedEvent, err := s.config.Mapper.FromTransport(ctx, e.streamID, event)
+		mappedEvent, ok, err := decoder.decode(ctx, e.streamID, e.streamVersion, e.eventName, e.eventPayload)
 		if err != nil {
-			return nil, fmt.Errorf("error deserializing event: %w", err)
+			return StreamEvents[T]{}, err
+		}
+		if !ok {
+			continue
 		}
 
-		events = append(events, esja.VersionedEvent[T]{
+		loaded.Events = append(loaded.Events, esja.VersionedEvent[T]{
 			Event:         mappedEvent,
 			StreamVersion: e.streamVersion,
 		})
 	}
 
-	return esja.NewEntity(id, events)
+	return loaded, nil
+}
+
+// ListStreams returns a page of streams kept in the database.
+func (s SQLStore[T]) ListStreams(ctx context.Context, query StreamQuery) (StreamPage, error) {
+	err := query.validate()
+	if err != nil {
+		return StreamPage{}, fmt.Errorf("invalid query: %w", err)
+	}
+
+	sqlQuery, args, err := s.config.SchemaAdapter.ListStreamsQuery(ctx, query)
+	if err != nil {
+		return StreamPage{}, fmt.Errorf("error building list streams query: %w", err)
+	}
+
+	results, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return StreamPage{}, fmt.Errorf("error retrieving rows for streams: %w", err)
+	}
+
+	defer func() {
+		_ = results.Close()
+	}()
+
+	streams := []StreamInfo{}
+	for results.Next() {
+		var info StreamInfo
+		var firstStoredAt, lastStoredAt sqlTime
+
+		err = results.Scan(
+			&info.StreamID,
+			&info.StreamType,
+			&info.Version,
+			&info.EventCount,
+			&firstStoredAt,
+			&lastStoredAt,
+		)
+		if err != nil {
+			return StreamPage{}, fmt.Errorf("error reading row result: %w", err)
+		}
+
+		info.FirstStoredAt = firstStoredAt.Time
+		info.LastStoredAt = lastStoredAt.Time
+
+		streams = append(streams, info)
+	}
+
+	err = results.Err()
+	if err != nil {
+		return StreamPage{}, fmt.Errorf("error reading rows: %w", err)
+	}
+
+	return newStreamPage(streams, query.limit()), nil
+}
+
+// ReadByStreamType returns events of streams of the type with positions greater than fromPosition.
+// Positions are IDs of the events table rows.
+func (s SQLStore[T]) ReadByStreamType(ctx context.Context, streamType string, fromPosition int64) ([]RecordedEvent[T], error) {
+	query, args, err := s.config.SchemaAdapter.ReadByStreamTypeQuery(ctx, streamType, fromPosition, readBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("error building read by stream type query: %w", err)
+	}
+
+	return s.queryRecordedEvents(ctx, query, args)
+}
+
+// QueryEvents returns a page of events matching the query.
+func (s SQLStore[T]) QueryEvents(ctx context.Context, query EventQuery) (EventPage[T], error) {
+	err := query.validate()
+	if err != nil {
+		return EventPage[T]{}, fmt.Errorf("invalid query: %w", err)
+	}
+
+	// One more event is selected to know if there's the next page.
+	sqlQuery, args, err := s.config.SchemaAdapter.QueryEventsQuery(ctx, query, query.limit()+1)
+	if err != nil {
+		return EventPage[T]{}, fmt.Errorf("error building query events query: %w", err)
+	}
+
+	events, err := s.queryRecordedEvents(ctx, sqlQuery, args)
+	if err != nil {
+		return EventPage[T]{}, err
+	}
+
+	return newEventPage(events, query.limit()), nil
+}
+
+func (s SQLStore[T]) queryRecordedEvents(ctx context.Context, query string, args []any) ([]RecordedEvent[T], error) {
+	results, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving rows for events: %w", err)
+	}
+
+	defer func() {
+		_ = results.Close()
+	}()
+
+	type recordedEvent struct {
+		event
+		position int64
+		storedAt sqlTime
+	}
+
+	var dbEvents []recordedEvent
+	for results.Next() {
+		e := recordedEvent{}
+
+		err = results.Scan(
+			&e.position,
+			&e.streamID,
+			&e.streamVersion,
+			&e.streamType,
+			&e.eventName,
+			&e.eventPayload,
+			&e.contentType,
+			&e.storedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error reading row result: %w", err)
+		}
+
+		dbEvents = append(dbEvents, e)
+	}
+
+	err = results.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error reading rows: %w", err)
+	}
+
+	events := make([]RecordedEvent[T], len(dbEvents))
+	for i, e := range dbEvents {
+		decoder, err := s.config.eventDecoder(e.contentType)
+		if err != nil {
+			return nil, err
+		}
+
+		mappedEvent, err := decoder.decodeRecorded(ctx, e.streamID, e.streamVersion, e.eventName, e.eventPayload)
+		if err != nil {
+			return nil, err
+		}
+
+		events[i] = RecordedEvent[T]{
+			VersionedEvent: esja.VersionedEvent[T]{
+				Event:         mappedEvent,
+				StreamVersion: e.streamVersion,
+			},
+			Position:   e.position,
+			StreamID:   e.streamID,
+			StreamType: e.streamType,
+			StoredAt:   e.storedAt.Time,
+		}
+	}
+
+	return events, nil
 }
 
 // Save saves the entity's queued events to the database.
@@ -148,34 +387,135 @@ func (s SQLStore[T]) Save(ctx context.Context, t *T) (err error) {
 		return errors.New("no events to save")
 	}
 
-	serializedEvents := make([]storageEvent[T], len(events))
-	for i, event := range events {
-		mapped, err := s.config.Mapper.ToTransport(ctx, stm.Stream().ID(), event.Event)
-		if err != nil {
-			return fmt.Errorf("error serializing event: %w", err)
-		}
+	toSave := StreamEvents[T]{
+		StreamID:   stm.Stream().ID(),
+		StreamType: stm.Stream().Type(),
+		Events:     events,
+	}
 
-		payload, err := s.config.Marshaler.Marshal(mapped)
+	err = s.config.Hooks.beforeSave(ctx, t, &toSave)
+	if err != nil {
+		return err
+	}
+
+	stageCtx, end := s.config.Hooks.startStage(ctx, StageEncode)
+	serializedEvents, err := s.encodeEvents(stageCtx, toSave)
+	end(err)
+	if err != nil {
+		return err
+	}
+
+	stageCtx, end = s.config.Hooks.startStage(ctx, StageWrite)
+	saved, err := s.writeEvents(stageCtx, toSave.StreamType, serializedEvents)
+	end(err)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return nil
+	}
+
+	return s.config.Hooks.afterSave(ctx, t, toSave)
+}
+
+func (s SQLStore[T]) encodeEvents(ctx context.Context, toSave StreamEvents[T]) ([]storageEvent[T], error) {
+	contentType, marshaler := s.config.marshaler()
+
+	serializedEvents := make([]storageEvent[T], len(toSave.Events))
+	for i, event := range toSave.Events {
+		payload, err := marshalEvent(
+			ctx,
+			s.config.Mapper,
+			marshaler,
+			toSave.StreamID,
+			event.Event,
+		)
 		if err != nil {
-			return fmt.Errorf("error marshaling event payload: %w", err)
+			return nil, err
 		}
 
 		serializedEvents[i] = storageEvent[T]{
 			VersionedEvent: event,
-			streamID:       stm.Stream().ID(),
+			streamID:       toSave.StreamID,
 			payload:        payload,
+			contentType:    contentType,
+		}
+	}
+
+	return serializedEvents, nil
+}
+
+// writeEvents stores the serialized events.
+// It returns false if the events were already saved with the context's idempotency key.
+func (s SQLStore[T]) writeEvents(ctx context.Context, streamType string, serializedEvents []storageEvent[T]) (bool, error) {
+	if s.config.SchemaAdapter.schemaConfig().HashChain {
+		err := s.chainEvents(ctx, serializedEvents)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	idempotencyKey, hasIdempotencyKey := IdempotencyKeyFromContext(ctx)
+	if !s.config.SchemaAdapter.schemaConfig().IdempotencyKeys {
+		hasIdempotencyKey = false
+	}
+
+	if hasIdempotencyKey {
+		saved, err := s.savedWithIdempotencyKey(ctx, idempotencyKey, serializedEvents)
+		if err != nil {
+			return false, err
+		}
+		if saved {
+			return false, nil
 		}
 	}
 
-	stmType := stm.Stream().Type()
-	query, args, err := s.config.SchemaAdapter.InsertQuery(stmType, serializedEvents)
+	query, args, err := s.config.SchemaAdapter.InsertQuery(ctx, streamType, serializedEvents)
+	if err != nil {
+		return false, fmt.Errorf("error building insert query: %w", err)
+	}
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		if hasIdempotencyKey {
+			// The same events could have been saved concurrently, so the insert failed on the stream version.
+			saved, checkErr := s.savedWithIdempotencyKey(ctx, idempotencyKey, serializedEvents)
+			if saved {
+				return false, nil
+			}
+			if errors.As(checkErr, &IdempotencyKeyConflictError{}) {
+				return false, checkErr
+			}
+		}
+
+		return false, fmt.Errorf("error executing insert query: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if rowsAffected != int64(len(serializedEvents)) {
+		return false, fmt.Errorf("insert did not work")
+	}
+
+	return true, nil
+}
+
+// DeleteStream deletes all events of the stream.
+// It returns ErrEntityNotFound if the stream has no events.
+// Blobs of transport.ClaimCheckMarshaler are kept, as they can be shared;
+// they're removed by CollectBlobs, which can be run periodically with RunBlobCollection.
+func (s SQLStore[T]) DeleteStream(ctx context.Context, id string) error {
+	query, args, err := s.config.SchemaAdapter.DeleteStreamQuery(ctx, id)
 	if err != nil {
-		return fmt.Errorf("error building insert query: %w", err)
+		return fmt.Errorf("error building delete stream query: %w", err)
 	}
 
 	result, err := s.db.ExecContext(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("error executing insert query: %w", err)
+		return fmt.Errorf("error executing delete stream query: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -183,9 +523,58 @@ func (s SQLStore[T]) Save(ctx context.Context, t *T) (err error) {
 		return err
 	}
 
-	if rowsAffected != int64(len(events)) {
-		return fmt.Errorf("insert did not work")
+	if rowsAffected == 0 {
+		return ErrEntityNotFound
 	}
 
 	return nil
 }
+
+// savedWithIdempotencyKey checks if the events were already saved with the idempotency key.
+// It returns IdempotencyKeyConflictError if other events were saved with the key.
+func (s SQLStore[T]) savedWithIdempotencyKey(
+	ctx context.Context,
+	idempotencyKey string,
+	events []storageEvent[T],
+) (bool, error) {
+	streamID := events[0].streamID
+
+	query, args, err := s.config.SchemaAdapter.SelectByIdempotencyKeyQuery(ctx, streamID, idempotencyKey)
+	if err != nil {
+		return false, fmt.Errorf("error building idempotency key query: %w", err)
+	}
+
+	saved, err := s.queryEvents(ctx, streamID, query, args)
+	if err != nil {
+		return false, err
+	}
+
+	if len(saved.Events) == 0 {
+		return false, nil
+	}
+
+	// The events are compared after a round trip, as it's how the saved events are loaded.
+	toSave := make([]esja.VersionedEvent[T], len(events))
+	_, marshaler := s.config.marshaler()
+
+	for i, e := range events {
+		event, err := unmarshalEvent(ctx, s.config.Mapper, marshaler, streamID, e.EventName(), e.payload)
+		if err != nil {
+			return false, err
+		}
+
+		toSave[i] = esja.VersionedEvent[T]{
+			Event:         event,
+			StreamVersion: e.StreamVersion,
+		}
+	}
+
+	if !sameEvents(saved.Events, toSave) {
+		return false, IdempotencyKeyConflictError{
+			StreamID:       streamID,
+			IdempotencyKey: idempotencyKey,
+		}
+	}
+
+	return true, nil
+}
@@ -11,6 +11,25 @@ type SQLConfig[T any] struct {
 	SchemaAdapter schemaAdapter[T]
 	Mapper        transport.Mapper[T]
 	Marshaler     transport.Marshaler
+	Hooks         Hooks[T]
+
+	// Marshalers replaces Marshaler with the marshalers by content type.
+	// New events are marshaled with the default marshaler, and stored events are unmarshaled
+	// with the marshaler of their content type, so the format can be changed without rewriting the events.
+	// It requires SchemaConfig.ContentType, and Marshaler set to nil, as the config constructors set it.
+	Marshalers *transport.MarshalerRegistry
+
+	// UnknownEventPolicy defines how the events that can't be decoded are loaded.
+	// Defaults to UnknownEventFail.
+	UnknownEventPolicy UnknownEventPolicy
+	// OnUnknownEvent is called for each event skipped or replaced with UnknownEvent.
+	OnUnknownEvent UnknownEventHandler
+
+	// HashChainKey makes the hashes of SchemaConfig.HashChain HMAC-SHA-256 keyed with it,
+	// so the events can't be edited along with their hashes without the key.
+	// It must have at least 32 bytes and requires SchemaConfig.HashChain.
+	// Events hashed without the key, or with another one, fail the check.
+	HashChainKey []byte
 }
 
 func (c SQLConfig[T]) validate() error {
@@ -20,12 +39,77 @@ func (c SQLConfig[T]) validate() error {
 	if c.Mapper == nil {
 		return fmt.Errorf("mapper is nil")
 	}
-	if c.Marshaler == nil {
+	if c.Marshaler == nil && c.Marshalers == nil {
 		return fmt.Errorf("marshaler is nil")
 	}
+	if c.Marshaler != nil && c.Marshalers != nil {
+		return fmt.Errorf("marshaler and marshalers must not be both set")
+	}
+	if c.Marshalers != nil && !c.SchemaAdapter.schemaConfig().ContentType {
+		return fmt.Errorf("marshalers require the content type enabled in the schema config")
+	}
+	if c.HashChainKey != nil {
+		if !c.SchemaAdapter.schemaConfig().HashChain {
+			return fmt.Errorf("hash chain key requires the hash chain enabled in the schema config")
+		}
+		if len(c.HashChainKey) < minHashChainKeySize {
+			return fmt.Errorf("hash chain key must have at least %d bytes", minHashChainKeySize)
+		}
+	}
+	err := c.SchemaAdapter.validate()
+	if err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	err = transport.ValidateMapper(c.Mapper)
+	if err != nil {
+		return fmt.Errorf("invalid mapper: %w", err)
+	}
+	err = c.UnknownEventPolicy.validate()
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
+// marshaler returns the marshaler of new events and their content type.
+// The content type is empty if Marshalers is not set.
+func (c SQLConfig[T]) marshaler() (string, transport.Marshaler) {
+	if c.Marshalers == nil {
+		return "", c.Marshaler
+	}
+	return c.Marshalers.Default()
+}
+
+// isDefaultContentType returns true if the events stored with the content type
+// are read with the marshaler of new events.
+func (c SQLConfig[T]) isDefaultContentType(contentType string) bool {
+	if c.Marshalers == nil {
+		return true
+	}
+
+	defaultContentType, _ := c.Marshalers.Default()
+	return c.Marshalers.Resolve(contentType) == defaultContentType
+}
+
+// eventDecoder returns the decoder of the events stored with the content type.
+func (c SQLConfig[T]) eventDecoder(contentType string) (eventDecoder[T], error) {
+	marshaler := c.Marshaler
+	if c.Marshalers != nil {
+		var err error
+		marshaler, err = c.Marshalers.Marshaler(contentType)
+		if err != nil {
+			return eventDecoder[T]{}, fmt.Errorf("error getting marshaler: %w", err)
+		}
+	}
+
+	return eventDecoder[T]{
+		mapper:         c.Mapper,
+		marshaler:      marshaler,
+		policy:         c.UnknownEventPolicy,
+		onUnknownEvent: c.OnUnknownEvent,
+	}, nil
+}
+
 func NewPostgresSQLConfig[T any](
 	supportedEvents []esja.Event[T],
 ) SQLConfig[T] {
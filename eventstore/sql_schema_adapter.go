@@ -1,52 +1,773 @@
 package eventstore
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
-const (
-	defaultEventsTableName = "events"
-	defaultSelectQuery     = `
+const defaultEventsTableName = "events"
+
+var (
+	errIdempotencyKeysDisabled = errors.New("idempotency keys are not enabled in the schema config")
+	errContentTypeDisabled     = errors.New("content type is not enabled in the schema config")
+	errHashChainDisabled       = errors.New("hash chain is not enabled in the schema config")
+	errHashChainRewrite        = errors.New("payloads can't be rewritten with the hash chain enabled")
+)
+
+// SchemaConfig configures optional features of the schema adapters.
+// The features change the events table, so they must be chosen before the table is created.
+type SchemaConfig struct {
+	// TableName is the name of the events table. Defaults to "events".
+	TableName string
+
+	// MultiTenant adds the tenant_id column to the events table.
+	// The tenant ID is taken from the context (see WithTenantID) and used in all queries,
+	// so streams of different tenants are isolated, even if they share the stream ID.
+	MultiTenant bool
+
+	// IdempotencyKeys adds the idempotency_key column to the events table,
+	// so events can be saved with WithIdempotencyKey.
+	// If disabled, the idempotency keys of the context are ignored.
+	IdempotencyKeys bool
+
+	// PayloadIndex adds the GIN index on event payloads,
+	// used by queries with PayloadEquals predicates.
+	// Supported only by the Postgres schema adapter.
+	PayloadIndex bool
+
+	// BinaryPayload stores event payloads as BYTEA instead of JSONB in Postgres,
+	// so payloads don't have to be valid JSON, like the ones of GOBMarshaler or CompressingMarshaler.
+	// Payload predicates and PayloadIndex are not supported with binary payloads.
+	// SQLite always stores payloads as BLOB.
+	BinaryPayload bool
+
+	// ContentType adds the content_type column to the events table,
+	// so events can be unmarshaled with the marshaler they were saved with (see SQLConfig.Marshalers).
+	// An existing table can be migrated by adding the column:
+	//
+	//	ALTER TABLE events ADD COLUMN content_type varchar(255) NOT NULL DEFAULT '';
+	//
+	// Events saved before have the empty content type, read with the legacy marshaler.
+	ContentType bool
+
+	// HashChain adds the event_hash column to the events table, keeping the hash of each event's
+	// tenant ID, stream ID, version, name, content type, payload and the previous event's hash,
+	// so edited events are detected.
+	// Load verifies the chain and returns IntegrityError on mismatch; VerifyIntegrity checks all streams.
+	//
+	// The hashes are plain SHA-256, so they detect accidental edits and edits not recomputing the hashes,
+	// but anyone able to edit the events table can recompute the hashes of the following events as well.
+	// To detect such edits, set SQLConfig.HashChainKey, keeping the key outside the database.
+	// Removing the last events of a stream isn't detected either way, as no event refers to them.
+	// It requires the payloads stored as they are, so with Postgres BinaryPayload must be enabled,
+	// as JSONB normalizes the payloads.
+	HashChain bool
+}
+
+func (c SchemaConfig) tableName() string {
+	if c.TableName == "" {
+		return defaultEventsTableName
+	}
+	return c.TableName
+}
+
+// sqlDialect describes the column types of a database.
+type sqlDialect struct {
+	idColumn       string
+	textType       string
+	intType        string
+	payloadType    string
+	storedAtColumn string
+
+	// timeArg converts the time to the query argument compared with stored_at.
+	timeArg func(t time.Time) any
+
+	// payloadIndex is the index used for payload predicates. Empty if payloads can't be queried.
+	payloadIndex string
+
+	// exactPayload is true if payloads are read exactly as they were written.
+	exactPayload bool
+}
+
+func validateSchema(dialect sqlDialect, config SchemaConfig) error {
+	if config.HashChain && !dialect.exactPayload {
+		return errors.New("hash chain requires payloads stored as they are, enable BinaryPayload")
+	}
+	return nil
+}
+
+func initializeSchemaQuery(dialect sqlDialect, config SchemaConfig) string {
+	var columns []string
+	var indexes []string
+
+	// Index names are unique in the whole database schema in Postgres.
+	indexPrefix := "idx_"
+	if config.tableName() != defaultEventsTableName {
+		indexPrefix = "idx_" + config.tableName() + "_"
+	}
+
+	columns = append(columns, dialect.idColumn)
+
+	streamColumns := "stream_id"
+	if config.MultiTenant {
+		columns = append(columns, "tenant_id "+dialect.textType+" NOT NULL")
+		streamColumns = "tenant_id, stream_id"
+	}
+
+	columns = append(
+		columns,
+		"stream_id "+dialect.textType+" NOT NULL",
+		"stream_version "+dialect.intType+" NOT NULL",
+		"stream_type "+dialect.textType+" NOT NULL",
+		"event_name "+dialect.textType+" NOT NULL",
+		"event_payload "+dialect.payloadType+" NOT NULL",
+		dialect.storedAtColumn,
+	)
+
+	if config.ContentType {
+		columns = append(columns, "content_type "+dialect.textType+" NOT NULL DEFAULT ''")
+	}
+
+	if config.HashChain {
+		columns = append(columns, "event_hash "+dialect.textType+" NOT NULL")
+	}
+
+	if config.IdempotencyKeys {
+		columns = append(columns, "idempotency_key "+dialect.textType)
+		indexes = append(
+			indexes,
+			"CREATE INDEX IF NOT EXISTS "+indexPrefix+"idempotency_key ON %[1]s ("+streamColumns+", idempotency_key);",
+		)
+	}
+
+	if config.MultiTenant {
+		indexes = append(
+			indexes,
+			"CREATE INDEX IF NOT EXISTS "+indexPrefix+"tenant_stream_id ON %[1]s ("+streamColumns+");",
+			"CREATE UNIQUE INDEX IF NOT EXISTS "+indexPrefix+"tenant_stream_id_version ON %[1]s ("+streamColumns+", stream_version);",
+			"CREATE INDEX IF NOT EXISTS "+indexPrefix+"tenant_stream_type ON %[1]s (tenant_id, stream_type, stream_id);",
+			"CREATE INDEX IF NOT EXISTS "+indexPrefix+"tenant_stream_type_id ON %[1]s (tenant_id, stream_type, id);",
+			"CREATE INDEX IF NOT EXISTS "+indexPrefix+"tenant_event_name_id ON %[1]s (tenant_id, event_name, id);",
+		)
+	} else {
+		indexes = append(
+			indexes,
+			"CREATE INDEX IF NOT EXISTS "+indexPrefix+"stream_id ON %[1]s (stream_id);",
+			"CREATE UNIQUE INDEX IF NOT EXISTS "+indexPrefix+"stream_id_version ON %[1]s (stream_id, stream_version);",
+			"CREATE INDEX IF NOT EXISTS "+indexPrefix+"stream_type ON %[1]s (stream_type, stream_id);",
+			"CREATE INDEX IF NOT EXISTS "+indexPrefix+"stream_type_id ON %[1]s (stream_type, id);",
+			"CREATE INDEX IF NOT EXISTS "+indexPrefix+"event_name_id ON %[1]s (event_name, id);",
+		)
+	}
+
+	if config.PayloadIndex && dialect.payloadIndex != "" {
+		indexes = append(
+			indexes,
+			"CREATE INDEX IF NOT EXISTS "+indexPrefix+"event_payload ON %[1]s "+dialect.payloadIndex+";",
+		)
+	}
+
+	query := "\nCREATE TABLE IF NOT EXISTS %[1]s (\n\t" +
+		strings.Join(columns, ",\n\t") +
+		"\n);\n" +
+		strings.Join(indexes, "\n") +
+		"\n"
+
+	return fmt.Sprintf(query, config.tableName())
+}
+
+func selectQuery(
+	ctx context.Context,
+	config SchemaConfig,
+	streamID string,
+	afterVersion int,
+) (string, []any, error) {
+	q := newQueryBuilder()
+
+	q.WriteString(`
 SELECT 
 	stream_id, 
 	stream_version, 
+	stream_type, 
 	event_name, 
-	event_payload
-FROM %s
-WHERE stream_id = $1
+	event_payload, 
+	` + contentTypeColumn(config) + `, 
+	` + eventHashColumn(config) + `
+FROM ` + config.tableName() + `
+WHERE stream_id = ` + q.arg(streamID) + ` AND stream_version > ` + q.arg(afterVersion))
+
+	err := q.whereTenant(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	q.WriteString(`
 ORDER BY stream_version ASC;
-`
-	defaultInsertQuery = `
-INSERT INTO %s (
+`)
+
+	return q.String(), q.args, nil
+}
+
+func selectByIdempotencyKeyQuery(
+	ctx context.Context,
+	config SchemaConfig,
+	streamID string,
+	idempotencyKey string,
+) (string, []any, error) {
+	if !config.IdempotencyKeys {
+		return "", nil, errIdempotencyKeysDisabled
+	}
+
+	q := newQueryBuilder()
+
+	q.WriteString(`
+SELECT 
 	stream_id, 
 	stream_version, 
 	stream_type, 
 	event_name, 
-	event_payload
-)
-VALUES %s
-`
-	defaultInsertMarkersCount   = 5
-	defaultInsertMarkersPattern = "($%d,$%d,$%d,$%d,$%d),"
+	event_payload, 
+	` + contentTypeColumn(config) + `, 
+	` + eventHashColumn(config) + `
+FROM ` + config.tableName() + `
+WHERE stream_id = ` + q.arg(streamID) + ` AND idempotency_key = ` + q.arg(idempotencyKey))
+
+	err := q.whereTenant(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	q.WriteString(`
+ORDER BY stream_version ASC;
+`)
+
+	return q.String(), q.args, nil
+}
+
+func listStreamsQuery(
+	ctx context.Context,
+	dialect sqlDialect,
+	config SchemaConfig,
+	query StreamQuery,
+) (string, []any, error) {
+	q := newQueryBuilder()
+
+	q.WriteString(`
+SELECT 
+	stream_id, 
+	MAX(stream_type), 
+	MAX(stream_version), 
+	COUNT(*), 
+	MIN(stored_at), 
+	MAX(stored_at)
+FROM ` + config.tableName() + `
+WHERE stream_id > ` + q.arg(query.Cursor))
+
+	if query.StreamType != "" {
+		q.WriteString(` AND stream_type = ` + q.arg(query.StreamType))
+	}
+
+	if len(query.Payload) > 0 {
+		q.WriteString(` AND stream_id IN (
+	SELECT stream_id FROM ` + config.tableName() + ` WHERE 1 = 1`)
+
+		err := q.wherePayload(dialect, query.Payload)
+		if err != nil {
+			return "", nil, err
+		}
+
+		err = q.whereTenant(ctx, config)
+		if err != nil {
+			return "", nil, err
+		}
+
+		q.WriteString(`
+)`)
+	}
+
+	err := q.whereTenant(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// One more stream is selected to know if there's the next page.
+	q.WriteString(`
+GROUP BY stream_id
+ORDER BY stream_id ASC
+LIMIT ` + q.arg(query.limit()+1) + `;
+`)
+
+	return q.String(), q.args, nil
+}
+
+func readByStreamTypeQuery(
+	ctx context.Context,
+	config SchemaConfig,
+	streamType string,
+	fromPosition int64,
+	limit int,
+) (string, []any, error) {
+	q := newQueryBuilder()
+
+	q.WriteString(`
+SELECT 
+	id, 
+	stream_id, 
+	stream_version, 
+	stream_type, 
+	event_name, 
+	event_payload, 
+	` + contentTypeColumn(config) + `, 
+	stored_at
+FROM ` + config.tableName() + `
+WHERE stream_type = ` + q.arg(streamType) + ` AND id > ` + q.arg(fromPosition))
+
+	err := q.whereTenant(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	q.WriteString(`
+ORDER BY id ASC
+LIMIT ` + q.arg(limit) + `;
+`)
+
+	return q.String(), q.args, nil
+}
+
+func queryEventsQuery(
+	ctx context.Context,
+	dialect sqlDialect,
+	config SchemaConfig,
+	query EventQuery,
+	limit int,
+) (string, []any, error) {
+	q := newQueryBuilder()
+
+	q.WriteString(`
+SELECT 
+	id, 
+	stream_id, 
+	stream_version, 
+	stream_type, 
+	event_name, 
+	event_payload, 
+	` + contentTypeColumn(config) + `, 
+	stored_at
+FROM ` + config.tableName() + `
+WHERE id > ` + q.arg(query.Cursor))
+
+	if len(query.EventNames) > 0 {
+		names := make([]any, len(query.EventNames))
+		for i, name := range query.EventNames {
+			names[i] = name
+		}
+
+		q.WriteString(` AND event_name IN ` + q.values(names...))
+	}
+
+	if !query.StoredFrom.IsZero() {
+		q.WriteString(` AND stored_at >= ` + q.arg(dialect.timeArg(query.StoredFrom)))
+	}
+
+	if !query.StoredTo.IsZero() {
+		q.WriteString(` AND stored_at < ` + q.arg(dialect.timeArg(query.StoredTo)))
+	}
+
+	err := q.wherePayload(dialect, query.Payload)
+	if err != nil {
+		return "", nil, err
+	}
+
+	err = q.whereTenant(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	q.WriteString(`
+ORDER BY id ASC
+LIMIT ` + q.arg(limit) + `;
+`)
+
+	return q.String(), q.args, nil
+}
+
+func insertQuery[A any](
+	ctx context.Context,
+	config SchemaConfig,
+	streamType string,
+	events []storageEvent[A],
+) (string, []any, error) {
+	columns := []string{
+		"stream_id",
+		"stream_version",
+		"stream_type",
+		"event_name",
+		"event_payload",
+	}
+
+	if config.ContentType {
+		columns = append(columns, "content_type")
+	} else {
+		for _, e := range events {
+			if e.contentType != "" {
+				return "", nil, errContentTypeDisabled
+			}
+		}
+	}
+
+	if config.HashChain {
+		columns = append(columns, "event_hash")
+	}
+
+	var tenantID string
+	if config.MultiTenant {
+		var err error
+		tenantID, err = requireTenantID(ctx)
+		if err != nil {
+			return "", nil, err
+		}
+
+		columns = append(columns, "tenant_id")
+	}
+
+	// The idempotency key is ignored if the column is disabled.
+	idempotencyKey, hasIdempotencyKey := IdempotencyKeyFromContext(ctx)
+
+	if config.IdempotencyKeys {
+		columns = append(columns, "idempotency_key")
+	}
+
+	q := newQueryBuilder()
+
+	q.WriteString(fmt.Sprintf(`
+INSERT INTO %s (
+	%s
 )
+VALUES `, config.tableName(), strings.Join(columns, ", \n\t")))
+
+	for i, e := range events {
+		values := []any{
+			e.streamID,
+			e.StreamVersion,
+			streamType,
+			e.EventName(),
+			e.payload,
+		}
+
+		if config.ContentType {
+			values = append(values, e.contentType)
+		}
+
+		if config.HashChain {
+			values = append(values, e.hash)
+		}
+
+		if config.MultiTenant {
+			values = append(values, tenantID)
+		}
+
+		if config.IdempotencyKeys {
+			if hasIdempotencyKey {
+				values = append(values, idempotencyKey)
+			} else {
+				values = append(values, nil)
+			}
+		}
+
+		if i > 0 {
+			q.WriteString(",")
+		}
+		q.WriteString(q.values(values...))
+	}
+
+	return q.String(), q.args, nil
+}
+
+func selectPayloadsQuery(
+	ctx context.Context,
+	config SchemaConfig,
+	afterID int64,
+	limit int,
+) (string, []any, error) {
+	q := newQueryBuilder()
+
+	q.WriteString(`
+SELECT 
+	id, 
+	event_payload,
+	` + contentTypeColumn(config) + `
+FROM ` + config.tableName() + `
+WHERE id > ` + q.arg(afterID))
+
+	err := q.whereTenant(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	q.WriteString(`
+ORDER BY id ASC
+LIMIT ` + q.arg(limit) + `;
+`)
+
+	return q.String(), q.args, nil
+}
+
+// selectAllPayloadsQuery works like selectPayloadsQuery, but reads the events of all tenants.
+func selectAllPayloadsQuery(
+	config SchemaConfig,
+	afterID int64,
+	limit int,
+) (string, []any, error) {
+	q := newQueryBuilder()
+
+	q.WriteString(`
+SELECT 
+	id, 
+	event_payload,
+	` + contentTypeColumn(config) + `
+FROM ` + config.tableName() + `
+WHERE id > ` + q.arg(afterID) + `
+ORDER BY id ASC
+LIMIT ` + q.arg(limit) + `;
+`)
+
+	return q.String(), q.args, nil
+}
+
+func updatePayloadQuery(
+	ctx context.Context,
+	config SchemaConfig,
+	id int64,
+	payload []byte,
+) (string, []any, error) {
+	if config.HashChain {
+		return "", nil, errHashChainRewrite
+	}
+
+	q := newQueryBuilder()
+
+	q.WriteString(`
+UPDATE ` + config.tableName() + `
+SET event_payload = ` + q.arg(payload) + `
+WHERE id = ` + q.arg(id))
+
+	err := q.whereTenant(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	q.WriteString(`;
+`)
+
+	return q.String(), q.args, nil
+}
+
+func deleteStreamQuery(
+	ctx context.Context,
+	config SchemaConfig,
+	streamID string,
+) (string, []any, error) {
+	q := newQueryBuilder()
+
+	q.WriteString(`
+DELETE FROM ` + config.tableName() + `
+WHERE stream_id = ` + q.arg(streamID))
+
+	err := q.whereTenant(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	q.WriteString(`;
+`)
+
+	return q.String(), q.args, nil
+}
+
+func selectEventHashQuery(
+	ctx context.Context,
+	config SchemaConfig,
+	streamID string,
+	streamVersion int,
+) (string, []any, error) {
+	if !config.HashChain {
+		return "", nil, errHashChainDisabled
+	}
+
+	q := newQueryBuilder()
+
+	q.WriteString(`
+SELECT 
+	event_hash
+FROM ` + config.tableName() + `
+WHERE stream_id = ` + q.arg(streamID) + ` AND stream_version = ` + q.arg(streamVersion))
+
+	err := q.whereTenant(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	q.WriteString(`;
+`)
+
+	return q.String(), q.args, nil
+}
+
+// selectHashChainQuery reads the events of all tenants, ordered by tenant, stream and version.
+func selectHashChainQuery(
+	config SchemaConfig,
+	afterTenantID string,
+	afterStreamID string,
+	afterVersion int,
+	limit int,
+) (string, []any, error) {
+	if !config.HashChain {
+		return "", nil, errHashChainDisabled
+	}
+
+	q := newQueryBuilder()
+
+	q.WriteString(`
+SELECT 
+	` + tenantIDColumn(config) + `, 
+	stream_id, 
+	stream_version, 
+	event_name, 
+	event_payload, 
+	` + contentTypeColumn(config) + `, 
+	event_hash
+FROM ` + config.tableName())
+
+	// The arguments are added in the order of their placeholders in the query.
+	q.WriteString(`
+WHERE `)
+
+	if config.MultiTenant {
+		q.WriteString(`(tenant_id > ` + q.arg(afterTenantID) + ` OR (tenant_id = ` + q.arg(afterTenantID) + ` AND `)
+	}
+
+	q.WriteString(`(stream_id > ` + q.arg(afterStreamID) + ` OR (stream_id = ` + q.arg(afterStreamID) + ` AND stream_version > ` + q.arg(afterVersion) + `))`)
+
+	if config.MultiTenant {
+		q.WriteString(`))
+ORDER BY tenant_id ASC, stream_id ASC, stream_version ASC`)
+	} else {
+		q.WriteString(`
+ORDER BY stream_id ASC, stream_version ASC`)
+	}
+
+	q.WriteString(`
+LIMIT ` + q.arg(limit) + `;
+`)
+
+	return q.String(), q.args, nil
+}
+
+// tenantIDColumn returns the tenant_id column, or the empty tenant ID if it's not enabled,
+// so the events are scanned the same way.
+func tenantIDColumn(config SchemaConfig) string {
+	if config.MultiTenant {
+		return "tenant_id"
+	}
+	return "'' AS tenant_id"
+}
+
+// eventHashColumn returns the event_hash column, or the empty hash if it's not enabled,
+// so the events are scanned the same way.
+func eventHashColumn(config SchemaConfig) string {
+	if config.HashChain {
+		return "event_hash"
+	}
+	return "'' AS event_hash"
+}
+
+// contentTypeColumn returns the content_type column, or the empty content type if it's not enabled,
+// so the events are scanned the same way.
+func contentTypeColumn(config SchemaConfig) string {
+	if config.ContentType {
+		return "content_type"
+	}
+	return "'' AS content_type"
+}
+
+// queryBuilder builds a query with numbered ($1, $2, ...) placeholders.
+type queryBuilder struct {
+	strings.Builder
+	args []any
+}
+
+func newQueryBuilder() *queryBuilder {
+	return &queryBuilder{}
+}
+
+// arg adds the argument and returns its placeholder.
+func (q *queryBuilder) arg(value any) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// values adds the arguments and returns a parenthesized list of their placeholders.
+func (q *queryBuilder) values(values ...any) string {
+	markers := make([]string, len(values))
+	for i, v := range values {
+		markers[i] = q.arg(v)
+	}
+
+	return "(" + strings.Join(markers, ",") + ")"
+}
+
+// whereTenant adds the tenant condition to the WHERE clause if the schema is multi-tenant.
+func (q *queryBuilder) whereTenant(ctx context.Context, config SchemaConfig) error {
+	if !config.MultiTenant {
+		return nil
+	}
+
+	tenantID, err := requireTenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	q.WriteString(` AND tenant_id = ` + q.arg(tenantID))
+
+	return nil
+}
+
+// wherePayload adds the payload predicates to the WHERE clause.
+func (q *queryBuilder) wherePayload(dialect sqlDialect, predicates []PayloadPredicate) error {
+	if len(predicates) == 0 {
+		return nil
+	}
+
+	if dialect.payloadIndex == "" {
+		return ErrPayloadQueryNotSupported
+	}
+
+	for _, p := range predicates {
+		keys, err := p.keys()
+		if err != nil {
+			return err
+		}
 
-func defaultInsertMarkers(count int) string {
-	result := strings.Builder{}
+		switch p.operator {
+		case payloadEquals:
+			document, err := p.containment(keys)
+			if err != nil {
+				return err
+			}
 
-	var indices []any
-	for i := 1; i <= count*defaultInsertMarkersCount; i++ {
-		indices = append(indices, i)
-		if i%defaultInsertMarkersCount == 0 {
-			result.WriteString(
-				fmt.Sprintf(
-					defaultInsertMarkersPattern,
-					indices...,
-				),
-			)
-			indices = nil
+			q.WriteString(` AND event_payload @> ` + q.arg(string(document)) + `::jsonb`)
+		case payloadExists:
+			q.WriteString(` AND event_payload #> ` + q.arg(textArray(keys)) + `::text[] IS NOT NULL`)
+		case payloadLike:
+			q.WriteString(` AND event_payload #>> ` + q.arg(textArray(keys)) + `::text[] LIKE ` + q.arg(p.value))
+		default:
+			return fmt.Errorf("unknown payload operator %d", p.operator)
 		}
 	}
 
-	return strings.TrimRight(result.String(), ",")
+	return nil
 }
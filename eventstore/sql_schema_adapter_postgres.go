@@ -1,57 +1,113 @@
 package eventstore
 
 import (
-	"fmt"
+	"context"
+	"time"
 )
 
-const postgresInitializeSchemaQuery = `
-CREATE TABLE IF NOT EXISTS %[1]s (
-		id serial NOT NULL PRIMARY KEY,
-		stream_id varchar(255) NOT NULL,
-		stream_version int NOT NULL,
-		stream_type varchar(255) NOT NULL,
-		event_name varchar(255) NOT NULL,
-		event_payload JSONB NOT NULL,
-		stored_at TIMESTAMP NOT NULL DEFAULT NOW()
-);
-CREATE INDEX IF NOT EXISTS idx_stream_id ON %[1]s (stream_id);
-CREATE UNIQUE INDEX IF NOT EXISTS idx_stream_id_version ON %[1]s (stream_id, stream_version);
-`
-
-type PostgresSchemaAdapter[A any] struct{}
+// postgresDialect keeps stored_at with the time zone, so it doesn't depend on the session's time zone.
+// Tables created before have a timestamp without time zone, set in the session's time zone with NOW().
+// They can be migrated with the time zone the events were saved in:
+//
+//	ALTER TABLE events ALTER COLUMN stored_at TYPE TIMESTAMPTZ USING stored_at AT TIME ZONE 'UTC';
+var postgresDialect = sqlDialect{
+	idColumn:       "id serial NOT NULL PRIMARY KEY",
+	textType:       "varchar(255)",
+	intType:        "int",
+	payloadType:    "JSONB",
+	storedAtColumn: "stored_at TIMESTAMPTZ NOT NULL DEFAULT NOW()",
+
+	// UTC is compared correctly with both stored_at types, as long as the old tables were written in UTC.
+	timeArg: func(t time.Time) any {
+		return t.UTC()
+	},
+	payloadIndex: "USING GIN (event_payload jsonb_path_ops)",
+}
+
+type PostgresSchemaAdapter[A any] struct {
+	config SchemaConfig
+}
 
 func NewPostgresSchemaAdapter[A any]() PostgresSchemaAdapter[A] {
 	return PostgresSchemaAdapter[A]{}
 }
 
+func NewPostgresSchemaAdapterWithConfig[A any](config SchemaConfig) PostgresSchemaAdapter[A] {
+	return PostgresSchemaAdapter[A]{
+		config: config,
+	}
+}
+
 func (a PostgresSchemaAdapter[A]) InitializeSchemaQuery() string {
-	return fmt.Sprintf(postgresInitializeSchemaQuery, defaultEventsTableName)
+	return initializeSchemaQuery(a.dialect(), a.config)
+}
+
+func (a PostgresSchemaAdapter[A]) SelectQuery(ctx context.Context, streamID string, afterVersion int) (string, []any, error) {
+	return selectQuery(ctx, a.config, streamID, afterVersion)
 }
 
-func (a PostgresSchemaAdapter[A]) SelectQuery(streamID string) (string, []any, error) {
-	query := fmt.Sprintf(defaultSelectQuery, defaultEventsTableName)
+func (a PostgresSchemaAdapter[A]) SelectByIdempotencyKeyQuery(ctx context.Context, streamID string, idempotencyKey string) (string, []any, error) {
+	return selectByIdempotencyKeyQuery(ctx, a.config, streamID, idempotencyKey)
+}
 
-	args := []any{
-		streamID,
-	}
+func (a PostgresSchemaAdapter[A]) ListStreamsQuery(ctx context.Context, query StreamQuery) (string, []any, error) {
+	return listStreamsQuery(ctx, a.dialect(), a.config, query)
+}
 
-	return query, args, nil
+func (a PostgresSchemaAdapter[A]) ReadByStreamTypeQuery(ctx context.Context, streamType string, fromPosition int64, limit int) (string, []any, error) {
+	return readByStreamTypeQuery(ctx, a.config, streamType, fromPosition, limit)
 }
 
-func (a PostgresSchemaAdapter[A]) InsertQuery(streamType string, events []storageEvent[A]) (string, []any, error) {
-	query := fmt.Sprintf(defaultInsertQuery, defaultEventsTableName, defaultInsertMarkers(len(events)))
+func (a PostgresSchemaAdapter[A]) QueryEventsQuery(ctx context.Context, query EventQuery, limit int) (string, []any, error) {
+	return queryEventsQuery(ctx, a.dialect(), a.config, query, limit)
+}
+
+func (a PostgresSchemaAdapter[A]) InsertQuery(ctx context.Context, streamType string, events []storageEvent[A]) (string, []any, error) {
+	return insertQuery(ctx, a.config, streamType, events)
+}
 
-	var args []any
-	for _, e := range events {
-		args = append(
-			args,
-			e.streamID,
-			e.StreamVersion,
-			streamType,
-			e.EventName(),
-			e.payload,
-		)
+// dialect returns the Postgres dialect with the payload column of the configured type.
+func (a PostgresSchemaAdapter[A]) dialect() sqlDialect {
+	if !a.config.BinaryPayload {
+		return postgresDialect
 	}
 
-	return query, args, nil
+	dialect := postgresDialect
+	dialect.payloadType = "BYTEA"
+	dialect.payloadIndex = ""
+	dialect.exactPayload = true
+
+	return dialect
+}
+
+func (a PostgresSchemaAdapter[A]) SelectPayloadsQuery(ctx context.Context, afterID int64, limit int) (string, []any, error) {
+	return selectPayloadsQuery(ctx, a.config, afterID, limit)
+}
+
+func (a PostgresSchemaAdapter[A]) UpdatePayloadQuery(ctx context.Context, id int64, payload []byte) (string, []any, error) {
+	return updatePayloadQuery(ctx, a.config, id, payload)
+}
+
+func (a PostgresSchemaAdapter[A]) SelectAllPayloadsQuery(ctx context.Context, afterID int64, limit int) (string, []any, error) {
+	return selectAllPayloadsQuery(a.config, afterID, limit)
+}
+
+func (a PostgresSchemaAdapter[A]) DeleteStreamQuery(ctx context.Context, streamID string) (string, []any, error) {
+	return deleteStreamQuery(ctx, a.config, streamID)
+}
+
+func (a PostgresSchemaAdapter[A]) SelectEventHashQuery(ctx context.Context, streamID string, streamVersion int) (string, []any, error) {
+	return selectEventHashQuery(ctx, a.config, streamID, streamVersion)
+}
+
+func (a PostgresSchemaAdapter[A]) SelectHashChainQuery(ctx context.Context, afterTenantID string, afterStreamID string, afterVersion int, limit int) (string, []any, error) {
+	return selectHashChainQuery(a.config, afterTenantID, afterStreamID, afterVersion, limit)
+}
+
+func (a PostgresSchemaAdapter[A]) schemaConfig() SchemaConfig {
+	return a.config
+}
+
+func (a PostgresSchemaAdapter[A]) validate() error {
+	return validateSchema(a.dialect(), a.config)
 }
@@ -1,57 +1,98 @@
 package eventstore
 
 import (
-	"fmt"
+	"context"
+	"time"
 )
 
-const sqliteInitializeSchemaQuery = `
-CREATE TABLE IF NOT EXISTS %[1]s (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    stream_id TEXT NOT NULL,
-    stream_version INTEGER NOT NULL,
-    stream_type TEXT NOT NULL,
-    event_name TEXT NOT NULL,
-    event_payload BLOB NOT NULL,
-    stored_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
-);
-CREATE INDEX IF NOT EXISTS idx_stream_id ON %[1]s (stream_id);
-CREATE UNIQUE INDEX IF NOT EXISTS idx_stream_id_version ON %[1]s (stream_id, stream_version);
-`
-
-type SQLiteSchemaAdapter[A any] struct{}
+var sqliteDialect = sqlDialect{
+	idColumn:     "id INTEGER PRIMARY KEY AUTOINCREMENT",
+	textType:     "TEXT",
+	intType:      "INTEGER",
+	payloadType:  "BLOB",
+	exactPayload: true,
+
+	// Percent signs are escaped, as the schema query is a format string.
+	storedAtColumn: "stored_at DATETIME NOT NULL DEFAULT (strftime('%%Y-%%m-%%d %%H:%%M:%%f', 'now'))",
+
+	// stored_at is a text with milliseconds, so it's compared with a text in the same format.
+	// Trailing zeros are trimmed, so the texts of equal times compare the same way as the times,
+	// including the texts without milliseconds stored in the tables created with CURRENT_TIMESTAMP.
+	timeArg: func(t time.Time) any {
+		return t.UTC().Format("2006-01-02 15:04:05.999")
+	},
+}
+
+type SQLiteSchemaAdapter[A any] struct {
+	config SchemaConfig
+}
 
 func NewSQLiteSchemaAdapter[A any]() SQLiteSchemaAdapter[A] {
 	return SQLiteSchemaAdapter[A]{}
 }
 
+func NewSQLiteSchemaAdapterWithConfig[A any](config SchemaConfig) SQLiteSchemaAdapter[A] {
+	return SQLiteSchemaAdapter[A]{
+		config: config,
+	}
+}
+
 func (a SQLiteSchemaAdapter[A]) InitializeSchemaQuery() string {
-	return fmt.Sprintf(sqliteInitializeSchemaQuery, defaultEventsTableName)
+	return initializeSchemaQuery(sqliteDialect, a.config)
 }
 
-func (a SQLiteSchemaAdapter[A]) SelectQuery(streamID string) (string, []any, error) {
-	query := fmt.Sprintf(defaultSelectQuery, defaultEventsTableName)
+func (a SQLiteSchemaAdapter[A]) SelectQuery(ctx context.Context, streamID string, afterVersion int) (string, []any, error) {
+	return selectQuery(ctx, a.config, streamID, afterVersion)
+}
 
-	args := []any{
-		streamID,
-	}
+func (a SQLiteSchemaAdapter[A]) SelectByIdempotencyKeyQuery(ctx context.Context, streamID string, idempotencyKey string) (string, []any, error) {
+	return selectByIdempotencyKeyQuery(ctx, a.config, streamID, idempotencyKey)
+}
 
-	return query, args, nil
+func (a SQLiteSchemaAdapter[A]) ListStreamsQuery(ctx context.Context, query StreamQuery) (string, []any, error) {
+	return listStreamsQuery(ctx, sqliteDialect, a.config, query)
 }
 
-func (a SQLiteSchemaAdapter[A]) InsertQuery(streamType string, events []storageEvent[A]) (string, []any, error) {
-	query := fmt.Sprintf(defaultInsertQuery, defaultEventsTableName, defaultInsertMarkers(len(events)))
+func (a SQLiteSchemaAdapter[A]) ReadByStreamTypeQuery(ctx context.Context, streamType string, fromPosition int64, limit int) (string, []any, error) {
+	return readByStreamTypeQuery(ctx, a.config, streamType, fromPosition, limit)
+}
 
-	var args []any
-	for _, e := range events {
-		args = append(
-			args,
-			e.streamID,
-			e.StreamVersion,
-			streamType,
-			e.EventName(),
-			e.payload,
-		)
-	}
+func (a SQLiteSchemaAdapter[A]) QueryEventsQuery(ctx context.Context, query EventQuery, limit int) (string, []any, error) {
+	return queryEventsQuery(ctx, sqliteDialect, a.config, query, limit)
+}
+
+func (a SQLiteSchemaAdapter[A]) InsertQuery(ctx context.Context, streamType string, events []storageEvent[A]) (string, []any, error) {
+	return insertQuery(ctx, a.config, streamType, events)
+}
+
+func (a SQLiteSchemaAdapter[A]) SelectPayloadsQuery(ctx context.Context, afterID int64, limit int) (string, []any, error) {
+	return selectPayloadsQuery(ctx, a.config, afterID, limit)
+}
+
+func (a SQLiteSchemaAdapter[A]) UpdatePayloadQuery(ctx context.Context, id int64, payload []byte) (string, []any, error) {
+	return updatePayloadQuery(ctx, a.config, id, payload)
+}
+
+func (a SQLiteSchemaAdapter[A]) SelectAllPayloadsQuery(ctx context.Context, afterID int64, limit int) (string, []any, error) {
+	return selectAllPayloadsQuery(a.config, afterID, limit)
+}
+
+func (a SQLiteSchemaAdapter[A]) DeleteStreamQuery(ctx context.Context, streamID string) (string, []any, error) {
+	return deleteStreamQuery(ctx, a.config, streamID)
+}
+
+func (a SQLiteSchemaAdapter[A]) SelectEventHashQuery(ctx context.Context, streamID string, streamVersion int) (string, []any, error) {
+	return selectEventHashQuery(ctx, a.config, streamID, streamVersion)
+}
+
+func (a SQLiteSchemaAdapter[A]) SelectHashChainQuery(ctx context.Context, afterTenantID string, afterStreamID string, afterVersion int, limit int) (string, []any, error) {
+	return selectHashChainQuery(a.config, afterTenantID, afterStreamID, afterVersion, limit)
+}
+
+func (a SQLiteSchemaAdapter[A]) schemaConfig() SchemaConfig {
+	return a.config
+}
 
-	return query, args, nil
+func (a SQLiteSchemaAdapter[A]) validate() error {
+	return validateSchema(sqliteDialect, a.config)
 }
@@ -0,0 +1,139 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/esja"
+)
+
+// readBatchSize is the maximum number of events returned by a single ReadByStreamType call.
+const readBatchSize = 1000
+
+const defaultSubscriptionPollInterval = time.Second
+
+// RecordedEvent is an event stored in the event store with its global position.
+type RecordedEvent[T any] struct {
+	esja.VersionedEvent[T]
+
+	// Position is the position of the event among all events in the store.
+	// Positions are increasing, but not necessarily consecutive.
+	// In Postgres, events may become visible out of the order of positions; see SubscriptionConfig.VisibilityLag.
+	Position int64
+
+	StreamID   string
+	StreamType string
+	StoredAt   time.Time
+}
+
+// StreamTypeReader reads events of all streams of a single type (a category of streams).
+type StreamTypeReader[T any] interface {
+	// ReadByStreamType returns events of streams of the type with positions greater than fromPosition,
+	// in the order of positions. It returns at most 1000 events, so it should be called again
+	// with the position of the last returned event until it returns no events.
+	ReadByStreamType(ctx context.Context, streamType string, fromPosition int64) ([]RecordedEvent[T], error)
+}
+
+// SubscriptionConfig configures SubscribeToStreamType.
+type SubscriptionConfig struct {
+	// PollInterval is the time between reads when there are no new events. Defaults to 1s.
+	PollInterval time.Duration
+
+	// VisibilityLag is the minimum age of the events passed to the handler.
+	// Younger events are read again after PollInterval.
+	//
+	// In Postgres, positions are assigned before transactions commit, so an event can become visible
+	// after events with greater positions, and be skipped by the subscription.
+	// The lag lets such events commit before the subscription moves past their positions,
+	// as long as their transactions are shorter than the lag.
+	// Zero disables the lag, which is safe if events are saved one at a time, like with SQLite.
+	//
+	// The lag is computed from RecordedEvent.StoredAt. In Postgres tables created with stored_at
+	// without time zone, it's correct only if the events were saved in a session with the UTC time zone.
+	VisibilityLag time.Duration
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+func (c *SubscriptionConfig) setDefaults() {
+	if c.PollInterval == 0 {
+		c.PollInterval = defaultSubscriptionPollInterval
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+}
+
+func (c SubscriptionConfig) validate() error {
+	if c.PollInterval < 0 {
+		return fmt.Errorf("poll interval must not be negative")
+	}
+	if c.VisibilityLag < 0 {
+		return fmt.Errorf("visibility lag must not be negative")
+	}
+	return nil
+}
+
+// RecordedEventHandler handles events passed by SubscribeToStreamType.
+type RecordedEventHandler[T any] func(ctx context.Context, event RecordedEvent[T]) error
+
+// SubscribeToStreamType passes events of streams of the type with positions greater than fromPosition
+// to the handler, in the order of positions. It polls the reader for new events
+// until the context is canceled or the handler returns an error.
+//
+// The handler should store the position of the last handled event,
+// so the subscription can be resumed from it.
+//
+// In Postgres, events saved concurrently may become visible out of the order of positions
+// and be skipped by the subscription. Set SubscriptionConfig.VisibilityLag to avoid it.
+func SubscribeToStreamType[T any](
+	ctx context.Context,
+	reader StreamTypeReader[T],
+	streamType string,
+	fromPosition int64,
+	handler RecordedEventHandler[T],
+	config SubscriptionConfig,
+) error {
+	config.setDefaults()
+	err := config.validate()
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	position := fromPosition
+
+	for {
+		events, err := reader.ReadByStreamType(ctx, streamType, position)
+		if err != nil {
+			return fmt.Errorf("error reading events: %w", err)
+		}
+
+		handled := 0
+		for _, event := range events {
+			if config.Now().Sub(event.StoredAt) < config.VisibilityLag {
+				// The position can't move past events younger than the lag.
+				break
+			}
+
+			err = handler(ctx, event)
+			if err != nil {
+				return fmt.Errorf("error handling event %s at position %d: %w", event.EventName(), event.Position, err)
+			}
+
+			position = event.Position
+			handled++
+		}
+
+		if handled > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(config.PollInterval):
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package eventstore_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja/eventstore"
+	"github.com/ThreeDotsLabs/esja/eventstore/eventstoretest"
+)
+
+func TestSubscribeToStreamType(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	store := eventstore.NewInMemoryStore[eventstoretest.Entity]()
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	received := make(chan eventstore.RecordedEvent[eventstoretest.Entity])
+	done := make(chan error)
+
+	go func() {
+		done <- eventstore.SubscribeToStreamType[eventstoretest.Entity](
+			ctx,
+			store,
+			"Entity",
+			0,
+			func(ctx context.Context, event eventstore.RecordedEvent[eventstoretest.Entity]) error {
+				received <- event
+				return nil
+			},
+			eventstore.SubscriptionConfig{PollInterval: time.Millisecond},
+		)
+	}()
+
+	event := <-received
+	assert.Equal(t, int64(1), event.Position)
+	assert.Equal(t, eventstoretest.Created{}.EventName(), event.EventName())
+
+	// Events saved after subscribing are received as well.
+	err = entity.Update("value")
+	require.NoError(t, err)
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	event = <-received
+	assert.Equal(t, int64(2), event.Position)
+	assert.Equal(t, eventstoretest.Updated{Value: "value"}, event.Event)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestSubscribeToStreamType_handler_error(t *testing.T) {
+	ctx := context.Background()
+
+	store := eventstore.NewInMemoryStore[eventstoretest.Entity]()
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	handlerErr := errors.New("handler error")
+
+	err = eventstore.SubscribeToStreamType[eventstoretest.Entity](
+		ctx,
+		store,
+		"Entity",
+		0,
+		func(ctx context.Context, event eventstore.RecordedEvent[eventstoretest.Entity]) error {
+			return handlerErr
+		},
+		eventstore.SubscriptionConfig{},
+	)
+	assert.ErrorIs(t, err, handlerErr)
+}
+
+func TestSubscribeToStreamType_visibility_lag(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	store := eventstore.NewInMemoryStore[eventstoretest.Entity]()
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	var lock sync.Mutex
+	now := time.Now()
+
+	received := make(chan eventstore.RecordedEvent[eventstoretest.Entity])
+
+	go func() {
+		_ = eventstore.SubscribeToStreamType[eventstoretest.Entity](
+			ctx,
+			store,
+			"Entity",
+			0,
+			func(ctx context.Context, event eventstore.RecordedEvent[eventstoretest.Entity]) error {
+				received <- event
+				return nil
+			},
+			eventstore.SubscriptionConfig{
+				PollInterval:  time.Millisecond,
+				VisibilityLag: time.Minute,
+				Now: func() time.Time {
+					lock.Lock()
+					defer lock.Unlock()
+					return now
+				},
+			},
+		)
+	}()
+
+	select {
+	case <-received:
+		t.Fatal("event younger than the visibility lag should not be received")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	lock.Lock()
+	now = now.Add(time.Minute)
+	lock.Unlock()
+
+	event := <-received
+	assert.Equal(t, int64(1), event.Position)
+}
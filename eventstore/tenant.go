@@ -0,0 +1,36 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTenantIDRequired is returned by multi-tenant stores if there's no tenant ID in the context.
+var ErrTenantIDRequired = errors.New("tenant ID missing in context")
+
+type tenantIDContextKey struct{}
+
+// WithTenantID returns a context carrying the tenant ID.
+// Multi-tenant stores save and load streams of the tenant from the context.
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantIDContextKey{}, tenantID)
+}
+
+// TenantIDFromContext returns the tenant ID set with WithTenantID.
+func TenantIDFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantIDContextKey{}).(string)
+	if !ok || tenantID == "" {
+		return "", false
+	}
+
+	return tenantID, true
+}
+
+func requireTenantID(ctx context.Context) (string, error) {
+	tenantID, ok := TenantIDFromContext(ctx)
+	if !ok {
+		return "", ErrTenantIDRequired
+	}
+
+	return tenantID, nil
+}
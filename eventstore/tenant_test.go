@@ -0,0 +1,73 @@
+package eventstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja/eventstore"
+	"github.com/ThreeDotsLabs/esja/eventstore/eventstoretest"
+)
+
+func TestInMemoryStore_multi_tenant(t *testing.T) {
+	config := eventstore.NewInMemoryConfig(eventstoretest.SupportedEvents())
+	config.MultiTenant = true
+
+	store, err := eventstore.NewInMemoryStoreWithConfig(config)
+	require.NoError(t, err)
+
+	eventstoretest.TestMultiTenancy(t, store)
+}
+
+func TestCachingStore_multi_tenant(t *testing.T) {
+	config := eventstore.NewInMemoryConfig(eventstoretest.SupportedEvents())
+	config.MultiTenant = true
+
+	inner, err := eventstore.NewInMemoryStoreWithConfig(config)
+	require.NoError(t, err)
+
+	store, err := eventstore.NewCachingStore[eventstoretest.Entity](inner, eventstore.CacheConfig{})
+	require.NoError(t, err)
+
+	eventstoretest.TestMultiTenancy(t, store)
+}
+
+func TestInMemoryStore_multi_tenant_conformance(t *testing.T) {
+	eventstoretest.TestEventStore(t, func(t *testing.T) eventstore.EventStore[eventstoretest.Entity] {
+		config := eventstore.NewInMemoryConfig(eventstoretest.SupportedEvents())
+		config.MultiTenant = true
+
+		store, err := eventstore.NewInMemoryStoreWithConfig(config)
+		require.NoError(t, err)
+
+		return tenantStore{store: store, tenantID: "tenant"}
+	})
+}
+
+func TestTenantIDFromContext(t *testing.T) {
+	_, ok := eventstore.TenantIDFromContext(context.Background())
+	assert.False(t, ok)
+
+	_, ok = eventstore.TenantIDFromContext(eventstore.WithTenantID(context.Background(), ""))
+	assert.False(t, ok)
+
+	tenantID, ok := eventstore.TenantIDFromContext(eventstore.WithTenantID(context.Background(), "tenant"))
+	assert.True(t, ok)
+	assert.Equal(t, "tenant", tenantID)
+}
+
+// tenantStore sets the tenant ID in the context of all calls.
+type tenantStore struct {
+	store    eventstore.EventStore[eventstoretest.Entity]
+	tenantID string
+}
+
+func (s tenantStore) Load(ctx context.Context, id string) (*eventstoretest.Entity, error) {
+	return s.store.Load(eventstore.WithTenantID(ctx, s.tenantID), id)
+}
+
+func (s tenantStore) Save(ctx context.Context, entity *eventstoretest.Entity) error {
+	return s.store.Save(eventstore.WithTenantID(ctx, s.tenantID), entity)
+}
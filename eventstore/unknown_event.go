@@ -0,0 +1,143 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/esja"
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+// UnknownEventPolicy defines how stores load events that can't be decoded:
+// events of names the mapper doesn't support, like events no longer registered in it,
+// and events which payloads the marshaler or the mapper fail to decode.
+// Loading canceled with the context always fails.
+type UnknownEventPolicy int
+
+const (
+	// UnknownEventFail fails loading the stream.
+	UnknownEventFail UnknownEventPolicy = iota
+	// UnknownEventSkip leaves the event out of the loaded stream.
+	// The loaded entity keeps the version of the last stored event, so it can be saved.
+	// If all events of the stream are skipped, the entity is not found.
+	//
+	// Reading events across streams (ReadByStreamType and QueryEvents) returns UnknownEvent instead,
+	// as the skipped events would break the positions.
+	UnknownEventSkip
+	// UnknownEventPlaceholder loads UnknownEvent in place of the event.
+	UnknownEventPlaceholder
+)
+
+func (p UnknownEventPolicy) validate() error {
+	if p != UnknownEventFail && p != UnknownEventSkip && p != UnknownEventPlaceholder {
+		return fmt.Errorf("unknown event policy %d", p)
+	}
+	return nil
+}
+
+// UnknownEvent is loaded in place of the event that can't be decoded with UnknownEventPlaceholder.
+// It keeps the raw name and payload of the event, and applying it doesn't change the entity.
+type UnknownEvent[T any] struct {
+	Name    string
+	Payload []byte
+}
+
+func (e UnknownEvent[T]) EventName() string {
+	return e.Name
+}
+
+func (e UnknownEvent[T]) ApplyTo(*T) error {
+	return nil
+}
+
+// skippedEvent keeps the version of the events left out with UnknownEventSkip
+// when the entity is built. It's never returned to the callers.
+type skippedEvent[T any] struct{}
+
+func (skippedEvent[T]) EventName() string {
+	return ""
+}
+
+func (skippedEvent[T]) ApplyTo(*T) error {
+	return nil
+}
+
+// UndecodedEvent is the event skipped or replaced with UnknownEvent.
+type UndecodedEvent struct {
+	StreamID      string
+	StreamVersion int
+	EventName     string
+	Payload       []byte
+
+	// Err is the error returned by the mapper for the unsupported event name,
+	// or by the marshaler or the mapper decoding the payload.
+	Err error
+}
+
+// UnknownEventHandler reports the events skipped or replaced with UnknownEvent.
+// Returning an error fails loading the events.
+type UnknownEventHandler func(ctx context.Context, event UndecodedEvent) error
+
+// eventDecoder decodes the loaded events, applying the unknown event policy.
+type eventDecoder[T any] struct {
+	mapper         transport.Mapper[T]
+	marshaler      transport.Marshaler
+	policy         UnknownEventPolicy
+	onUnknownEvent UnknownEventHandler
+}
+
+// decode returns the decoded event, or false if the event should be skipped.
+func (d eventDecoder[T]) decode(
+	ctx context.Context,
+	streamID string,
+	streamVersion int,
+	eventName string,
+	payload []byte,
+) (esja.Event[T], bool, error) {
+	event, err := unmarshalEvent(ctx, d.mapper, d.marshaler, streamID, eventName, payload)
+	if err == nil {
+		return event, true, nil
+	}
+	if d.policy == UnknownEventFail || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil, false, err
+	}
+
+	if d.onUnknownEvent != nil {
+		reportErr := d.onUnknownEvent(ctx, UndecodedEvent{
+			StreamID:      streamID,
+			StreamVersion: streamVersion,
+			EventName:     eventName,
+			Payload:       payload,
+			Err:           err,
+		})
+		if reportErr != nil {
+			return nil, false, fmt.Errorf("error reporting unknown event %s: %w", eventName, reportErr)
+		}
+	}
+
+	if d.policy == UnknownEventSkip {
+		return nil, false, nil
+	}
+
+	return UnknownEvent[T]{
+		Name:    eventName,
+		Payload: payload,
+	}, true, nil
+}
+
+// decodeRecorded works like decode, but returns UnknownEvent instead of skipping the event.
+func (d eventDecoder[T]) decodeRecorded(
+	ctx context.Context,
+	streamID string,
+	streamVersion int,
+	eventName string,
+	payload []byte,
+) (esja.Event[T], error) {
+	if d.policy == UnknownEventSkip {
+		d.policy = UnknownEventPlaceholder
+	}
+
+	event, _, err := d.decode(ctx, streamID, streamVersion, eventName, payload)
+	return event, err
+}
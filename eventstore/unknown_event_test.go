@@ -0,0 +1,240 @@
+package eventstore_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja"
+	"github.com/ThreeDotsLabs/esja/eventstore"
+	"github.com/ThreeDotsLabs/esja/eventstore/eventstoretest"
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+func TestUnknownEventPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name   string
+		policy eventstore.UnknownEventPolicy
+		assert func(t *testing.T, store *eventstore.FileStore[eventstoretest.Entity], id string, reported *[]eventstore.UndecodedEvent)
+	}{
+		{
+			name:   "fail",
+			policy: eventstore.UnknownEventFail,
+			assert: func(t *testing.T, store *eventstore.FileStore[eventstoretest.Entity], id string, reported *[]eventstore.UndecodedEvent) {
+				_, err := store.Load(ctx, id)
+				assert.ErrorContains(t, err, "error creating new event instance")
+				assert.Empty(t, *reported)
+			},
+		},
+		{
+			name:   "skip",
+			policy: eventstore.UnknownEventSkip,
+			assert: func(t *testing.T, store *eventstore.FileStore[eventstoretest.Entity], id string, reported *[]eventstore.UndecodedEvent) {
+				loaded, err := store.Load(ctx, id)
+				require.NoError(t, err)
+
+				assert.Equal(t, id, loaded.ID())
+				assert.Empty(t, loaded.Value())
+				assert.Equal(t, 3, loaded.Stream().Version(), "should keep the version of the skipped events")
+
+				require.Len(t, *reported, 2)
+				for i, r := range *reported {
+					assert.Equal(t, id, r.StreamID)
+					assert.Equal(t, i+2, r.StreamVersion)
+					assert.Equal(t, "Updated_v1", r.EventName)
+					assert.NotEmpty(t, r.Payload)
+					assert.ErrorContains(t, r.Err, "error creating new event instance")
+				}
+
+				events, err := store.LoadEvents(ctx, id, 1)
+				require.NoError(t, err)
+				assert.Empty(t, events.Events)
+				assert.Equal(t, 3, events.Version)
+
+				cache, err := eventstore.NewCachingStore[eventstoretest.Entity](store, eventstore.CacheConfig{})
+				require.NoError(t, err)
+				for i := 0; i < 2; i++ {
+					cached, err := cache.Load(ctx, id)
+					require.NoError(t, err)
+					assert.Equal(t, 3, cached.Stream().Version(), "cached entity should keep the version of the skipped events")
+				}
+
+				err = loaded.Update("third")
+				require.NoError(t, err)
+
+				err = store.Save(ctx, loaded)
+				require.NoError(t, err, "should save after the skipped events")
+			},
+		},
+		{
+			name:   "placeholder",
+			policy: eventstore.UnknownEventPlaceholder,
+			assert: func(t *testing.T, store *eventstore.FileStore[eventstoretest.Entity], id string, reported *[]eventstore.UndecodedEvent) {
+				loaded, err := store.Load(ctx, id)
+				require.NoError(t, err)
+
+				assert.Empty(t, loaded.Value())
+				assert.Equal(t, 3, loaded.Stream().Version())
+				assert.Len(t, *reported, 2)
+
+				events, err := store.LoadEvents(ctx, id, 1)
+				require.NoError(t, err)
+				require.Len(t, events.Events, 2)
+
+				unknown, ok := events.Events[0].Event.(eventstore.UnknownEvent[eventstoretest.Entity])
+				require.True(t, ok, "expected UnknownEvent, got %T", events.Events[0].Event)
+				assert.Equal(t, "Updated_v1", unknown.EventName())
+				assert.JSONEq(t, `{"Value":"first"}`, string(unknown.Payload))
+
+				err = loaded.Update("third")
+				require.NoError(t, err)
+
+				err = store.Save(ctx, loaded)
+				require.NoError(t, err, "should save after the placeholders keeping the version")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			id := saveUpdatedEntity(t, dir)
+
+			var reported []eventstore.UndecodedEvent
+
+			config := eventstore.NewFileConfig([]esja.Event[eventstoretest.Entity]{
+				eventstoretest.Created{},
+			})
+			config.UnknownEventPolicy = tc.policy
+			config.OnUnknownEvent = func(_ context.Context, event eventstore.UndecodedEvent) error {
+				reported = append(reported, event)
+				return nil
+			}
+
+			tc.assert(t, newFileStore(t, dir, config), id, &reported)
+		})
+	}
+}
+
+func TestUnknownEventPolicy_reporting_error(t *testing.T) {
+	dir := t.TempDir()
+	id := saveUpdatedEntity(t, dir)
+
+	config := eventstore.NewFileConfig([]esja.Event[eventstoretest.Entity]{
+		eventstoretest.Created{},
+	})
+	config.UnknownEventPolicy = eventstore.UnknownEventSkip
+	config.OnUnknownEvent = func(context.Context, eventstore.UndecodedEvent) error {
+		return errors.New("retired event")
+	}
+
+	_, err := newFileStore(t, dir, config).Load(context.Background(), id)
+	assert.ErrorContains(t, err, "error reporting unknown event Updated_v1: retired event")
+}
+
+func TestUnknownEventPolicy_undecodable_events(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	id := saveUpdatedEntity(t, dir)
+
+	var reported []eventstore.UndecodedEvent
+
+	config := eventstore.NewFileConfig(eventstoretest.SupportedEvents())
+	config.Marshaler = failingUnmarshaler{
+		failing: "Updated",
+		err:     errors.New("corrupted payload"),
+	}
+	config.UnknownEventPolicy = eventstore.UnknownEventSkip
+	config.OnUnknownEvent = func(_ context.Context, event eventstore.UndecodedEvent) error {
+		reported = append(reported, event)
+		return nil
+	}
+	store := newFileStore(t, dir, config)
+
+	loaded, err := store.Load(ctx, id)
+	require.NoError(t, err)
+	assert.Empty(t, loaded.Value())
+	assert.Equal(t, 3, loaded.Stream().Version(), "should keep the version of the skipped events")
+
+	require.Len(t, reported, 2)
+	assert.Equal(t, "Updated_v1", reported[0].EventName)
+	assert.ErrorContains(t, reported[0].Err, "corrupted payload")
+
+	err = loaded.Update("third")
+	require.NoError(t, err)
+	err = store.Save(ctx, loaded)
+	require.NoError(t, err, "should save after the skipped events")
+}
+
+func TestUnknownEventPolicy_canceled_context(t *testing.T) {
+	dir := t.TempDir()
+	id := saveUpdatedEntity(t, dir)
+
+	config := eventstore.NewFileConfig(eventstoretest.SupportedEvents())
+	config.Marshaler = failingUnmarshaler{
+		failing: "Updated",
+		err:     context.Canceled,
+	}
+	config.UnknownEventPolicy = eventstore.UnknownEventSkip
+	config.OnUnknownEvent = func(context.Context, eventstore.UndecodedEvent) error {
+		t.Error("events of the canceled load should not be reported")
+		return nil
+	}
+
+	_, err := newFileStore(t, dir, config).Load(context.Background(), id)
+	assert.ErrorIs(t, err, context.Canceled, "canceled loading should not skip events")
+}
+
+// failingUnmarshaler fails to unmarshal the events of the failing type.
+type failingUnmarshaler struct {
+	transport.JSONMarshaler
+	failing string
+	err     error
+}
+
+func (m failingUnmarshaler) Unmarshal(data []byte, target any) error {
+	if strings.HasSuffix(fmt.Sprintf("%T", target), "."+m.failing) {
+		return m.err
+	}
+	return m.JSONMarshaler.Unmarshal(data, target)
+}
+
+func TestUnknownEventPolicy_invalid(t *testing.T) {
+	config := eventstore.NewFileConfig(eventstoretest.SupportedEvents())
+	config.UnknownEventPolicy = 5
+
+	_, err := eventstore.NewFileStore(t.TempDir(), config)
+	assert.EqualError(t, err, "invalid config: unknown event policy 5")
+}
+
+// saveUpdatedEntity saves the entity updated twice with all events supported
+// and returns its ID.
+func saveUpdatedEntity(t *testing.T, dir string) string {
+	t.Helper()
+
+	store := newFileStore(t, dir, eventstore.NewFileConfig(eventstoretest.SupportedEvents()))
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+
+	err = entity.Update("first")
+	require.NoError(t, err)
+	err = entity.Update("second")
+	require.NoError(t, err)
+
+	err = store.Save(context.Background(), entity)
+	require.NoError(t, err)
+
+	err = store.Close()
+	require.NoError(t, err)
+
+	return entity.ID()
+}
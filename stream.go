@@ -44,6 +44,12 @@ func (s *Stream[T]) Type() string {
 	return s.streamType
 }
 
+// Version returns the version of the last event recorded in the stream
+// or loaded into it.
+func (s *Stream[T]) Version() int {
+	return s.version
+}
+
 // Record applies the provided Event to the entity
 // and puts it into the stream's event queue as a next VersionedEvent.
 func (s *Stream[T]) Record(entity *T, event Event[T]) error {
@@ -80,4 +80,5 @@ func TestNewStream(t *testing.T) {
 
 	assert.Equal(t, event3, events[0].Event)
 	assert.Equal(t, 3, events[0].StreamVersion)
+	assert.Equal(t, 3, stm.Version())
 }
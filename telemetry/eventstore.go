@@ -0,0 +1,138 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ThreeDotsLabs/esja"
+	"github.com/ThreeDotsLabs/esja/eventstore"
+)
+
+// EventStore is a wrapper to any eventstore.EventStore instance.
+// EventStore traces Load and Save calls and records their latency,
+// the number of events in streams and errors.
+// Use NewStageHook to trace the stages of the wrapped store's calls.
+type EventStore[T esja.Entity[T]] struct {
+	store  eventstore.EventStore[T]
+	tracer Tracer
+	meter  Meter
+}
+
+// NewEventStore returns a new instance of EventStore.
+// Nil tracer or meter disable tracing or metrics respectively.
+func NewEventStore[T esja.Entity[T]](
+	store eventstore.EventStore[T],
+	tracer Tracer,
+	meter Meter,
+) *EventStore[T] {
+	return &EventStore[T]{
+		store:  store,
+		tracer: tracerOrNoop(tracer),
+		meter:  meterOrNoop(meter),
+	}
+}
+
+func (s *EventStore[T]) Load(ctx context.Context, id string) (*T, error) {
+	ctx, span := s.tracer.Start(ctx, SpanLoad, Attr(AttributeStreamID, id))
+	defer span.End()
+
+	start := time.Now()
+	entity, err := s.store.Load(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	if err != nil {
+		s.meter.RecordHistogram(ctx, MetricLoadDuration, duration)
+		s.recordError(ctx, span, "load", err)
+		return nil, err
+	}
+
+	// Stream IDs are not used as metric attributes to keep the cardinality low.
+	stream := (*entity).Stream()
+	typeAttribute := Attr(AttributeStreamType, stream.Type())
+
+	span.SetAttributes(typeAttribute, Attr(AttributeStreamVersion, stream.Version()))
+	s.meter.RecordHistogram(ctx, MetricLoadDuration, duration, typeAttribute)
+	s.meter.RecordHistogram(ctx, MetricStreamEvents, float64(stream.Version()), typeAttribute, Attr(AttributeOperation, "load"))
+
+	return entity, nil
+}
+
+func (s *EventStore[T]) Save(ctx context.Context, t *T) error {
+	if t == nil {
+		return s.store.Save(ctx, t)
+	}
+
+	stream := (*t).Stream()
+	typeAttribute := Attr(AttributeStreamType, stream.Type())
+
+	ctx, span := s.tracer.Start(ctx, SpanSave, Attr(AttributeStreamID, stream.ID()), typeAttribute)
+	defer span.End()
+
+	start := time.Now()
+	err := s.store.Save(ctx, t)
+	s.meter.RecordHistogram(ctx, MetricSaveDuration, time.Since(start).Seconds(), typeAttribute)
+
+	if err != nil {
+		s.recordError(ctx, span, "save", err)
+		return err
+	}
+
+	span.SetAttributes(Attr(AttributeStreamVersion, stream.Version()))
+	s.meter.RecordHistogram(ctx, MetricStreamEvents, float64(stream.Version()), typeAttribute, Attr(AttributeOperation, "save"))
+
+	return nil
+}
+
+// LoadEvents calls LoadEvents of the wrapped store, so EventStore can be wrapped with eventstore.CachingStore.
+// It returns an error if the wrapped store doesn't implement eventstore.EventsLoader.
+func (s *EventStore[T]) LoadEvents(ctx context.Context, id string, afterVersion int) (eventstore.StreamEvents[T], error) {
+	loader, ok := s.store.(eventstore.EventsLoader[T])
+	if !ok {
+		return eventstore.StreamEvents[T]{}, fmt.Errorf("store %T does not implement eventstore.EventsLoader", s.store)
+	}
+
+	ctx, span := s.tracer.Start(ctx, SpanLoad, Attr(AttributeStreamID, id))
+	defer span.End()
+
+	start := time.Now()
+	events, err := loader.LoadEvents(ctx, id, afterVersion)
+	s.meter.RecordHistogram(ctx, MetricLoadDuration, time.Since(start).Seconds())
+
+	if err != nil {
+		s.recordError(ctx, span, "load", err)
+		return eventstore.StreamEvents[T]{}, err
+	}
+
+	span.SetAttributes(Attr(AttributeEventCount, len(events.Events)))
+
+	return events, nil
+}
+
+func (s *EventStore[T]) recordError(ctx context.Context, span Span, operation string, err error) {
+	span.RecordError(err)
+	s.meter.AddCounter(ctx, MetricErrors, 1, Attr(AttributeOperation, operation))
+}
+
+// NewStageHook returns an eventstore.StageHook tracing the stages of the store's calls,
+// like the SQL queries, decoding the events and applying them, and recording their latency.
+// Nil tracer or meter disable tracing or metrics respectively.
+func NewStageHook(tracer Tracer, meter Meter) eventstore.StageHook {
+	tracer = tracerOrNoop(tracer)
+	meter = meterOrNoop(meter)
+
+	return func(ctx context.Context, stage eventstore.Stage) (context.Context, func(error)) {
+		stageAttribute := Attr(AttributeStage, string(stage))
+		ctx, span := tracer.Start(ctx, SpanStage, stageAttribute)
+		start := time.Now()
+
+		return ctx, func(err error) {
+			defer span.End()
+
+			meter.RecordHistogram(ctx, MetricStageDuration, time.Since(start).Seconds(), stageAttribute)
+			if err != nil {
+				span.RecordError(err)
+			}
+		}
+	}
+}
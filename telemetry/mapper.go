@@ -0,0 +1,97 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"github.com/ThreeDotsLabs/esja"
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+// Mapper is a wrapper to any transport.Mapper instance.
+// Mapper traces mapping of events and records its latency and errors.
+type Mapper[T any] struct {
+	mapper transport.Mapper[T]
+	tracer Tracer
+	meter  Meter
+}
+
+// NewMapper returns a new instance of Mapper.
+// Nil tracer or meter disable tracing or metrics respectively.
+func NewMapper[T any](
+	mapper transport.Mapper[T],
+	tracer Tracer,
+	meter Meter,
+) *Mapper[T] {
+	return &Mapper[T]{
+		mapper: mapper,
+		tracer: tracerOrNoop(tracer),
+		meter:  meterOrNoop(meter),
+	}
+}
+
+// Validate validates the wrapped mapper.
+func (m *Mapper[T]) Validate() error {
+	return transport.ValidateMapper(m.mapper)
+}
+
+// SupportedEvents returns the names of events supported by the wrapped mapper.
+func (m *Mapper[T]) SupportedEvents() []string {
+	return transport.SupportedEvents(m.mapper)
+}
+
+func (m *Mapper[T]) New(eventName string) (any, error) {
+	return m.mapper.New(eventName)
+}
+
+func (m *Mapper[T]) FromTransport(
+	ctx context.Context,
+	streamID string,
+	transportEvent any,
+) (esja.Event[T], error) {
+	ctx, span := m.tracer.Start(ctx, SpanFromTransport, Attr(AttributeStreamID, streamID))
+	defer span.End()
+
+	start := time.Now()
+	event, err := m.mapper.FromTransport(ctx, streamID, transportEvent)
+	duration := time.Since(start).Seconds()
+
+	operation := Attr(AttributeOperation, "from_transport")
+
+	if err != nil {
+		span.RecordError(err)
+		m.meter.RecordHistogram(ctx, MetricMapperDuration, duration, operation)
+		m.meter.AddCounter(ctx, MetricMapperErrors, 1, operation)
+		return nil, err
+	}
+
+	eventName := Attr(AttributeEventName, event.EventName())
+	span.SetAttributes(eventName)
+	m.meter.RecordHistogram(ctx, MetricMapperDuration, duration, operation, eventName)
+
+	return event, nil
+}
+
+func (m *Mapper[T]) ToTransport(
+	ctx context.Context,
+	streamID string,
+	event esja.Event[T],
+) (any, error) {
+	operation := Attr(AttributeOperation, "to_transport")
+	eventName := Attr(AttributeEventName, event.EventName())
+
+	ctx, span := m.tracer.Start(ctx, SpanToTransport, Attr(AttributeStreamID, streamID), eventName)
+	defer span.End()
+
+	start := time.Now()
+	transportEvent, err := m.mapper.ToTransport(ctx, streamID, event)
+	m.meter.RecordHistogram(ctx, MetricMapperDuration, time.Since(start).Seconds(), operation, eventName)
+
+	if err != nil {
+		span.RecordError(err)
+		m.meter.AddCounter(ctx, MetricMapperErrors, 1, operation, eventName)
+		return nil, err
+	}
+
+	return transportEvent, nil
+}
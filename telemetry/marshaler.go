@@ -0,0 +1,77 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+// Marshaler is a wrapper to any transport.Marshaler instance.
+// Marshaler traces marshaling and records its latency, payload sizes and errors.
+//
+// Marshaler implements transport.ContextMarshaler, so the event stores pass it the context
+// of the operation. Marshal and Unmarshal use context.Background().
+type Marshaler struct {
+	marshaler transport.Marshaler
+	tracer    Tracer
+	meter     Meter
+}
+
+// NewMarshaler returns a new instance of Marshaler.
+// Nil tracer or meter disable tracing or metrics respectively.
+func NewMarshaler(marshaler transport.Marshaler, tracer Tracer, meter Meter) *Marshaler {
+	return &Marshaler{
+		marshaler: marshaler,
+		tracer:    tracerOrNoop(tracer),
+		meter:     meterOrNoop(meter),
+	}
+}
+
+func (m *Marshaler) Marshal(data interface{}) ([]byte, error) {
+	return m.MarshalContext(context.Background(), data)
+}
+
+func (m *Marshaler) Unmarshal(bytes []byte, target interface{}) error {
+	return m.UnmarshalContext(context.Background(), bytes, target)
+}
+
+func (m *Marshaler) MarshalContext(ctx context.Context, data interface{}) ([]byte, error) {
+	ctx, span := m.tracer.Start(ctx, SpanMarshal)
+	defer span.End()
+
+	operation := Attr(AttributeOperation, "marshal")
+
+	start := time.Now()
+	payload, err := transport.MarshalContext(ctx, m.marshaler, data)
+	m.meter.RecordHistogram(ctx, MetricMarshalerDuration, time.Since(start).Seconds(), operation)
+	if err != nil {
+		span.RecordError(err)
+		m.meter.AddCounter(ctx, MetricMarshalerErrors, 1, operation)
+		return nil, err
+	}
+
+	span.SetAttributes(Attr(AttributePayloadBytes, len(payload)))
+	m.meter.RecordHistogram(ctx, MetricPayloadBytes, float64(len(payload)), operation)
+
+	return payload, nil
+}
+
+func (m *Marshaler) UnmarshalContext(ctx context.Context, bytes []byte, target interface{}) error {
+	ctx, span := m.tracer.Start(ctx, SpanUnmarshal, Attr(AttributePayloadBytes, len(bytes)))
+	defer span.End()
+
+	operation := Attr(AttributeOperation, "unmarshal")
+	m.meter.RecordHistogram(ctx, MetricPayloadBytes, float64(len(bytes)), operation)
+
+	start := time.Now()
+	err := transport.UnmarshalContext(ctx, m.marshaler, bytes, target)
+	m.meter.RecordHistogram(ctx, MetricMarshalerDuration, time.Since(start).Seconds(), operation)
+	if err != nil {
+		span.RecordError(err)
+		m.meter.AddCounter(ctx, MetricMarshalerErrors, 1, operation)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type recordedSpanContextKey struct{}
+
+// RecordedSpan is a span recorded by the Recorder.
+type RecordedSpan struct {
+	Name       string
+	Parent     string
+	Attributes map[string]any
+	Errors     []error
+	Start      time.Time
+	End        time.Time
+	Ended      bool
+}
+
+// MeasurementKind is the kind of instrument that recorded the Measurement.
+type MeasurementKind string
+
+const (
+	MeasurementHistogram MeasurementKind = "histogram"
+	MeasurementCounter   MeasurementKind = "counter"
+)
+
+// Measurement is a value recorded by the Recorder.
+type Measurement struct {
+	Kind       MeasurementKind
+	Name       string
+	Value      float64
+	Attributes map[string]any
+}
+
+// Recorder is an in-memory Tracer and Meter meant for tests.
+type Recorder struct {
+	lock         sync.Mutex
+	spans        []*RecordedSpan
+	measurements []Measurement
+}
+
+// NewRecorder returns a new instance of Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, Span) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	span := &RecordedSpan{
+		Name:       name,
+		Attributes: map[string]any{},
+		Start:      time.Now(),
+	}
+
+	if parent, ok := ctx.Value(recordedSpanContextKey{}).(*RecordedSpan); ok {
+		span.Parent = parent.Name
+	}
+
+	for _, a := range attributes {
+		span.Attributes[a.Key] = a.Value
+	}
+
+	r.spans = append(r.spans, span)
+
+	return context.WithValue(ctx, recordedSpanContextKey{}, span), &recorderSpan{
+		recorder: r,
+		span:     span,
+	}
+}
+
+func (r *Recorder) RecordHistogram(_ context.Context, name string, value float64, attributes ...Attribute) {
+	r.record(MeasurementHistogram, name, value, attributes)
+}
+
+func (r *Recorder) AddCounter(_ context.Context, name string, value int64, attributes ...Attribute) {
+	r.record(MeasurementCounter, name, float64(value), attributes)
+}
+
+// Spans returns copies of all recorded spans in the order they were started.
+func (r *Recorder) Spans() []RecordedSpan {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	spans := make([]RecordedSpan, len(r.spans))
+	for i, s := range r.spans {
+		spans[i] = *s
+		spans[i].Attributes = copyAttributes(s.Attributes)
+		spans[i].Errors = append([]error(nil), s.Errors...)
+	}
+
+	return spans
+}
+
+// SpansByName returns copies of the recorded spans with the provided name.
+func (r *Recorder) SpansByName(name string) []RecordedSpan {
+	var spans []RecordedSpan
+	for _, s := range r.Spans() {
+		if s.Name == name {
+			spans = append(spans, s)
+		}
+	}
+
+	return spans
+}
+
+// Measurements returns all recorded measurements in the order they were recorded.
+func (r *Recorder) Measurements() []Measurement {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	measurements := make([]Measurement, len(r.measurements))
+	copy(measurements, r.measurements)
+
+	return measurements
+}
+
+// MeasurementsByName returns the recorded measurements with the provided name.
+func (r *Recorder) MeasurementsByName(name string) []Measurement {
+	var measurements []Measurement
+	for _, m := range r.Measurements() {
+		if m.Name == name {
+			measurements = append(measurements, m)
+		}
+	}
+
+	return measurements
+}
+
+// Reset removes all recorded spans and measurements.
+func (r *Recorder) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.spans = nil
+	r.measurements = nil
+}
+
+func (r *Recorder) record(kind MeasurementKind, name string, value float64, attributes []Attribute) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	m := Measurement{
+		Kind:       kind,
+		Name:       name,
+		Value:      value,
+		Attributes: map[string]any{},
+	}
+
+	for _, a := range attributes {
+		m.Attributes[a.Key] = a.Value
+	}
+
+	r.measurements = append(r.measurements, m)
+}
+
+type recorderSpan struct {
+	recorder *Recorder
+	span     *RecordedSpan
+}
+
+func (s *recorderSpan) SetAttributes(attributes ...Attribute) {
+	s.recorder.lock.Lock()
+	defer s.recorder.lock.Unlock()
+
+	for _, a := range attributes {
+		s.span.Attributes[a.Key] = a.Value
+	}
+}
+
+func (s *recorderSpan) RecordError(err error) {
+	s.recorder.lock.Lock()
+	defer s.recorder.lock.Unlock()
+
+	s.span.Errors = append(s.span.Errors, err)
+}
+
+func (s *recorderSpan) End() {
+	s.recorder.lock.Lock()
+	defer s.recorder.lock.Unlock()
+
+	s.span.End = time.Now()
+	s.span.Ended = true
+}
+
+func copyAttributes(attributes map[string]any) map[string]any {
+	c := make(map[string]any, len(attributes))
+	for k, v := range attributes {
+		c[k] = v
+	}
+
+	return c
+}
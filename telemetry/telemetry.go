@@ -0,0 +1,119 @@
+// Package telemetry provides tracing and metrics wrappers
+// for event stores, mappers and marshalers.
+//
+// The wrappers depend only on the small Tracer and Meter interfaces,
+// which can be implemented with OpenTelemetry or any other instrumentation library.
+package telemetry
+
+import (
+	"context"
+)
+
+// Span names.
+const (
+	SpanLoad          = "esja.eventstore.load"
+	SpanSave          = "esja.eventstore.save"
+	SpanStage         = "esja.eventstore.stage"
+	SpanToTransport   = "esja.mapper.to_transport"
+	SpanFromTransport = "esja.mapper.from_transport"
+	SpanMarshal       = "esja.marshaler.marshal"
+	SpanUnmarshal     = "esja.marshaler.unmarshal"
+)
+
+// Metric names. Durations are recorded in seconds.
+const (
+	MetricLoadDuration      = "esja.eventstore.load.duration"
+	MetricSaveDuration      = "esja.eventstore.save.duration"
+	MetricStageDuration     = "esja.eventstore.stage.duration"
+	MetricStreamEvents      = "esja.eventstore.stream.events"
+	MetricErrors            = "esja.eventstore.errors"
+	MetricMapperDuration    = "esja.mapper.duration"
+	MetricMapperErrors      = "esja.mapper.errors"
+	MetricMarshalerDuration = "esja.marshaler.duration"
+	MetricPayloadBytes      = "esja.marshaler.payload.bytes"
+	MetricMarshalerErrors   = "esja.marshaler.errors"
+)
+
+// Attribute keys.
+const (
+	AttributeStreamID      = "esja.stream_id"
+	AttributeStreamType    = "esja.stream_type"
+	AttributeStreamVersion = "esja.stream_version"
+	AttributeEventName     = "esja.event_name"
+	AttributeEventCount    = "esja.event_count"
+	AttributeOperation     = "esja.operation"
+	AttributeStage         = "esja.stage"
+	AttributePayloadBytes  = "esja.payload_bytes"
+)
+
+// Attribute is a key-value pair describing a span or a measurement.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Attr returns a new Attribute.
+func Attr(key string, value any) Attribute {
+	return Attribute{
+		Key:   key,
+		Value: value,
+	}
+}
+
+// Tracer starts spans.
+type Tracer interface {
+	// Start starts a new span and returns the context carrying it.
+	Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, Span)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	SetAttributes(attributes ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Meter records measurements.
+type Meter interface {
+	// RecordHistogram records the value in the histogram of the provided name.
+	RecordHistogram(ctx context.Context, name string, value float64, attributes ...Attribute)
+
+	// AddCounter adds the value to the counter of the provided name.
+	AddCounter(ctx context.Context, name string, value int64, attributes ...Attribute)
+}
+
+// NoopTracer is a Tracer which doesn't record anything.
+type NoopTracer struct{}
+
+func (NoopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+
+func (noopSpan) RecordError(error) {}
+
+func (noopSpan) End() {}
+
+// NoopMeter is a Meter which doesn't record anything.
+type NoopMeter struct{}
+
+func (NoopMeter) RecordHistogram(context.Context, string, float64, ...Attribute) {}
+
+func (NoopMeter) AddCounter(context.Context, string, int64, ...Attribute) {}
+
+func tracerOrNoop(tracer Tracer) Tracer {
+	if tracer == nil {
+		return NoopTracer{}
+	}
+	return tracer
+}
+
+func meterOrNoop(meter Meter) Meter {
+	if meter == nil {
+		return NoopMeter{}
+	}
+	return meter
+}
@@ -0,0 +1,100 @@
+package telemetry_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja/eventstore"
+	"github.com/ThreeDotsLabs/esja/eventstore/eventstoretest"
+	"github.com/ThreeDotsLabs/esja/telemetry"
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+func TestEventStore(t *testing.T) {
+	ctx := context.Background()
+	recorder := telemetry.NewRecorder()
+
+	inner, err := eventstore.NewInMemoryStoreWithConfig(
+		eventstore.InMemoryConfig[eventstoretest.Entity]{
+			Mapper: telemetry.NewMapper[eventstoretest.Entity](
+				transport.NewNoOpMapper(eventstoretest.SupportedEvents()),
+				recorder,
+				recorder,
+			),
+			Marshaler: telemetry.NewMarshaler(transport.JSONMarshaler{}, recorder, recorder),
+			Hooks: eventstore.Hooks[eventstoretest.Entity]{
+				Stage: []eventstore.StageHook{telemetry.NewStageHook(recorder, recorder)},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	store := telemetry.NewEventStore[eventstoretest.Entity](inner, recorder, recorder)
+
+	entity, err := eventstoretest.NewEntity(eventstoretest.NewID())
+	require.NoError(t, err)
+	err = entity.Update("value")
+	require.NoError(t, err)
+
+	err = store.Save(ctx, entity)
+	require.NoError(t, err)
+
+	_, err = store.Load(ctx, entity.ID())
+	require.NoError(t, err)
+
+	_, err = store.Load(ctx, eventstoretest.NewID())
+	require.ErrorIs(t, err, eventstore.ErrEntityNotFound)
+
+	saveSpans := recorder.SpansByName(telemetry.SpanSave)
+	require.Len(t, saveSpans, 1)
+	assert.True(t, saveSpans[0].Ended)
+	assert.Equal(t, entity.ID(), saveSpans[0].Attributes[telemetry.AttributeStreamID])
+	assert.Equal(t, "Entity", saveSpans[0].Attributes[telemetry.AttributeStreamType])
+	assert.Equal(t, 2, saveSpans[0].Attributes[telemetry.AttributeStreamVersion])
+
+	loadSpans := recorder.SpansByName(telemetry.SpanLoad)
+	require.Len(t, loadSpans, 2)
+	assert.Empty(t, loadSpans[0].Errors)
+	assert.Len(t, loadSpans[1].Errors, 1)
+
+	toTransportSpans := recorder.SpansByName(telemetry.SpanToTransport)
+	require.Len(t, toTransportSpans, 2)
+	assert.Equal(t, telemetry.SpanSave, toTransportSpans[0].Parent)
+
+	fromTransportSpans := recorder.SpansByName(telemetry.SpanFromTransport)
+	require.Len(t, fromTransportSpans, 2)
+	assert.Equal(t, telemetry.SpanLoad, fromTransportSpans[0].Parent)
+	assert.Equal(t, "Updated_v1", fromTransportSpans[1].Attributes[telemetry.AttributeEventName])
+
+	marshalSpans := recorder.SpansByName(telemetry.SpanMarshal)
+	require.Len(t, marshalSpans, 2)
+	assert.Equal(t, telemetry.SpanSave, marshalSpans[0].Parent)
+
+	unmarshalSpans := recorder.SpansByName(telemetry.SpanUnmarshal)
+	require.Len(t, unmarshalSpans, 2)
+	assert.Equal(t, telemetry.SpanLoad, unmarshalSpans[0].Parent)
+
+	stageSpans := recorder.SpansByName(telemetry.SpanStage)
+	require.Len(t, stageSpans, 1)
+	assert.Equal(t, "apply", stageSpans[0].Attributes[telemetry.AttributeStage])
+	assert.Equal(t, telemetry.SpanLoad, stageSpans[0].Parent)
+	assert.Len(t, recorder.MeasurementsByName(telemetry.MetricStageDuration), 1)
+
+	streamEvents := recorder.MeasurementsByName(telemetry.MetricStreamEvents)
+	require.Len(t, streamEvents, 2)
+	assert.Equal(t, float64(2), streamEvents[0].Value)
+	assert.Equal(t, "save", streamEvents[0].Attributes[telemetry.AttributeOperation])
+	assert.Equal(t, float64(2), streamEvents[1].Value)
+	assert.Equal(t, "load", streamEvents[1].Attributes[telemetry.AttributeOperation])
+
+	assert.Len(t, recorder.MeasurementsByName(telemetry.MetricSaveDuration), 1)
+	assert.Len(t, recorder.MeasurementsByName(telemetry.MetricLoadDuration), 2)
+	assert.Len(t, recorder.MeasurementsByName(telemetry.MetricPayloadBytes), 4)
+
+	errs := recorder.MeasurementsByName(telemetry.MetricErrors)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "load", errs[0].Attributes[telemetry.AttributeOperation])
+}
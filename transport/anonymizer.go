@@ -36,6 +36,16 @@ func NewAnonymizer[T any](
 	}
 }
 
+// Validate validates the wrapped mapper.
+func (a *Anonymizer[T]) Validate() error {
+	return ValidateMapper(a.mapper)
+}
+
+// SupportedEvents returns the names of events supported by the wrapped mapper.
+func (a *Anonymizer[T]) SupportedEvents() []string {
+	return SupportedEvents(a.mapper)
+}
+
 func (a *Anonymizer[T]) New(eventName string) (any, error) {
 	return a.mapper.New(eventName)
 }
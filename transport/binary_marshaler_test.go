@@ -0,0 +1,92 @@
+package transport_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+type shipment struct {
+	ID        string                 `json:"id"`
+	Weight    float64                `cbor:"weight_kg" msgpack:"weight_kg"`
+	SentAt    time.Time              `json:"sent_at"`
+	Stops     []time.Time            `json:"stops"`
+	Deadline  *time.Time             `json:"deadline"`
+	Labels    map[string]string      `json:"labels"`
+	Metadata  map[string]interface{} `json:"metadata"`
+	Delivered time.Time              `json:"delivered"`
+}
+
+func TestBinaryMarshalers(t *testing.T) {
+	warsaw := time.FixedZone("CET", 3600)
+	sentAt := time.Date(2024, 3, 1, 12, 30, 15, 123456789, warsaw)
+	deadline := sentAt.Add(48 * time.Hour)
+
+	s := shipment{
+		ID:       "1",
+		Weight:   2.5,
+		SentAt:   sentAt,
+		Stops:    []time.Time{sentAt.Add(time.Hour)},
+		Deadline: &deadline,
+		Labels: map[string]string{
+			"fragile": "yes",
+			"color":   "red",
+			"size":    "L",
+		},
+		Metadata: map[string]interface{}{
+			"source": "api",
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		marshaler transport.Marshaler
+	}{
+		{
+			name:      "cbor",
+			marshaler: transport.CBORMarshaler{},
+		},
+		{
+			name:      "msgpack",
+			marshaler: transport.MsgPackMarshaler{},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			payload, err := tc.marshaler.Marshal(s)
+			require.NoError(t, err)
+
+			assert.Contains(t, string(payload), "weight_kg", "native struct tag should be used")
+			assert.Contains(t, string(payload), "sent_at", "json struct tag should be used as fallback")
+
+			for i := 0; i < 10; i++ {
+				again, err := tc.marshaler.Marshal(s)
+				require.NoError(t, err)
+				require.Equal(t, payload, again, "encoding should be deterministic")
+			}
+
+			decoded := shipment{}
+			err = tc.marshaler.Unmarshal(payload, &decoded)
+			require.NoError(t, err)
+
+			assert.Equal(t, time.UTC, decoded.SentAt.Location())
+			assert.True(t, sentAt.Equal(decoded.SentAt))
+			assert.Equal(t, sentAt.UTC(), decoded.SentAt)
+			assert.Equal(t, []time.Time{sentAt.Add(time.Hour).UTC()}, decoded.Stops)
+			require.NotNil(t, decoded.Deadline)
+			assert.Equal(t, deadline.UTC(), *decoded.Deadline)
+			assert.True(t, decoded.Delivered.IsZero())
+
+			assert.Equal(t, s.ID, decoded.ID)
+			assert.Equal(t, s.Weight, decoded.Weight)
+			assert.Equal(t, s.Labels, decoded.Labels)
+			assert.Equal(t, s.Metadata, decoded.Metadata)
+		})
+	}
+}
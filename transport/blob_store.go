@@ -0,0 +1,293 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ErrBlobNotFound is returned by BlobStore for unknown keys.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobInfo describes a blob kept in the BlobStore.
+type BlobInfo struct {
+	Key string
+	// StoredAt is the time the blob was last put.
+	StoredAt time.Time
+}
+
+// BlobStore keeps the payloads offloaded by ClaimCheckMarshaler.
+type BlobStore interface {
+	// Put stores the blob. Putting an existing key overwrites it and updates its StoredAt.
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Get returns the blob, or ErrBlobNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Delete removes the blob. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// DeleteIfStoredBefore removes the blob only if it was last put before the time,
+	// so a blob put again after it was listed is kept.
+	// It returns false if the blob is kept or missing.
+	DeleteIfStoredBefore(ctx context.Context, key string, before time.Time) (bool, error)
+
+	// List returns all kept blobs.
+	List(ctx context.Context) ([]BlobInfo, error)
+}
+
+type memoryBlob struct {
+	data     []byte
+	storedAt time.Time
+}
+
+// MemoryBlobStore is a BlobStore keeping the blobs in memory.
+type MemoryBlobStore struct {
+	lock  sync.RWMutex
+	blobs map[string]memoryBlob
+}
+
+// NewMemoryBlobStore returns a new instance of MemoryBlobStore.
+func NewMemoryBlobStore() *MemoryBlobStore {
+	return &MemoryBlobStore{
+		blobs: map[string]memoryBlob{},
+	}
+}
+
+func (s *MemoryBlobStore) Put(_ context.Context, key string, data []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.blobs[key] = memoryBlob{
+		data:     append([]byte(nil), data...),
+		storedAt: time.Now(),
+	}
+
+	return nil
+}
+
+func (s *MemoryBlobStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	blob, ok := s.blobs[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, key)
+	}
+
+	return append([]byte(nil), blob.data...), nil
+}
+
+func (s *MemoryBlobStore) Delete(_ context.Context, key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.blobs, key)
+
+	return nil
+}
+
+func (s *MemoryBlobStore) DeleteIfStoredBefore(_ context.Context, key string, before time.Time) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	blob, ok := s.blobs[key]
+	if !ok || !blob.storedAt.Before(before) {
+		return false, nil
+	}
+
+	delete(s.blobs, key)
+
+	return true, nil
+}
+
+func (s *MemoryBlobStore) List(_ context.Context) ([]BlobInfo, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	blobs := make([]BlobInfo, 0, len(s.blobs))
+	for key, blob := range s.blobs {
+		blobs = append(blobs, BlobInfo{
+			Key:      key,
+			StoredAt: blob.storedAt,
+		})
+	}
+
+	return blobs, nil
+}
+
+var blobKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// FileBlobStore is a BlobStore keeping each blob in a file of the directory.
+// Keys can contain only letters, digits, '-' and '_'.
+type FileBlobStore struct {
+	dir string
+}
+
+// NewFileBlobStore returns a new instance of FileBlobStore, creating the directory if needed.
+func NewFileBlobStore(dir string) (FileBlobStore, error) {
+	if dir == "" {
+		return FileBlobStore{}, errors.New("directory is empty")
+	}
+
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return FileBlobStore{}, fmt.Errorf("error creating directory: %w", err)
+	}
+
+	return FileBlobStore{
+		dir: dir,
+	}, nil
+}
+
+// Put writes the blob to a temporary file first, so readers never see partially written blobs.
+func (s FileBlobStore) Put(_ context.Context, key string, data []byte) (err error) {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	_, err = f.Write(data)
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("error writing blob: %w", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("error closing blob file: %w", err)
+	}
+
+	err = os.Rename(f.Name(), path)
+	if err != nil {
+		return fmt.Errorf("error renaming blob file: %w", err)
+	}
+
+	return nil
+}
+
+func (s FileBlobStore) Get(_ context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading blob: %w", err)
+	}
+
+	return data, nil
+}
+
+func (s FileBlobStore) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing blob: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteIfStoredBefore moves the blob aside before checking its modification time,
+// so a blob put again in the meantime is either moved aside too and restored, or not touched.
+// Reading the blob while it's moved aside returns ErrBlobNotFound.
+func (s FileBlobStore) DeleteIfStoredBefore(_ context.Context, key string, before time.Time) (bool, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+
+	aside := filepath.Join(s.dir, ".delete-"+key)
+
+	err = os.Rename(path, aside)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error moving blob aside: %w", err)
+	}
+
+	info, err := os.Stat(aside)
+	if err != nil {
+		return false, fmt.Errorf("error reading blob info: %w", err)
+	}
+
+	if !info.ModTime().Before(before) {
+		// ClaimCheckMarshaler keys blobs by their content,
+		// so restoring the blob over the one put in the meantime keeps the same data.
+		err = os.Rename(aside, path)
+		if err != nil {
+			return false, fmt.Errorf("error restoring blob: %w", err)
+		}
+		return false, nil
+	}
+
+	err = os.Remove(aside)
+	if err != nil {
+		return false, fmt.Errorf("error removing blob: %w", err)
+	}
+
+	return true, nil
+}
+
+func (s FileBlobStore) List(_ context.Context) ([]BlobInfo, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory: %w", err)
+	}
+
+	var blobs []BlobInfo
+	for _, e := range entries {
+		if e.IsDir() || !blobKeyRegexp.MatchString(e.Name()) {
+			continue
+		}
+
+		info, err := e.Info()
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading blob info: %w", err)
+		}
+
+		blobs = append(blobs, BlobInfo{
+			Key:      e.Name(),
+			StoredAt: info.ModTime(),
+		})
+	}
+
+	return blobs, nil
+}
+
+func (s FileBlobStore) path(key string) (string, error) {
+	if !blobKeyRegexp.MatchString(key) {
+		return "", fmt.Errorf("invalid blob key '%s'", key)
+	}
+
+	return filepath.Join(s.dir, key), nil
+}
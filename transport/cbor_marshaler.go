@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+var (
+	cborEncMode = mustCBOREncMode()
+	cborDecMode = mustCBORDecMode()
+)
+
+// CBORMarshaler encodes payloads with CBOR (RFC 8949).
+// Struct fields use the `cbor` tags, falling back to the `json` tags.
+//
+// Payloads are encoded deterministically, with sorted map keys and the shortest number encodings.
+// Time values are encoded as RFC 3339 strings with nanoseconds and decoded in UTC,
+// so loaded events don't depend on the time zone of the machine.
+//
+// Payloads are binary, so use SchemaConfig.BinaryPayload with Postgres.
+type CBORMarshaler struct{}
+
+func (CBORMarshaler) Marshal(data interface{}) ([]byte, error) {
+	return cborEncMode.Marshal(data)
+}
+
+func (CBORMarshaler) Unmarshal(data []byte, target interface{}) error {
+	err := cborDecMode.Unmarshal(data, target)
+	if err != nil {
+		return err
+	}
+
+	timesToUTC(reflect.ValueOf(target))
+
+	return nil
+}
+
+func mustCBOREncMode() cbor.EncMode {
+	options := cbor.CoreDetEncOptions()
+	options.Time = cbor.TimeRFC3339Nano
+	options.TimeTag = cbor.EncTagRequired
+
+	mode, err := options.EncMode()
+	if err != nil {
+		panic(err)
+	}
+
+	return mode
+}
+
+func mustCBORDecMode() cbor.DecMode {
+	mode, err := cbor.DecOptions{
+		DefaultMapType: reflect.TypeOf(map[string]interface{}{}),
+	}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+
+	return mode
+}
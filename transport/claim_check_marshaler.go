@@ -0,0 +1,169 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const defaultClaimCheckThreshold = 64 * 1024
+
+// claimCheckHeader starts the payloads offloaded to the BlobStore and is followed by the blob key.
+// It can't start a JSON document or a GOB stream, so other payloads are read as they are.
+var claimCheckHeader = []byte("\xffEC")
+
+type ClaimCheckConfig struct {
+	// Threshold is the size in bytes from which payloads are offloaded to the BlobStore.
+	// Defaults to 64 KiB.
+	Threshold int
+}
+
+func (c *ClaimCheckConfig) setDefaults() {
+	if c.Threshold == 0 {
+		c.Threshold = defaultClaimCheckThreshold
+	}
+}
+
+func (c ClaimCheckConfig) validate() error {
+	if c.Threshold < 0 {
+		return errors.New("threshold must not be negative")
+	}
+	return nil
+}
+
+// ClaimCheckMarshaler decorates a Marshaler, offloading payloads above the size threshold
+// to the BlobStore and keeping only the reference to the blob in the stored payload.
+// Blobs are keyed by the SHA-256 of the payload, so equal payloads share a blob.
+//
+// Blobs are not removed with the events, as they can be shared by many events.
+// Use the CollectBlobs method of the event stores to remove the ones no event refers to.
+// To rewrite the payloads of the wrapped marshaler, like re-encrypting them, use RewriteBlobs.
+// It finds the references in the stored payloads, so ClaimCheckMarshaler must be the outermost
+// decorator, e.g. wrapping EncryptingMarshaler instead of being wrapped by it.
+//
+// ClaimCheckMarshaler implements ContextMarshaler and passes the context to the wrapped marshaler
+// and the BlobStore.
+// References aren't valid JSON, so use SchemaConfig.BinaryPayload with Postgres.
+type ClaimCheckMarshaler struct {
+	marshaler Marshaler
+	blobs     BlobStore
+	config    ClaimCheckConfig
+}
+
+// NewClaimCheckMarshaler returns a new instance of ClaimCheckMarshaler.
+func NewClaimCheckMarshaler(marshaler Marshaler, blobs BlobStore, config ClaimCheckConfig) (ClaimCheckMarshaler, error) {
+	if marshaler == nil {
+		return ClaimCheckMarshaler{}, errors.New("marshaler is nil")
+	}
+	if blobs == nil {
+		return ClaimCheckMarshaler{}, errors.New("blob store is nil")
+	}
+
+	config.setDefaults()
+	err := config.validate()
+	if err != nil {
+		return ClaimCheckMarshaler{}, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return ClaimCheckMarshaler{
+		marshaler: marshaler,
+		blobs:     blobs,
+		config:    config,
+	}, nil
+}
+
+func (m ClaimCheckMarshaler) Marshal(data interface{}) ([]byte, error) {
+	return m.MarshalContext(context.Background(), data)
+}
+
+func (m ClaimCheckMarshaler) Unmarshal(data []byte, target interface{}) error {
+	return m.UnmarshalContext(context.Background(), data, target)
+}
+
+func (m ClaimCheckMarshaler) MarshalContext(ctx context.Context, data interface{}) ([]byte, error) {
+	payload, err := MarshalContext(ctx, m.marshaler, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) < m.config.Threshold {
+		return payload, nil
+	}
+
+	return m.offload(ctx, payload)
+}
+
+func (m ClaimCheckMarshaler) offload(ctx context.Context, payload []byte) ([]byte, error) {
+	sum := sha256.Sum256(payload)
+	key := "sha256-" + hex.EncodeToString(sum[:])
+
+	err := m.blobs.Put(ctx, key, payload)
+	if err != nil {
+		return nil, fmt.Errorf("error storing blob: %w", err)
+	}
+
+	return append(append([]byte(nil), claimCheckHeader...), key...), nil
+}
+
+func (m ClaimCheckMarshaler) UnmarshalContext(ctx context.Context, data []byte, target interface{}) error {
+	key, ok := BlobKey(data)
+	if !ok {
+		return UnmarshalContext(ctx, m.marshaler, data, target)
+	}
+
+	payload, err := m.blobs.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("error getting blob: %w", err)
+	}
+
+	return UnmarshalContext(ctx, m.marshaler, payload, target)
+}
+
+// RewriteBlobs returns the rewriter of the stored payloads applying rewrite
+// to the payloads of the wrapped marshaler, both kept inline and offloaded to the BlobStore,
+// e.g. with eventstore.SQLStore.RewritePayloads and EncryptingMarshaler.Reencrypt.
+//
+// A rewritten blob is stored under the key of its new content, and the reference to it is returned.
+// The old blob is kept until it's removed by the CollectBlobs method of the event stores.
+// The BlobStore is called with ctx.
+func (m ClaimCheckMarshaler) RewriteBlobs(
+	ctx context.Context,
+	rewrite func(payload []byte) ([]byte, bool, error),
+) func(payload []byte) ([]byte, bool, error) {
+	return func(data []byte) ([]byte, bool, error) {
+		key, ok := BlobKey(data)
+		if !ok {
+			return rewrite(data)
+		}
+
+		payload, err := m.blobs.Get(ctx, key)
+		if err != nil {
+			return nil, false, fmt.Errorf("error getting blob: %w", err)
+		}
+
+		rewritten, ok, err := rewrite(payload)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+
+		reference, err := m.offload(ctx, rewritten)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return reference, true, nil
+	}
+}
+
+// BlobKey returns the key of the blob the payload of ClaimCheckMarshaler refers to,
+// or false if the payload is not offloaded.
+func BlobKey(payload []byte) (string, bool) {
+	if !bytes.HasPrefix(payload, claimCheckHeader) || len(payload) == len(claimCheckHeader) {
+		return "", false
+	}
+
+	return string(payload[len(claimCheckHeader):]), true
+}
@@ -0,0 +1,255 @@
+package transport_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+func TestClaimCheckMarshaler(t *testing.T) {
+	fileBlobs, err := transport.NewFileBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name  string
+		blobs transport.BlobStore
+	}{
+		{
+			name:  "memory",
+			blobs: transport.NewMemoryBlobStore(),
+		},
+		{
+			name:  "file",
+			blobs: fileBlobs,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			marshaler, err := transport.NewClaimCheckMarshaler(transport.JSONMarshaler{}, tc.blobs, transport.ClaimCheckConfig{
+				Threshold: 100,
+			})
+			require.NoError(t, err)
+
+			large := document{ID: "1", Content: strings.Repeat("a", 200)}
+
+			payload, err := marshaler.Marshal(large)
+			require.NoError(t, err)
+			assert.Less(t, len(payload), 100, "large payload should be replaced with the reference")
+
+			key, ok := transport.BlobKey(payload)
+			require.True(t, ok)
+			assert.True(t, strings.HasPrefix(key, "sha256-"))
+
+			blob, err := tc.blobs.Get(ctx, key)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"ID":"1","Content":"`+large.Content+`"}`, string(blob))
+
+			decoded := document{}
+			err = marshaler.Unmarshal(payload, &decoded)
+			require.NoError(t, err)
+			assert.Equal(t, large, decoded)
+
+			again, err := marshaler.Marshal(large)
+			require.NoError(t, err)
+			assert.Equal(t, payload, again, "equal payloads should share the blob")
+
+			listed, err := tc.blobs.List(ctx)
+			require.NoError(t, err)
+			require.Len(t, listed, 1)
+			assert.Equal(t, key, listed[0].Key)
+
+			small := document{ID: "2"}
+
+			payload, err = marshaler.Marshal(small)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"ID":"2","Content":""}`, string(payload), "small payload should be kept")
+
+			_, ok = transport.BlobKey(payload)
+			assert.False(t, ok)
+
+			err = tc.blobs.Delete(ctx, key)
+			require.NoError(t, err)
+			err = tc.blobs.Delete(ctx, key)
+			require.NoError(t, err, "deleting a missing blob should not fail")
+
+			err = marshaler.Unmarshal(again, &decoded)
+			assert.ErrorIs(t, err, transport.ErrBlobNotFound)
+		})
+	}
+}
+
+func TestFileBlobStore_invalid_key(t *testing.T) {
+	blobs, err := transport.NewFileBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	err = blobs.Put(context.Background(), "../outside", []byte("data"))
+	assert.EqualError(t, err, "invalid blob key '../outside'")
+}
+
+func TestNewClaimCheckMarshaler_invalid(t *testing.T) {
+	_, err := transport.NewClaimCheckMarshaler(nil, transport.NewMemoryBlobStore(), transport.ClaimCheckConfig{})
+	assert.EqualError(t, err, "marshaler is nil")
+
+	_, err = transport.NewClaimCheckMarshaler(transport.JSONMarshaler{}, nil, transport.ClaimCheckConfig{})
+	assert.EqualError(t, err, "blob store is nil")
+
+	_, err = transport.NewClaimCheckMarshaler(transport.JSONMarshaler{}, transport.NewMemoryBlobStore(), transport.ClaimCheckConfig{
+		Threshold: -1,
+	})
+	assert.EqualError(t, err, "invalid config: threshold must not be negative")
+}
+
+func TestClaimCheckMarshaler_rewrite_blobs(t *testing.T) {
+	blobs := transport.NewMemoryBlobStore()
+
+	keyRing, err := transport.NewMemoryKeyRing("a", keyA)
+	require.NoError(t, err)
+
+	encrypting, err := transport.NewEncryptingMarshaler(transport.JSONMarshaler{}, keyRing)
+	require.NoError(t, err)
+
+	marshaler, err := transport.NewClaimCheckMarshaler(encrypting, blobs, transport.ClaimCheckConfig{
+		Threshold: 100,
+	})
+	require.NoError(t, err)
+
+	large := document{ID: "1", Content: strings.Repeat("a", 200)}
+	small := document{ID: "2"}
+
+	largePayload, err := marshaler.Marshal(large)
+	require.NoError(t, err)
+	_, ok := transport.BlobKey(largePayload)
+	require.True(t, ok)
+
+	smallPayload, err := marshaler.Marshal(small)
+	require.NoError(t, err)
+
+	err = keyRing.Rotate("b", keyB)
+	require.NoError(t, err)
+
+	_, ok, err = encrypting.Reencrypt(largePayload)
+	require.NoError(t, err)
+	assert.False(t, ok, "references should not be encrypted")
+
+	rewrite := marshaler.RewriteBlobs(context.Background(), encrypting.Reencrypt)
+
+	rewrittenLarge, ok, err := rewrite(largePayload)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.NotEqual(t, largePayload, rewrittenLarge, "re-encrypted blob should be stored under a new key")
+
+	rewrittenSmall, ok, err := rewrite(smallPayload)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, ok, err = rewrite(rewrittenLarge)
+	require.NoError(t, err)
+	assert.False(t, ok, "blob encrypted with the current key should be kept")
+
+	onlyB, err := transport.NewMemoryKeyRing("b", keyB)
+	require.NoError(t, err)
+
+	onlyBEncrypting, err := transport.NewEncryptingMarshaler(transport.JSONMarshaler{}, onlyB)
+	require.NoError(t, err)
+
+	onlyBMarshaler, err := transport.NewClaimCheckMarshaler(onlyBEncrypting, blobs, transport.ClaimCheckConfig{})
+	require.NoError(t, err)
+
+	decoded := document{}
+	err = onlyBMarshaler.Unmarshal(rewrittenLarge, &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, large, decoded)
+
+	decoded = document{}
+	err = onlyBMarshaler.Unmarshal(rewrittenSmall, &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, small, decoded)
+}
+
+// contextBlobStore records the context values the blobs are stored and read with.
+type contextBlobStore struct {
+	transport.BlobStore
+	values *[]any
+}
+
+func (s contextBlobStore) Put(ctx context.Context, key string, data []byte) error {
+	*s.values = append(*s.values, ctx.Value(contextKey{}))
+	return s.BlobStore.Put(ctx, key, data)
+}
+
+func (s contextBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	*s.values = append(*s.values, ctx.Value(contextKey{}))
+	return s.BlobStore.Get(ctx, key)
+}
+
+func TestClaimCheckMarshaler_passes_context_to_blob_store(t *testing.T) {
+	var values []any
+	blobs := contextBlobStore{BlobStore: transport.NewMemoryBlobStore(), values: &values}
+
+	marshaler, err := transport.NewClaimCheckMarshaler(transport.JSONMarshaler{}, blobs, transport.ClaimCheckConfig{
+		Threshold: 1,
+	})
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), contextKey{}, "marshal")
+	payload, err := marshaler.MarshalContext(ctx, document{ID: "1"})
+	require.NoError(t, err)
+
+	ctx = context.WithValue(context.Background(), contextKey{}, "unmarshal")
+	var target document
+	err = marshaler.UnmarshalContext(ctx, payload, &target)
+	require.NoError(t, err)
+
+	ctx = context.WithValue(context.Background(), contextKey{}, "rewrite")
+	_, _, err = marshaler.RewriteBlobs(ctx, func(payload []byte) ([]byte, bool, error) {
+		return append(payload, ' '), true, nil
+	})(payload)
+	require.NoError(t, err)
+
+	assert.Equal(t, []any{"marshal", "unmarshal", "rewrite", "rewrite"}, values)
+}
+
+func TestBlobStore_delete_if_stored_before(t *testing.T) {
+	fileBlobs, err := transport.NewFileBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, blobs := range []transport.BlobStore{transport.NewMemoryBlobStore(), fileBlobs} {
+		ctx := context.Background()
+
+		err := blobs.Put(ctx, "key", []byte("data"))
+		require.NoError(t, err)
+
+		deleted, err := blobs.DeleteIfStoredBefore(ctx, "key", time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.False(t, deleted, "blob put after the time should be kept")
+
+		data, err := blobs.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("data"), data)
+
+		deleted, err = blobs.DeleteIfStoredBefore(ctx, "key", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.True(t, deleted)
+
+		_, err = blobs.Get(ctx, "key")
+		assert.ErrorIs(t, err, transport.ErrBlobNotFound)
+
+		deleted, err = blobs.DeleteIfStoredBefore(ctx, "key", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.False(t, deleted, "missing blob should not be deleted")
+
+		listed, err := blobs.List(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, listed)
+	}
+}
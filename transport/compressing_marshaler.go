@@ -0,0 +1,226 @@
+package transport
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+)
+
+const (
+	defaultCompressionThreshold = 1024
+	defaultMaxDecompressedSize  = 16 * 1024 * 1024
+)
+
+// CompressAllPayloads set as CompressionConfig.Threshold compresses payloads of any size.
+const CompressAllPayloads = -1
+
+// compressionHeader starts the compressed payloads and is followed by the CompressionAlgorithm.
+// It can't start a JSON document or a GOB stream, so uncompressed payloads are read as they are.
+var compressionHeader = []byte("\xffEZ")
+
+// CompressionAlgorithm is the algorithm used by CompressingMarshaler.
+type CompressionAlgorithm byte
+
+const (
+	// CompressionGzip compresses payloads with gzip.
+	CompressionGzip CompressionAlgorithm = 'g'
+	// CompressionDeflate compresses payloads with deflate, without the gzip header and checksum.
+	CompressionDeflate CompressionAlgorithm = 'd'
+)
+
+type CompressionConfig struct {
+	// Algorithm is used to compress new payloads. Defaults to CompressionGzip.
+	// Payloads compressed with any algorithm are decompressed.
+	Algorithm CompressionAlgorithm
+
+	// Threshold is the size in bytes from which payloads are compressed. Defaults to 1 KiB.
+	// Use CompressAllPayloads to compress payloads of any size.
+	Threshold int
+
+	// MaxDecompressedSize is the size in bytes above which decompressed payloads are rejected,
+	// so a small corrupted or crafted payload can't exhaust the memory. Defaults to 16 MiB.
+	MaxDecompressedSize int64
+
+	// Level is the compression level, from flate.BestSpeed to flate.BestCompression.
+	// Defaults to flate.DefaultCompression.
+	Level int
+}
+
+func (c *CompressionConfig) setDefaults() {
+	if c.Algorithm == 0 {
+		c.Algorithm = CompressionGzip
+	}
+	if c.Threshold == 0 {
+		c.Threshold = defaultCompressionThreshold
+	}
+	if c.Level == 0 {
+		c.Level = flate.DefaultCompression
+	}
+	if c.MaxDecompressedSize == 0 {
+		c.MaxDecompressedSize = defaultMaxDecompressedSize
+	}
+}
+
+func (c CompressionConfig) validate() error {
+	if c.Algorithm != CompressionGzip && c.Algorithm != CompressionDeflate {
+		return fmt.Errorf("unknown compression algorithm %q", c.Algorithm)
+	}
+	if c.Threshold < 0 && c.Threshold != CompressAllPayloads {
+		return fmt.Errorf("threshold must not be negative, except CompressAllPayloads")
+	}
+	if c.Level != flate.DefaultCompression && (c.Level < flate.BestSpeed || c.Level > flate.BestCompression) {
+		return fmt.Errorf("invalid compression level %d", c.Level)
+	}
+	if c.MaxDecompressedSize < 0 {
+		return fmt.Errorf("max decompressed size must not be negative")
+	}
+	return nil
+}
+
+// CompressingMarshaler decorates a Marshaler, compressing payloads above the size threshold.
+// Compressed payloads start with a header, so compressed and uncompressed payloads can be read
+// regardless of the threshold and algorithm they were written with.
+//
+// CompressingMarshaler implements ContextMarshaler and passes the context to the wrapped marshaler.
+// Compressed payloads aren't valid JSON, so use SchemaConfig.BinaryPayload with Postgres.
+type CompressingMarshaler struct {
+	marshaler Marshaler
+	config    CompressionConfig
+}
+
+// NewCompressingMarshaler returns a new instance of CompressingMarshaler.
+func NewCompressingMarshaler(marshaler Marshaler, config CompressionConfig) (CompressingMarshaler, error) {
+	if marshaler == nil {
+		return CompressingMarshaler{}, fmt.Errorf("marshaler is nil")
+	}
+
+	config.setDefaults()
+	err := config.validate()
+	if err != nil {
+		return CompressingMarshaler{}, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return CompressingMarshaler{
+		marshaler: marshaler,
+		config:    config,
+	}, nil
+}
+
+func (m CompressingMarshaler) Marshal(data interface{}) ([]byte, error) {
+	return m.MarshalContext(context.Background(), data)
+}
+
+func (m CompressingMarshaler) Unmarshal(data []byte, target interface{}) error {
+	return m.UnmarshalContext(context.Background(), data, target)
+}
+
+func (m CompressingMarshaler) MarshalContext(ctx context.Context, data interface{}) ([]byte, error) {
+	payload, err := MarshalContext(ctx, m.marshaler, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) < m.config.Threshold {
+		return payload, nil
+	}
+
+	compressed, err := m.compress(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error compressing payload: %w", err)
+	}
+
+	// Payloads that don't compress well are kept as they are.
+	if len(compressed) >= len(payload) {
+		return payload, nil
+	}
+
+	return compressed, nil
+}
+
+func (m CompressingMarshaler) UnmarshalContext(ctx context.Context, data []byte, target interface{}) error {
+	if !bytes.HasPrefix(data, compressionHeader) {
+		return UnmarshalContext(ctx, m.marshaler, data, target)
+	}
+
+	payload, err := m.decompress(data)
+	if err != nil {
+		return fmt.Errorf("error decompressing payload: %w", err)
+	}
+
+	return UnmarshalContext(ctx, m.marshaler, payload, target)
+}
+
+func (m CompressingMarshaler) compress(payload []byte) ([]byte, error) {
+	b := bytes.NewBuffer(make([]byte, 0, len(payload)/2))
+	b.Write(compressionHeader)
+	b.WriteByte(byte(m.config.Algorithm))
+
+	var w io.WriteCloser
+	var err error
+
+	switch m.config.Algorithm {
+	case CompressionGzip:
+		w, err = gzip.NewWriterLevel(b, m.config.Level)
+	case CompressionDeflate:
+		w, err = flate.NewWriter(b, m.config.Level)
+	default:
+		err = fmt.Errorf("unknown compression algorithm %q", m.config.Algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = w.Write(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func (m CompressingMarshaler) decompress(data []byte) ([]byte, error) {
+	if len(data) <= len(compressionHeader) {
+		return nil, fmt.Errorf("missing compression algorithm")
+	}
+
+	algorithm := CompressionAlgorithm(data[len(compressionHeader)])
+	compressed := bytes.NewReader(data[len(compressionHeader)+1:])
+
+	var r io.ReadCloser
+	var err error
+
+	switch algorithm {
+	case CompressionGzip:
+		r, err = gzip.NewReader(compressed)
+	case CompressionDeflate:
+		r = flate.NewReader(compressed)
+	default:
+		err = fmt.Errorf("unknown compression algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = r.Close()
+	}()
+
+	payload, err := io.ReadAll(io.LimitReader(r, m.config.MaxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(payload)) > m.config.MaxDecompressedSize {
+		return nil, fmt.Errorf("decompressed payload exceeds %d bytes", m.config.MaxDecompressedSize)
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,205 @@
+package transport_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+type document struct {
+	ID      string
+	Content string
+}
+
+func TestCompressingMarshaler(t *testing.T) {
+	large := document{
+		ID:      "1",
+		Content: strings.Repeat("lorem ipsum dolor sit amet ", 100),
+	}
+	small := document{
+		ID:      "2",
+		Content: "short",
+	}
+
+	testCases := []struct {
+		name      string
+		marshaler transport.Marshaler
+		config    transport.CompressionConfig
+	}{
+		{
+			name:      "json_gzip",
+			marshaler: transport.JSONMarshaler{},
+		},
+		{
+			name:      "json_deflate",
+			marshaler: transport.JSONMarshaler{},
+			config: transport.CompressionConfig{
+				Algorithm: transport.CompressionDeflate,
+			},
+		},
+		{
+			name:      "gob_gzip",
+			marshaler: transport.GOBMarshaler{},
+		},
+		{
+			name:      "gob_deflate_best_compression",
+			marshaler: transport.GOBMarshaler{},
+			config: transport.CompressionConfig{
+				Algorithm: transport.CompressionDeflate,
+				Level:     9,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			marshaler, err := transport.NewCompressingMarshaler(tc.marshaler, tc.config)
+			require.NoError(t, err)
+
+			payload, err := marshaler.Marshal(large)
+			require.NoError(t, err)
+
+			uncompressed, err := tc.marshaler.Marshal(large)
+			require.NoError(t, err)
+
+			assert.True(t, bytes.HasPrefix(payload, []byte("\xffEZ")), "large payload should be compressed")
+			assert.Less(t, len(payload), len(uncompressed))
+
+			decoded := document{}
+			err = marshaler.Unmarshal(payload, &decoded)
+			require.NoError(t, err)
+			assert.Equal(t, large, decoded)
+
+			payload, err = marshaler.Marshal(small)
+			require.NoError(t, err)
+
+			uncompressed, err = tc.marshaler.Marshal(small)
+			require.NoError(t, err)
+			assert.Equal(t, uncompressed, payload, "small payload should not be compressed")
+
+			decoded = document{}
+			err = marshaler.Unmarshal(payload, &decoded)
+			require.NoError(t, err)
+			assert.Equal(t, small, decoded)
+		})
+	}
+}
+
+func TestCompressingMarshaler_reads_any_algorithm(t *testing.T) {
+	doc := document{
+		ID:      "1",
+		Content: strings.Repeat("lorem ipsum dolor sit amet ", 100),
+	}
+
+	deflate, err := transport.NewCompressingMarshaler(transport.JSONMarshaler{}, transport.CompressionConfig{
+		Algorithm: transport.CompressionDeflate,
+	})
+	require.NoError(t, err)
+
+	gzip, err := transport.NewCompressingMarshaler(transport.JSONMarshaler{}, transport.CompressionConfig{})
+	require.NoError(t, err)
+
+	payload, err := deflate.Marshal(doc)
+	require.NoError(t, err)
+
+	decoded := document{}
+	err = gzip.Unmarshal(payload, &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, doc, decoded)
+}
+
+func TestCompressingMarshaler_incompressible_payload(t *testing.T) {
+	marshaler, err := transport.NewCompressingMarshaler(transport.JSONMarshaler{}, transport.CompressionConfig{
+		Threshold: 1,
+	})
+	require.NoError(t, err)
+
+	payload, err := marshaler.Marshal(document{ID: "1"})
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"ID":"1","Content":""}`, string(payload), "payload larger after compression should be kept")
+}
+
+func TestCompressingMarshaler_corrupted_payload(t *testing.T) {
+	marshaler, err := transport.NewCompressingMarshaler(transport.JSONMarshaler{}, transport.CompressionConfig{})
+	require.NoError(t, err)
+
+	err = marshaler.Unmarshal([]byte("\xffEZx..."), &document{})
+	assert.EqualError(t, err, "error decompressing payload: unknown compression algorithm 'x'")
+
+	err = marshaler.Unmarshal([]byte("\xffEZg..."), &document{})
+	assert.ErrorContains(t, err, "error decompressing payload")
+}
+
+func TestNewCompressingMarshaler_invalid(t *testing.T) {
+	_, err := transport.NewCompressingMarshaler(nil, transport.CompressionConfig{})
+	assert.EqualError(t, err, "marshaler is nil")
+
+	_, err = transport.NewCompressingMarshaler(transport.JSONMarshaler{}, transport.CompressionConfig{
+		Algorithm: 'z',
+	})
+	assert.EqualError(t, err, "invalid config: unknown compression algorithm 'z'")
+
+	_, err = transport.NewCompressingMarshaler(transport.JSONMarshaler{}, transport.CompressionConfig{
+		Level: 10,
+	})
+	assert.EqualError(t, err, "invalid config: invalid compression level 10")
+
+	_, err = transport.NewCompressingMarshaler(transport.JSONMarshaler{}, transport.CompressionConfig{
+		Threshold: -2,
+	})
+	assert.EqualError(t, err, "invalid config: threshold must not be negative, except CompressAllPayloads")
+
+	_, err = transport.NewCompressingMarshaler(transport.JSONMarshaler{}, transport.CompressionConfig{
+		MaxDecompressedSize: -1,
+	})
+	assert.EqualError(t, err, "invalid config: max decompressed size must not be negative")
+}
+
+func TestCompressingMarshaler_compress_all_payloads(t *testing.T) {
+	marshaler, err := transport.NewCompressingMarshaler(transport.JSONMarshaler{}, transport.CompressionConfig{
+		Threshold: transport.CompressAllPayloads,
+	})
+	require.NoError(t, err)
+
+	doc := document{
+		ID:      "1",
+		Content: strings.Repeat("a", 100),
+	}
+
+	payload, err := marshaler.Marshal(doc)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(payload, []byte("\xffEZ")), "payload below the default threshold should be compressed")
+
+	decoded := document{}
+	err = marshaler.Unmarshal(payload, &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, doc, decoded)
+}
+
+func TestCompressingMarshaler_max_decompressed_size(t *testing.T) {
+	doc := document{
+		ID:      "1",
+		Content: strings.Repeat("a", 10*1024),
+	}
+
+	marshaler, err := transport.NewCompressingMarshaler(transport.JSONMarshaler{}, transport.CompressionConfig{})
+	require.NoError(t, err)
+
+	payload, err := marshaler.Marshal(doc)
+	require.NoError(t, err)
+
+	limited, err := transport.NewCompressingMarshaler(transport.JSONMarshaler{}, transport.CompressionConfig{
+		MaxDecompressedSize: 1024,
+	})
+	require.NoError(t, err)
+
+	err = limited.Unmarshal(payload, &document{})
+	assert.EqualError(t, err, "error decompressing payload: decompressed payload exceeds 1024 bytes")
+}
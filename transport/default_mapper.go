@@ -21,22 +21,77 @@ type Event[T any] interface {
 // mapping between stream- and transport- layer models.
 type DefaultMapper[T any] struct {
 	supported map[string]Event[T]
+	err       error
+}
+
+// NewValidatedDefaultMapper returns a new instance of a DefaultMapper,
+// or an error if any transport event is nil or not a pointer, or event names are duplicated.
+// Transport events can be typed nil pointers, like (*CreatedTransport)(nil).
+func NewValidatedDefaultMapper[T any](
+	supportedEvents []Event[T],
+) (DefaultMapper[T], error) {
+	m := newDefaultMapper(supportedEvents)
+	if m.err != nil {
+		return DefaultMapper[T]{}, m.err
+	}
+
+	return m, nil
 }
 
 // NewDefaultMapper returns a new instance of a DefaultMapper.
+// Transport events must be pointers.
+// Nil and non-pointer events and duplicated event names are reported by Validate,
+// which the event stores call when they're created. Use NewValidatedDefaultMapper to get them right away.
 func NewDefaultMapper[T any](
 	supportedEvents []Event[T],
+) DefaultMapper[T] {
+	return newDefaultMapper(supportedEvents)
+}
+
+func newDefaultMapper[T any](
+	supportedEvents []Event[T],
 ) DefaultMapper[T] {
 	supported := map[string]Event[T]{}
-	for _, e := range supportedEvents {
-		supported[e.StreamEventName()] = e
+	problems := registrationProblems{}
+
+	for i, e := range supportedEvents {
+		if e == nil {
+			problems.add("event at index %d is nil", i)
+			continue
+		}
+
+		if reflect.TypeOf(e).Kind() != reflect.Ptr {
+			problems.add("transport event %T is not a pointer", e)
+			continue
+		}
+
+		e = prototype(e)
+
+		name := e.StreamEventName()
+		if existing, ok := supported[name]; ok {
+			problems.add("event name '%s' is registered by both %T and %T", name, existing, e)
+			continue
+		}
+
+		supported[name] = e
 	}
 
 	return DefaultMapper[T]{
 		supported: supported,
+		err:       problems.err(),
 	}
 }
 
+// Validate returns an error if the supported events are invalid.
+func (m DefaultMapper[T]) Validate() error {
+	return m.err
+}
+
+// SupportedEvents returns the sorted names of supported events.
+func (m DefaultMapper[T]) SupportedEvents() []string {
+	return sortedNames(m.supported)
+}
+
 func (m DefaultMapper[T]) New(eventName string) (any, error) {
 	e, err := m.eventFor(eventName)
 	if err != nil {
@@ -0,0 +1,243 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxKeyIDLength is the maximum length of the key ID, as it's stored in a single byte.
+const maxKeyIDLength = 255
+
+// encryptionHeader starts the encrypted payloads and is followed by the key ID length, the key ID,
+// the nonce and the ciphertext. It can't start a JSON document or a GOB stream,
+// so unencrypted payloads are told apart from the encrypted ones.
+var encryptionHeader = []byte("\xffEE")
+
+// ErrUnencryptedPayload is returned by EncryptingMarshaler for the payloads without the encryption header,
+// unless EncryptionConfig.AllowPlaintext is set.
+var ErrUnencryptedPayload = errors.New("payload is not encrypted")
+
+type EncryptionConfig struct {
+	// AllowPlaintext reads unencrypted payloads as they are, so the encryption can be enabled
+	// for existing events. Use it only until the old payloads are encrypted with Reencrypt,
+	// as anyone who can write the payloads can replace them with unencrypted ones then.
+	AllowPlaintext bool
+}
+
+// EncryptingMarshaler decorates a Marshaler, encrypting the whole payloads with AES-GCM.
+// Payloads are encrypted with the current key of the KeyRing, and keep the ID of the key,
+// so they are decrypted after the key rotation. Use Reencrypt to encrypt old payloads
+// with the current key, e.g. with eventstore.SQLStore.RewritePayloads.
+//
+// The encryption protects the content of the payloads and detects their changes.
+// Only the header with the key ID is authenticated with them, as the marshaler doesn't know
+// the event the payload belongs to, so an encrypted payload can be moved to another event or stream
+// undetected. Use eventstore.SchemaConfig.HashChain with eventstore.SQLConfig.HashChainKey
+// to detect such changes.
+//
+// Unencrypted payloads are rejected with ErrUnencryptedPayload, unless EncryptionConfig.AllowPlaintext is set.
+// EncryptingMarshaler implements ContextMarshaler and passes the context to the wrapped marshaler.
+// Encrypted payloads aren't valid JSON, so use SchemaConfig.BinaryPayload with Postgres.
+type EncryptingMarshaler struct {
+	marshaler Marshaler
+	keyRing   KeyRing
+	config    EncryptionConfig
+}
+
+// NewEncryptingMarshaler returns a new instance of EncryptingMarshaler rejecting unencrypted payloads.
+func NewEncryptingMarshaler(marshaler Marshaler, keyRing KeyRing) (EncryptingMarshaler, error) {
+	return NewEncryptingMarshalerWithConfig(marshaler, keyRing, EncryptionConfig{})
+}
+
+// NewEncryptingMarshalerWithConfig returns a new instance of EncryptingMarshaler with the config.
+func NewEncryptingMarshalerWithConfig(
+	marshaler Marshaler,
+	keyRing KeyRing,
+	config EncryptionConfig,
+) (EncryptingMarshaler, error) {
+	if marshaler == nil {
+		return EncryptingMarshaler{}, errors.New("marshaler is nil")
+	}
+	if keyRing == nil {
+		return EncryptingMarshaler{}, errors.New("key ring is nil")
+	}
+
+	return EncryptingMarshaler{
+		marshaler: marshaler,
+		keyRing:   keyRing,
+		config:    config,
+	}, nil
+}
+
+func (m EncryptingMarshaler) Marshal(data interface{}) ([]byte, error) {
+	return m.MarshalContext(context.Background(), data)
+}
+
+func (m EncryptingMarshaler) Unmarshal(data []byte, target interface{}) error {
+	return m.UnmarshalContext(context.Background(), data, target)
+}
+
+func (m EncryptingMarshaler) MarshalContext(ctx context.Context, data interface{}) ([]byte, error) {
+	payload, err := MarshalContext(ctx, m.marshaler, data)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := m.encrypt(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting payload: %w", err)
+	}
+
+	return encrypted, nil
+}
+
+func (m EncryptingMarshaler) UnmarshalContext(ctx context.Context, data []byte, target interface{}) error {
+	payload, err := m.decrypt(data)
+	if err != nil {
+		return fmt.Errorf("error decrypting payload: %w", err)
+	}
+
+	return UnmarshalContext(ctx, m.marshaler, payload, target)
+}
+
+// Reencrypt encrypts the payload with the current key.
+// It returns false if the payload is already encrypted with the current key.
+// Unencrypted payloads are encrypted, regardless of EncryptionConfig.AllowPlaintext.
+//
+// References to the payloads offloaded by ClaimCheckMarshaler are kept,
+// use ClaimCheckMarshaler.RewriteBlobs to re-encrypt the offloaded payloads.
+func (m EncryptingMarshaler) Reencrypt(data []byte) ([]byte, bool, error) {
+	if _, ok := BlobKey(data); ok {
+		return nil, false, nil
+	}
+
+	currentKeyID, _, err := m.keyRing.CurrentKey()
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting current key: %w", err)
+	}
+
+	keyID, encrypted, err := parseKeyID(data)
+	if err != nil {
+		return nil, false, err
+	}
+	if encrypted && keyID == currentKeyID {
+		return nil, false, nil
+	}
+
+	payload := data
+	if encrypted {
+		payload, err = m.decrypt(data)
+		if err != nil {
+			return nil, false, fmt.Errorf("error decrypting payload: %w", err)
+		}
+	}
+
+	reencrypted, err := m.encrypt(payload)
+	if err != nil {
+		return nil, false, fmt.Errorf("error encrypting payload: %w", err)
+	}
+
+	return reencrypted, true, nil
+}
+
+func (m EncryptingMarshaler) encrypt(payload []byte) ([]byte, error) {
+	keyID, key, err := m.keyRing.CurrentKey()
+	if err != nil {
+		return nil, fmt.Errorf("error getting current key: %w", err)
+	}
+	if keyID == "" || len(keyID) > maxKeyIDLength {
+		return nil, fmt.Errorf("invalid key ID '%s'", keyID)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(encryptionHeader)+1+len(keyID))
+	header = append(header, encryptionHeader...)
+	header = append(header, byte(len(keyID)))
+	header = append(header, keyID...)
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+
+	encrypted := make([]byte, 0, len(header)+len(nonce)+len(payload)+aead.Overhead())
+	encrypted = append(encrypted, header...)
+	encrypted = append(encrypted, nonce...)
+
+	// The header is authenticated, so the key ID can't be swapped.
+	return aead.Seal(encrypted, nonce, payload, header), nil
+}
+
+func (m EncryptingMarshaler) decrypt(data []byte) ([]byte, error) {
+	keyID, encrypted, err := parseKeyID(data)
+	if err != nil {
+		return nil, err
+	}
+	if !encrypted {
+		if !m.config.AllowPlaintext {
+			return nil, ErrUnencryptedPayload
+		}
+		return data, nil
+	}
+
+	key, err := m.keyRing.Key(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting key: %w", err)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	headerLength := len(encryptionHeader) + 1 + len(keyID)
+	if len(data) < headerLength+aead.NonceSize() {
+		return nil, errors.New("missing nonce")
+	}
+
+	header := data[:headerLength]
+	nonce := data[headerLength : headerLength+aead.NonceSize()]
+	ciphertext := data[headerLength+aead.NonceSize():]
+
+	return aead.Open(nil, nonce, ciphertext, header)
+}
+
+// parseKeyID returns the ID of the key the payload is encrypted with,
+// or false if the payload is not encrypted.
+func parseKeyID(data []byte) (string, bool, error) {
+	if !bytes.HasPrefix(data, encryptionHeader) {
+		return "", false, nil
+	}
+
+	rest := data[len(encryptionHeader):]
+	if len(rest) == 0 {
+		return "", false, errors.New("missing key ID")
+	}
+
+	keyIDLength := int(rest[0])
+	if keyIDLength == 0 || len(rest) < 1+keyIDLength {
+		return "", false, errors.New("invalid key ID")
+	}
+
+	return string(rest[1 : 1+keyIDLength]), true, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,164 @@
+package transport_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+var (
+	keyA = bytes.Repeat([]byte("a"), 32)
+	keyB = bytes.Repeat([]byte("b"), 16)
+)
+
+func TestEncryptingMarshaler(t *testing.T) {
+	doc := document{ID: "1", Content: "secret"}
+
+	for _, m := range []transport.Marshaler{transport.JSONMarshaler{}, transport.GOBMarshaler{}} {
+		keyRing, err := transport.NewMemoryKeyRing("a", keyA)
+		require.NoError(t, err)
+
+		marshaler, err := transport.NewEncryptingMarshaler(m, keyRing)
+		require.NoError(t, err)
+
+		payload, err := marshaler.Marshal(doc)
+		require.NoError(t, err)
+
+		assert.True(t, bytes.HasPrefix(payload, []byte("\xffEE\x01a")))
+		assert.NotContains(t, string(payload), "secret")
+
+		decoded := document{}
+		err = marshaler.Unmarshal(payload, &decoded)
+		require.NoError(t, err)
+		assert.Equal(t, doc, decoded)
+	}
+}
+
+func TestEncryptingMarshaler_key_rotation(t *testing.T) {
+	doc := document{ID: "1", Content: "secret"}
+
+	keyRing, err := transport.NewMemoryKeyRing("a", keyA)
+	require.NoError(t, err)
+
+	marshaler, err := transport.NewEncryptingMarshaler(transport.JSONMarshaler{}, keyRing)
+	require.NoError(t, err)
+
+	oldPayload, err := marshaler.Marshal(doc)
+	require.NoError(t, err)
+
+	err = keyRing.Rotate("b", keyB)
+	require.NoError(t, err)
+
+	newPayload, err := marshaler.Marshal(doc)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(newPayload, []byte("\xffEE\x01b")))
+
+	for _, payload := range [][]byte{oldPayload, newPayload} {
+		decoded := document{}
+		err = marshaler.Unmarshal(payload, &decoded)
+		require.NoError(t, err)
+		assert.Equal(t, doc, decoded)
+	}
+
+	reencrypted, ok, err := marshaler.Reencrypt(oldPayload)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.True(t, bytes.HasPrefix(reencrypted, []byte("\xffEE\x01b")))
+
+	_, ok, err = marshaler.Reencrypt(newPayload)
+	require.NoError(t, err)
+	assert.False(t, ok, "payload encrypted with the current key should not be reencrypted")
+
+	onlyB, err := transport.NewMemoryKeyRing("b", keyB)
+	require.NoError(t, err)
+
+	onlyBMarshaler, err := transport.NewEncryptingMarshaler(transport.JSONMarshaler{}, onlyB)
+	require.NoError(t, err)
+
+	decoded := document{}
+	err = onlyBMarshaler.Unmarshal(reencrypted, &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, doc, decoded)
+
+	err = onlyBMarshaler.Unmarshal(oldPayload, &decoded)
+	assert.ErrorIs(t, err, transport.ErrKeyNotFound)
+}
+
+func TestEncryptingMarshaler_unencrypted_payload(t *testing.T) {
+	keyRing, err := transport.NewMemoryKeyRing("a", keyA)
+	require.NoError(t, err)
+
+	marshaler, err := transport.NewEncryptingMarshaler(transport.JSONMarshaler{}, keyRing)
+	require.NoError(t, err)
+
+	plain := []byte(`{"ID":"1","Content":"plain"}`)
+
+	err = marshaler.Unmarshal(plain, &document{})
+	assert.ErrorIs(t, err, transport.ErrUnencryptedPayload, "unencrypted payloads should be rejected by default")
+
+	migrating, err := transport.NewEncryptingMarshalerWithConfig(transport.JSONMarshaler{}, keyRing, transport.EncryptionConfig{
+		AllowPlaintext: true,
+	})
+	require.NoError(t, err)
+
+	decoded := document{}
+	err = migrating.Unmarshal(plain, &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, document{ID: "1", Content: "plain"}, decoded)
+
+	encrypted, ok, err := marshaler.Reencrypt(plain)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	decoded = document{}
+	err = marshaler.Unmarshal(encrypted, &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, document{ID: "1", Content: "plain"}, decoded)
+}
+
+func TestEncryptingMarshaler_tampered_payload(t *testing.T) {
+	keyRing, err := transport.NewMemoryKeyRing("a", keyA)
+	require.NoError(t, err)
+	err = keyRing.AddKey("b", keyA)
+	require.NoError(t, err)
+
+	marshaler, err := transport.NewEncryptingMarshaler(transport.JSONMarshaler{}, keyRing)
+	require.NoError(t, err)
+
+	payload, err := marshaler.Marshal(document{ID: "1"})
+	require.NoError(t, err)
+
+	tampered := append([]byte(nil), payload...)
+	tampered[len(tampered)-1] ^= 1
+	err = marshaler.Unmarshal(tampered, &document{})
+	assert.ErrorContains(t, err, "error decrypting payload")
+
+	swappedKeyID := append([]byte(nil), payload...)
+	swappedKeyID[4] = 'b'
+	err = marshaler.Unmarshal(swappedKeyID, &document{})
+	assert.ErrorContains(t, err, "error decrypting payload", "key ID should be authenticated")
+
+	err = marshaler.Unmarshal([]byte("\xffEE\x05a"), &document{})
+	assert.EqualError(t, err, "error decrypting payload: invalid key ID")
+}
+
+func TestMemoryKeyRing_invalid(t *testing.T) {
+	_, err := transport.NewMemoryKeyRing("", keyA)
+	assert.EqualError(t, err, "empty key ID")
+
+	_, err = transport.NewMemoryKeyRing("a", []byte("short"))
+	assert.EqualError(t, err, "invalid key size 5, must be 16, 24 or 32 bytes")
+
+	keyRing, err := transport.NewMemoryKeyRing("a", keyA)
+	require.NoError(t, err)
+
+	err = keyRing.AddKey("a", keyB)
+	assert.EqualError(t, err, "key 'a' is already added with a different value")
+
+	_, err = transport.NewEncryptingMarshaler(transport.JSONMarshaler{}, nil)
+	assert.EqualError(t, err, "key ring is nil")
+}
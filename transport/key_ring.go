@@ -0,0 +1,111 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrKeyNotFound is returned by KeyRing for unknown key IDs.
+var ErrKeyNotFound = errors.New("key not found")
+
+// KeyRing provides the keys used by EncryptingMarshaler.
+type KeyRing interface {
+	// CurrentKey returns the ID and the key used to encrypt new payloads.
+	CurrentKey() (keyID string, key []byte, err error)
+
+	// Key returns the key of the ID, used to decrypt the payloads encrypted with it.
+	Key(keyID string) ([]byte, error)
+}
+
+// MemoryKeyRing is a KeyRing keeping the keys in memory.
+// After the rotation, the previous keys are kept to decrypt the payloads encrypted with them.
+type MemoryKeyRing struct {
+	lock         sync.RWMutex
+	currentKeyID string
+	keys         map[string][]byte
+}
+
+// NewMemoryKeyRing returns a new instance of MemoryKeyRing with the current key.
+// Keys must be 16, 24 or 32 bytes long, to select AES-128, AES-192 or AES-256.
+func NewMemoryKeyRing(keyID string, key []byte) (*MemoryKeyRing, error) {
+	r := &MemoryKeyRing{
+		keys: map[string][]byte{},
+	}
+
+	err := r.Rotate(keyID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// AddKey adds the key used only to decrypt payloads, like a key of the previous rotation.
+func (r *MemoryKeyRing) AddKey(keyID string, key []byte) error {
+	err := validateKey(keyID, key)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if existing, ok := r.keys[keyID]; ok && string(existing) != string(key) {
+		return fmt.Errorf("key '%s' is already added with a different value", keyID)
+	}
+
+	r.keys[keyID] = append([]byte(nil), key...)
+
+	return nil
+}
+
+// Rotate adds the key and makes it the current one.
+func (r *MemoryKeyRing) Rotate(keyID string, key []byte) error {
+	err := r.AddKey(keyID, key)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.currentKeyID = keyID
+
+	return nil
+}
+
+func (r *MemoryKeyRing) CurrentKey() (string, []byte, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.currentKeyID, r.keys[r.currentKeyID], nil
+}
+
+func (r *MemoryKeyRing) Key(keyID string) ([]byte, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	key, ok := r.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrKeyNotFound, keyID)
+	}
+
+	return key, nil
+}
+
+func validateKey(keyID string, key []byte) error {
+	if keyID == "" {
+		return errors.New("empty key ID")
+	}
+	if len(keyID) > maxKeyIDLength {
+		return fmt.Errorf("key ID must not be longer than %d bytes", maxKeyIDLength)
+	}
+
+	switch len(key) {
+	case 16, 24, 32:
+		return nil
+	default:
+		return fmt.Errorf("invalid key size %d, must be 16, 24 or 32 bytes", len(key))
+	}
+}
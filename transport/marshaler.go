@@ -2,6 +2,7 @@ package transport
 
 import (
 	"bytes"
+	"context"
 	"encoding/gob"
 	"encoding/json"
 )
@@ -11,6 +12,32 @@ type Marshaler interface {
 	Unmarshal(bytes []byte, target interface{}) error
 }
 
+// ContextMarshaler is a Marshaler receiving the context of the event store's operation,
+// e.g. to trace marshaling. The event stores call MarshalContext and UnmarshalContext if implemented.
+type ContextMarshaler interface {
+	Marshaler
+	MarshalContext(ctx context.Context, data interface{}) ([]byte, error)
+	UnmarshalContext(ctx context.Context, bytes []byte, target interface{}) error
+}
+
+// MarshalContext marshals the data with MarshalContext if the marshaler implements ContextMarshaler,
+// or with Marshal otherwise.
+func MarshalContext(ctx context.Context, marshaler Marshaler, data interface{}) ([]byte, error) {
+	if m, ok := marshaler.(ContextMarshaler); ok {
+		return m.MarshalContext(ctx, data)
+	}
+	return marshaler.Marshal(data)
+}
+
+// UnmarshalContext unmarshals the bytes with UnmarshalContext if the marshaler implements ContextMarshaler,
+// or with Unmarshal otherwise.
+func UnmarshalContext(ctx context.Context, marshaler Marshaler, bytes []byte, target interface{}) error {
+	if m, ok := marshaler.(ContextMarshaler); ok {
+		return m.UnmarshalContext(ctx, bytes, target)
+	}
+	return marshaler.Unmarshal(bytes, target)
+}
+
 type JSONMarshaler struct{}
 
 func (JSONMarshaler) Marshal(data interface{}) ([]byte, error) {
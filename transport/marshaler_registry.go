@@ -0,0 +1,111 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Content types of the built-in marshalers.
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeGOB     = "application/x-gob"
+	ContentTypeCBOR    = "application/cbor"
+	ContentTypeMsgPack = "application/msgpack"
+)
+
+// ErrUnknownContentType is returned by MarshalerRegistry for content types without a marshaler.
+var ErrUnknownContentType = errors.New("unknown content type")
+
+type MarshalerRegistryConfig struct {
+	// Marshalers are the marshalers by the content type of the payloads.
+	Marshalers map[string]Marshaler
+
+	// DefaultContentType is the content type of new payloads.
+	DefaultContentType string
+
+	// LegacyContentType is the content type of payloads stored without one,
+	// like the ones saved before the content type was stored. Defaults to DefaultContentType.
+	LegacyContentType string
+}
+
+func (c *MarshalerRegistryConfig) setDefaults() {
+	if c.LegacyContentType == "" {
+		c.LegacyContentType = c.DefaultContentType
+	}
+}
+
+func (c MarshalerRegistryConfig) validate() error {
+	if len(c.Marshalers) == 0 {
+		return errors.New("no marshalers")
+	}
+	for contentType, marshaler := range c.Marshalers {
+		if contentType == "" {
+			return errors.New("empty content type")
+		}
+		if marshaler == nil {
+			return fmt.Errorf("marshaler of %s is nil", contentType)
+		}
+	}
+	if c.DefaultContentType == "" {
+		return errors.New("default content type is empty")
+	}
+	if _, ok := c.Marshalers[c.DefaultContentType]; !ok {
+		return fmt.Errorf("no marshaler for the default content type %s", c.DefaultContentType)
+	}
+	if _, ok := c.Marshalers[c.LegacyContentType]; !ok {
+		return fmt.Errorf("no marshaler for the legacy content type %s", c.LegacyContentType)
+	}
+	return nil
+}
+
+// MarshalerRegistry keeps the marshalers by content type, so payloads stored
+// in different formats can be read, while new payloads are written in the default one.
+type MarshalerRegistry struct {
+	config MarshalerRegistryConfig
+}
+
+// NewMarshalerRegistry returns a new instance of MarshalerRegistry.
+func NewMarshalerRegistry(config MarshalerRegistryConfig) (*MarshalerRegistry, error) {
+	config.setDefaults()
+	err := config.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	marshalers := make(map[string]Marshaler, len(config.Marshalers))
+	for contentType, marshaler := range config.Marshalers {
+		marshalers[contentType] = marshaler
+	}
+	config.Marshalers = marshalers
+
+	return &MarshalerRegistry{
+		config: config,
+	}, nil
+}
+
+// Default returns the marshaler of new payloads and its content type.
+func (r *MarshalerRegistry) Default() (string, Marshaler) {
+	return r.config.DefaultContentType, r.config.Marshalers[r.config.DefaultContentType]
+}
+
+// Resolve returns the content type the payloads stored with contentType are read with.
+// The empty content type resolves to the legacy content type.
+func (r *MarshalerRegistry) Resolve(contentType string) string {
+	if contentType == "" {
+		return r.config.LegacyContentType
+	}
+	return contentType
+}
+
+// Marshaler returns the marshaler of the content type.
+// The empty content type returns the marshaler of the legacy content type.
+func (r *MarshalerRegistry) Marshaler(contentType string) (Marshaler, error) {
+	contentType = r.Resolve(contentType)
+
+	marshaler, ok := r.config.Marshalers[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownContentType, contentType)
+	}
+
+	return marshaler, nil
+}
@@ -0,0 +1,109 @@
+package transport_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+func TestMarshalerRegistry(t *testing.T) {
+	registry, err := transport.NewMarshalerRegistry(transport.MarshalerRegistryConfig{
+		Marshalers: map[string]transport.Marshaler{
+			transport.ContentTypeJSON: transport.JSONMarshaler{},
+			transport.ContentTypeGOB:  transport.GOBMarshaler{},
+		},
+		DefaultContentType: transport.ContentTypeJSON,
+		LegacyContentType:  transport.ContentTypeGOB,
+	})
+	require.NoError(t, err)
+
+	contentType, marshaler := registry.Default()
+	assert.Equal(t, transport.ContentTypeJSON, contentType)
+	assert.Equal(t, transport.JSONMarshaler{}, marshaler)
+
+	marshaler, err = registry.Marshaler(transport.ContentTypeJSON)
+	require.NoError(t, err)
+	assert.Equal(t, transport.JSONMarshaler{}, marshaler)
+
+	marshaler, err = registry.Marshaler("")
+	require.NoError(t, err)
+	assert.Equal(t, transport.GOBMarshaler{}, marshaler, "empty content type should use the legacy marshaler")
+
+	assert.Equal(t, transport.ContentTypeGOB, registry.Resolve(""))
+	assert.Equal(t, transport.ContentTypeJSON, registry.Resolve(transport.ContentTypeJSON))
+
+	_, err = registry.Marshaler(transport.ContentTypeCBOR)
+	assert.ErrorIs(t, err, transport.ErrUnknownContentType)
+	assert.EqualError(t, err, "unknown content type: application/cbor")
+}
+
+func TestMarshalerRegistry_legacy_defaults_to_default(t *testing.T) {
+	registry, err := transport.NewMarshalerRegistry(transport.MarshalerRegistryConfig{
+		Marshalers: map[string]transport.Marshaler{
+			transport.ContentTypeCBOR: transport.CBORMarshaler{},
+		},
+		DefaultContentType: transport.ContentTypeCBOR,
+	})
+	require.NoError(t, err)
+
+	marshaler, err := registry.Marshaler("")
+	require.NoError(t, err)
+	assert.Equal(t, transport.CBORMarshaler{}, marshaler)
+}
+
+func TestNewMarshalerRegistry_invalid(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        transport.MarshalerRegistryConfig
+		expectedError string
+	}{
+		{
+			name:          "no_marshalers",
+			config:        transport.MarshalerRegistryConfig{DefaultContentType: transport.ContentTypeJSON},
+			expectedError: "invalid config: no marshalers",
+		},
+		{
+			name: "nil_marshaler",
+			config: transport.MarshalerRegistryConfig{
+				Marshalers:         map[string]transport.Marshaler{transport.ContentTypeJSON: nil},
+				DefaultContentType: transport.ContentTypeJSON,
+			},
+			expectedError: "invalid config: marshaler of application/json is nil",
+		},
+		{
+			name: "empty_default",
+			config: transport.MarshalerRegistryConfig{
+				Marshalers: map[string]transport.Marshaler{transport.ContentTypeJSON: transport.JSONMarshaler{}},
+			},
+			expectedError: "invalid config: default content type is empty",
+		},
+		{
+			name: "unknown_default",
+			config: transport.MarshalerRegistryConfig{
+				Marshalers:         map[string]transport.Marshaler{transport.ContentTypeJSON: transport.JSONMarshaler{}},
+				DefaultContentType: transport.ContentTypeGOB,
+			},
+			expectedError: "invalid config: no marshaler for the default content type application/x-gob",
+		},
+		{
+			name: "unknown_legacy",
+			config: transport.MarshalerRegistryConfig{
+				Marshalers:         map[string]transport.Marshaler{transport.ContentTypeJSON: transport.JSONMarshaler{}},
+				DefaultContentType: transport.ContentTypeJSON,
+				LegacyContentType:  transport.ContentTypeGOB,
+			},
+			expectedError: "invalid config: no marshaler for the legacy content type application/x-gob",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := transport.NewMarshalerRegistry(tc.config)
+			assert.EqualError(t, err, tc.expectedError)
+		})
+	}
+}
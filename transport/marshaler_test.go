@@ -0,0 +1,88 @@
+package transport_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+type contextKey struct{}
+
+// contextRecorder records the context values it's called with.
+type contextRecorder struct {
+	transport.JSONMarshaler
+	values *[]any
+}
+
+func (m contextRecorder) MarshalContext(ctx context.Context, data interface{}) ([]byte, error) {
+	*m.values = append(*m.values, ctx.Value(contextKey{}))
+	return m.Marshal(data)
+}
+
+func (m contextRecorder) UnmarshalContext(ctx context.Context, bytes []byte, target interface{}) error {
+	*m.values = append(*m.values, ctx.Value(contextKey{}))
+	return m.Unmarshal(bytes, target)
+}
+
+func TestDecorators_pass_context(t *testing.T) {
+	testCases := []struct {
+		name     string
+		decorate func(t *testing.T, marshaler transport.Marshaler) transport.Marshaler
+	}{
+		{
+			name: "compressing",
+			decorate: func(t *testing.T, marshaler transport.Marshaler) transport.Marshaler {
+				m, err := transport.NewCompressingMarshaler(marshaler, transport.CompressionConfig{
+					Threshold: transport.CompressAllPayloads,
+				})
+				require.NoError(t, err)
+				return m
+			},
+		},
+		{
+			name: "encrypting",
+			decorate: func(t *testing.T, marshaler transport.Marshaler) transport.Marshaler {
+				keyRing, err := transport.NewMemoryKeyRing("key", bytes.Repeat([]byte("k"), 32))
+				require.NoError(t, err)
+
+				m, err := transport.NewEncryptingMarshaler(marshaler, keyRing)
+				require.NoError(t, err)
+				return m
+			},
+		},
+		{
+			name: "claim_check",
+			decorate: func(t *testing.T, marshaler transport.Marshaler) transport.Marshaler {
+				m, err := transport.NewClaimCheckMarshaler(marshaler, transport.NewMemoryBlobStore(), transport.ClaimCheckConfig{
+					Threshold: 1,
+				})
+				require.NoError(t, err)
+				return m
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var values []any
+			marshaler := tc.decorate(t, contextRecorder{values: &values})
+
+			ctx := context.WithValue(context.Background(), contextKey{}, tc.name)
+
+			payload, err := transport.MarshalContext(ctx, marshaler, document{ID: "1", Content: "content"})
+			require.NoError(t, err)
+
+			var target document
+			err = transport.UnmarshalContext(ctx, marshaler, payload, &target)
+			require.NoError(t, err)
+			assert.Equal(t, "content", target.Content)
+
+			assert.Equal(t, []any{tc.name, tc.name}, values)
+		})
+	}
+}
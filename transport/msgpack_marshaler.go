@@ -0,0 +1,47 @@
+package transport
+
+import (
+	"bytes"
+	"reflect"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgPackMarshaler encodes payloads with MessagePack.
+// Struct fields use the `msgpack` tags, falling back to the `json` tags.
+//
+// Map keys are sorted, so payloads are encoded deterministically.
+// Time values are encoded with the MessagePack timestamp extension and decoded in UTC,
+// so loaded events don't depend on the time zone of the machine.
+//
+// Payloads are binary, so use SchemaConfig.BinaryPayload with Postgres.
+type MsgPackMarshaler struct{}
+
+func (MsgPackMarshaler) Marshal(data interface{}) ([]byte, error) {
+	b := bytes.NewBuffer([]byte{})
+
+	e := msgpack.NewEncoder(b)
+	e.SetSortMapKeys(true)
+	e.SetCustomStructTag("json")
+
+	err := e.Encode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func (MsgPackMarshaler) Unmarshal(data []byte, target interface{}) error {
+	d := msgpack.NewDecoder(bytes.NewReader(data))
+	d.SetCustomStructTag("json")
+
+	err := d.Decode(target)
+	if err != nil {
+		return err
+	}
+
+	timesToUTC(reflect.ValueOf(target))
+
+	return nil
+}
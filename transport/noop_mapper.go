@@ -12,22 +12,73 @@ import (
 // The mapper will use provided original stream events as transport models.
 type NoOpMapper[T any] struct {
 	supported map[string]esja.Event[T]
+	err       error
+}
+
+// NewValidatedNoOpMapper returns a new instance of NoOpMapper,
+// or an error if any event is nil or event names are duplicated.
+//
+// Events can be values or pointers, including typed nil pointers like (*Created)(nil).
+// Events of pointer types are loaded as pointers.
+func NewValidatedNoOpMapper[T any](
+	supportedEvents []esja.Event[T],
+) (NoOpMapper[T], error) {
+	m := newNoOpMapper(supportedEvents)
+	if m.err != nil {
+		return NoOpMapper[T]{}, m.err
+	}
+
+	return m, nil
 }
 
 // NewNoOpMapper returns a new instance of NoOpMapper.
+// Nil events and duplicated event names are reported by Validate,
+// which the event stores call when they're created. Use NewValidatedNoOpMapper to get them right away.
 func NewNoOpMapper[T any](
 	supportedEvents []esja.Event[T],
+) NoOpMapper[T] {
+	return newNoOpMapper(supportedEvents)
+}
+
+func newNoOpMapper[T any](
+	supportedEvents []esja.Event[T],
 ) NoOpMapper[T] {
 	supported := make(map[string]esja.Event[T])
-	for _, e := range supportedEvents {
-		supported[e.EventName()] = e
+	problems := registrationProblems{}
+
+	for i, e := range supportedEvents {
+		if e == nil {
+			problems.add("event at index %d is nil", i)
+			continue
+		}
+
+		e = prototype(e)
+
+		name := e.EventName()
+		if existing, ok := supported[name]; ok {
+			problems.add("event name '%s' is registered by both %T and %T", name, existing, e)
+			continue
+		}
+
+		supported[name] = e
 	}
 
 	return NoOpMapper[T]{
 		supported: supported,
+		err:       problems.err(),
 	}
 }
 
+// Validate returns an error if the supported events are invalid.
+func (m NoOpMapper[T]) Validate() error {
+	return m.err
+}
+
+// SupportedEvents returns the sorted names of supported events.
+func (m NoOpMapper[T]) SupportedEvents() []string {
+	return sortedNames(m.supported)
+}
+
 func (m NoOpMapper[T]) New(eventName string) (any, error) {
 	e, ok := m.supported[eventName]
 	if !ok {
@@ -58,6 +109,13 @@ func (m NoOpMapper[T]) FromTransport(
 	return event, nil
 }
 
+// newInstance returns a pointer to a new value of the event's type.
+// For pointer events, it returns a pointer to a new value of the type they point to.
 func newInstance(e any) any {
-	return reflect.New(reflect.TypeOf(e)).Interface()
+	t := reflect.TypeOf(e)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return reflect.New(t).Interface()
 }
@@ -0,0 +1,248 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ThreeDotsLabs/esja"
+)
+
+// MappedEvent is a pointer to the transport model TE of the event E.
+// The mapping methods are typed, so the compiler checks that
+// the transport model corresponds to the event it's registered for.
+type MappedEvent[T any, E esja.Event[T], TE any] interface {
+	*TE
+	FromEvent(event E)
+	ToEvent() E
+}
+
+// PointerEvent is the event E used as a pointer.
+type PointerEvent[T any, E any] interface {
+	*E
+	esja.Event[T]
+}
+
+// Registration is a single event registered in the Registry.
+// It's created with Register, RegisterPointer or RegisterMapped.
+type Registration[T any] struct {
+	eventName     string
+	newTransport  func() any
+	toTransport   func(event esja.Event[T]) (any, bool)
+	fromTransport func(transportEvent any) (esja.Event[T], bool)
+	err           error
+}
+
+// Register registers the event E used as its own transport model, like in NoOpMapper.
+// The event name is taken from the zero value of E, so use RegisterPointer for pointer events.
+func Register[T any, E esja.Event[T]]() Registration[T] {
+	var event E
+
+	eventName, err := zeroEventName[T](event)
+
+	return Registration[T]{
+		eventName: eventName,
+		newTransport: func() any {
+			return new(E)
+		},
+		toTransport: func(event esja.Event[T]) (any, bool) {
+			e, ok := event.(E)
+			return e, ok
+		},
+		fromTransport: func(transportEvent any) (esja.Event[T], bool) {
+			e, ok := transportEvent.(*E)
+			if !ok {
+				return nil, false
+			}
+			return *e, true
+		},
+		err: err,
+	}
+}
+
+// RegisterPointer registers the event E used as a pointer and as its own transport model.
+// Events are loaded as pointers to new values of E, so they're never nil.
+//
+// The pointer type of E is inferred, so it's enough to call it as RegisterPointer[Entity, Event]().
+func RegisterPointer[T any, E any, PE PointerEvent[T, E]]() Registration[T] {
+	return Registration[T]{
+		eventName: PE(new(E)).EventName(),
+		newTransport: func() any {
+			return PE(new(E))
+		},
+		toTransport: func(event esja.Event[T]) (any, bool) {
+			e, ok := event.(PE)
+			return e, ok
+		},
+		fromTransport: func(transportEvent any) (esja.Event[T], bool) {
+			e, ok := transportEvent.(PE)
+			if !ok {
+				return nil, false
+			}
+			return e, true
+		},
+	}
+}
+
+// RegisterMapped registers the event E mapped to the transport model TE, like in DefaultMapper.
+// The event name is taken from the zero value of E.
+//
+// The pointer type of TE is inferred, so it's enough to call it as RegisterMapped[Entity, Event, TransportEvent]().
+func RegisterMapped[T any, E esja.Event[T], TE any, PTE MappedEvent[T, E, TE]]() Registration[T] {
+	var event E
+
+	eventName, err := zeroEventName[T](event)
+
+	return Registration[T]{
+		eventName: eventName,
+		newTransport: func() any {
+			return PTE(new(TE))
+		},
+		toTransport: func(event esja.Event[T]) (any, bool) {
+			e, ok := event.(E)
+			if !ok {
+				return nil, false
+			}
+
+			transportEvent := PTE(new(TE))
+			transportEvent.FromEvent(e)
+
+			return transportEvent, true
+		},
+		fromTransport: func(transportEvent any) (esja.Event[T], bool) {
+			e, ok := transportEvent.(PTE)
+			if !ok {
+				return nil, false
+			}
+			return e.ToEvent(), true
+		},
+		err: err,
+	}
+}
+
+// zeroEventName returns the name of the event's zero value,
+// or an error if it can't be called, like for a nil pointer with a value receiver.
+func zeroEventName[T any](event esja.Event[T]) (eventName string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event %T can't be registered with its zero value, use RegisterPointer for pointer events", event)
+		}
+	}()
+
+	return event.EventName(), nil
+}
+
+// Registry implements transport.Mapper using events registered with Register, RegisterPointer and RegisterMapped.
+// Unlike NoOpMapper and DefaultMapper, it doesn't use reflection: transport models are created
+// with the registered types, and transport models are matched to their events with type assertions.
+type Registry[T any] struct {
+	registrations map[string]Registration[T]
+	// ordered keeps the registrations in the order of registering, so they're matched deterministically.
+	ordered []Registration[T]
+	err     error
+}
+
+// NewRegistry returns a new instance of Registry.
+// Duplicated event names, transport models shared by events and events that can't be registered
+// are reported by Validate.
+func NewRegistry[T any](registrations ...Registration[T]) *Registry[T] {
+	r := &Registry[T]{
+		registrations: map[string]Registration[T]{},
+	}
+	problems := registrationProblems{}
+
+	for _, registration := range registrations {
+		if registration.err != nil {
+			problems.add("%s", registration.err)
+			continue
+		}
+
+		if _, ok := r.registrations[registration.eventName]; ok {
+			problems.add("event name '%s' is registered twice", registration.eventName)
+			continue
+		}
+
+		if other, ok := r.sharedTransport(registration); ok {
+			problems.add(
+				"transport model %T is registered for both '%s' and '%s'",
+				registration.newTransport(),
+				other.eventName,
+				registration.eventName,
+			)
+			continue
+		}
+
+		r.registrations[registration.eventName] = registration
+		r.ordered = append(r.ordered, registration)
+	}
+
+	r.err = problems.err()
+
+	return r
+}
+
+// sharedTransport returns the registered event using the same transport model as the registration.
+func (r *Registry[T]) sharedTransport(registration Registration[T]) (Registration[T], bool) {
+	transportEvent := registration.newTransport()
+
+	for _, other := range r.ordered {
+		if _, ok := other.fromTransport(transportEvent); ok {
+			return other, true
+		}
+		if _, ok := registration.fromTransport(other.newTransport()); ok {
+			return other, true
+		}
+	}
+
+	return Registration[T]{}, false
+}
+
+// Validate returns an error if the registered events are invalid.
+func (r *Registry[T]) Validate() error {
+	return r.err
+}
+
+// SupportedEvents returns the sorted names of registered events.
+func (r *Registry[T]) SupportedEvents() []string {
+	return sortedNames(r.registrations)
+}
+
+func (r *Registry[T]) New(eventName string) (any, error) {
+	registration, ok := r.registrations[eventName]
+	if !ok {
+		return nil, fmt.Errorf("unsupported event of name '%s'", eventName)
+	}
+
+	return registration.newTransport(), nil
+}
+
+func (r *Registry[T]) ToTransport(
+	_ context.Context,
+	_ string,
+	event esja.Event[T],
+) (any, error) {
+	registration, ok := r.registrations[event.EventName()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported event of name '%s'", event.EventName())
+	}
+
+	transportEvent, ok := registration.toTransport(event)
+	if !ok {
+		return nil, fmt.Errorf("event %T of name '%s' is not of the registered type", event, event.EventName())
+	}
+
+	return transportEvent, nil
+}
+
+func (r *Registry[T]) FromTransport(
+	_ context.Context,
+	_ string,
+	transportEvent any,
+) (esja.Event[T], error) {
+	for _, registration := range r.ordered {
+		if event, ok := registration.fromTransport(transportEvent); ok {
+			return event, nil
+		}
+	}
+
+	return nil, fmt.Errorf("transport event %T is not registered", transportEvent)
+}
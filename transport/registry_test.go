@@ -0,0 +1,146 @@
+package transport_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+type renamed struct {
+	Name string
+}
+
+func (renamed) EventName() string {
+	return "Renamed_v1"
+}
+
+func (renamed) ApplyTo(*entity) error {
+	return nil
+}
+
+type renamedTransport struct {
+	NewName string `json:"new_name"`
+}
+
+func (t *renamedTransport) FromEvent(event renamed) {
+	t.NewName = event.Name
+}
+
+func (t *renamedTransport) ToEvent() renamed {
+	return renamed{Name: t.NewName}
+}
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+
+	registry := transport.NewRegistry(
+		transport.Register[entity, created](),
+		transport.RegisterMapped[entity, renamed, renamedTransport](),
+	)
+	require.NoError(t, registry.Validate())
+	assert.Equal(t, []string{"Created_v1", "Renamed_v1"}, registry.SupportedEvents())
+
+	marshaler := transport.JSONMarshaler{}
+
+	transportEvent, err := registry.ToTransport(ctx, "id", renamed{Name: "name"})
+	require.NoError(t, err)
+	assert.Equal(t, &renamedTransport{NewName: "name"}, transportEvent)
+
+	payload, err := marshaler.Marshal(transportEvent)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"new_name": "name"}`, string(payload))
+
+	newEvent, err := registry.New("Renamed_v1")
+	require.NoError(t, err)
+	err = marshaler.Unmarshal(payload, newEvent)
+	require.NoError(t, err)
+
+	event, err := registry.FromTransport(ctx, "id", newEvent)
+	require.NoError(t, err)
+	assert.Equal(t, renamed{Name: "name"}, event)
+
+	newEvent, err = registry.New("Created_v1")
+	require.NoError(t, err)
+
+	event, err = registry.FromTransport(ctx, "id", newEvent)
+	require.NoError(t, err)
+	assert.Equal(t, created{}, event, "events should be loaded as values")
+
+	_, err = registry.New("Updated_v1")
+	assert.Error(t, err)
+
+	_, err = registry.ToTransport(ctx, "id", createdAgain{})
+	assert.Error(t, err, "event of other type with the registered name should not be mapped")
+}
+
+func TestRegistry_duplicates(t *testing.T) {
+	registry := transport.NewRegistry(
+		transport.Register[entity, created](),
+		transport.Register[entity, createdAgain](),
+	)
+
+	assert.ErrorContains(t, registry.Validate(), "event name 'Created_v1' is registered twice")
+}
+
+func TestRegistry_pointer_events(t *testing.T) {
+	ctx := context.Background()
+
+	registry := transport.NewRegistry(
+		transport.RegisterPointer[entity, created](),
+	)
+	require.NoError(t, registry.Validate())
+	assert.Equal(t, []string{"Created_v1"}, registry.SupportedEvents())
+
+	transportEvent, err := registry.ToTransport(ctx, "id", &created{})
+	require.NoError(t, err)
+	assert.Equal(t, &created{}, transportEvent)
+
+	newEvent, err := registry.New("Created_v1")
+	require.NoError(t, err)
+
+	event, err := registry.FromTransport(ctx, "id", newEvent)
+	require.NoError(t, err)
+	assert.Equal(t, &created{}, event)
+
+	_, err = registry.ToTransport(ctx, "id", created{})
+	assert.Error(t, err, "value of the pointer event should not be mapped")
+
+	// The zero value of a pointer event is nil, so its name can't be read with a value receiver.
+	registry = transport.NewRegistry(
+		transport.Register[entity, *created](),
+	)
+	assert.ErrorContains(t, registry.Validate(), "use RegisterPointer for pointer events")
+}
+
+type upgraded struct{}
+
+func (upgraded) EventName() string {
+	return "Upgraded_v1"
+}
+
+func (upgraded) ApplyTo(*entity) error {
+	return nil
+}
+
+func (*renamed) FromEvent(upgraded) {}
+
+func (*renamed) ToEvent() upgraded {
+	return upgraded{}
+}
+
+func TestRegistry_shared_transport_model(t *testing.T) {
+	registry := transport.NewRegistry(
+		transport.Register[entity, renamed](),
+		transport.RegisterMapped[entity, upgraded, renamed](),
+	)
+
+	assert.ErrorContains(
+		t,
+		registry.Validate(),
+		"transport model *transport_test.renamed is registered for both 'Renamed_v1' and 'Upgraded_v1'",
+	)
+}
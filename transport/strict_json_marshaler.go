@@ -0,0 +1,258 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrMissingRequiredField is returned by StrictJSONMarshaler for payloads missing a required field.
+var ErrMissingRequiredField = errors.New("missing required field")
+
+// ErrUnknownField is returned by StrictJSONMarshaler for payloads with a field missing in the event.
+var ErrUnknownField = errors.New("unknown field")
+
+// StrictJSONMarshaler marshals events to JSON like JSONMarshaler,
+// but can decode them with strict checks, to detect the drift between event schemas.
+// The zero value decodes events the same way as JSONMarshaler.
+type StrictJSONMarshaler struct {
+	// DisallowUnknownFields fails decoding payloads with fields missing in the event.
+	DisallowUnknownFields bool
+
+	// UseNumber decodes numbers into interface{} values as json.Number instead of float64.
+	UseNumber bool
+
+	// RequiredFields fails decoding payloads missing the fields tagged with `required:"true"`,
+	// or having them set to null. Nested structs, slices and maps of structs are checked too.
+	RequiredFields bool
+}
+
+// UnmarshalError is returned by StrictJSONMarshaler when the payload doesn't match the event.
+type UnmarshalError struct {
+	// Event is the type of the event the payload is decoded into.
+	Event string
+
+	// Field is the path of the field in the payload, like "sender.name". Empty if it's not known.
+	Field string
+
+	Err error
+}
+
+func (e UnmarshalError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %v", e.Event, e.Err)
+	}
+	return fmt.Sprintf("%s: field %s: %v", e.Event, e.Field, e.Err)
+}
+
+func (e UnmarshalError) Unwrap() error {
+	return e.Err
+}
+
+func (StrictJSONMarshaler) Marshal(data interface{}) ([]byte, error) {
+	return json.Marshal(data)
+}
+
+func (m StrictJSONMarshaler) Unmarshal(data []byte, target interface{}) error {
+	event := fmt.Sprintf("%T", target)
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if m.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if m.UseNumber {
+		decoder.UseNumber()
+	}
+
+	err := decoder.Decode(target)
+	if err != nil {
+		return decodingError(event, err)
+	}
+
+	_, err = decoder.Token()
+	if !errors.Is(err, io.EOF) {
+		return UnmarshalError{
+			Event: event,
+			Err:   errors.New("unexpected data after the payload"),
+		}
+	}
+
+	if !m.RequiredFields {
+		return nil
+	}
+
+	return checkRequiredFields(event, reflect.TypeOf(target), data, "")
+}
+
+func decodingError(event string, err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return UnmarshalError{
+			Event: event,
+			Field: typeErr.Field,
+			Err:   err,
+		}
+	}
+
+	// The decoder doesn't expose the unknown field with a dedicated error type.
+	const unknownFieldPrefix = `json: unknown field "`
+	if msg := err.Error(); strings.HasPrefix(msg, unknownFieldPrefix) {
+		return UnmarshalError{
+			Event: event,
+			Field: strings.TrimSuffix(strings.TrimPrefix(msg, unknownFieldPrefix), `"`),
+			Err:   ErrUnknownField,
+		}
+	}
+
+	return UnmarshalError{
+		Event: event,
+		Err:   err,
+	}
+}
+
+// checkRequiredFields checks if the payload has all required fields of the type.
+func checkRequiredFields(event string, t reflect.Type, data json.RawMessage, path string) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if isNull(data) {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(data, &fields) != nil {
+			return nil
+		}
+		return checkStructFields(event, t, fields, path)
+	case reflect.Slice, reflect.Array:
+		var elements []json.RawMessage
+		if json.Unmarshal(data, &elements) != nil {
+			return nil
+		}
+		for i, element := range elements {
+			err := checkRequiredFields(event, t.Elem(), element, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		var values map[string]json.RawMessage
+		if json.Unmarshal(data, &values) != nil {
+			return nil
+		}
+		for _, key := range sortedKeys(values) {
+			err := checkRequiredFields(event, t.Elem(), values[key], fieldPath(path, key))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkStructFields(event string, t reflect.Type, fields map[string]json.RawMessage, path string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		// Fields of embedded structs without a JSON name are decoded from the same object.
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			err := checkStructFields(event, fieldType, fields, path)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		value, present := lookupField(fields, name)
+		if field.Tag.Get("required") == "true" && (!present || isNull(value)) {
+			return UnmarshalError{
+				Event: event,
+				Field: fieldPath(path, name),
+				Err:   ErrMissingRequiredField,
+			}
+		}
+
+		if present {
+			err := checkRequiredFields(event, field.Type, value, fieldPath(path, name))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// jsonFieldName returns the name of the field from the json tag.
+// It returns false if the field is not decoded.
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() && !field.Anonymous {
+		return "", false
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	return name, true
+}
+
+// lookupField finds the field like encoding/json does, preferring the exact match.
+func lookupField(fields map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	if value, ok := fields[name]; ok {
+		return value, true
+	}
+
+	for key, value := range fields {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+func isNull(data json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
+
+func fieldPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func sortedKeys(values map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
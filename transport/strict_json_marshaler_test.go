@@ -0,0 +1,187 @@
+package transport_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+type strictAddress struct {
+	Name  string `json:"name" required:"true"`
+	Line1 string `json:"line1"`
+}
+
+type strictMetadata struct {
+	Source string `json:"source" required:"true"`
+}
+
+type strictAddressed struct {
+	strictMetadata
+
+	ID         string          `json:"id" required:"true"`
+	Sender     strictAddress   `json:"sender" required:"true"`
+	Addressees []strictAddress `json:"addressees"`
+	Note       *strictAddress  `json:"note"`
+	Extra      interface{}     `json:"extra"`
+}
+
+func TestStrictJSONMarshaler(t *testing.T) {
+	valid := `{
+		"source": "test",
+		"id": "1",
+		"sender": {"name": "Alice", "line1": "Street"},
+		"addressees": [{"name": "Bob"}],
+		"extra": 12345678901234567890
+	}`
+
+	testCases := []struct {
+		name          string
+		marshaler     transport.StrictJSONMarshaler
+		payload       string
+		expectedField string
+		expectedError string
+	}{
+		{
+			name:      "zero_value_accepts_drift",
+			marshaler: transport.StrictJSONMarshaler{},
+			payload:   `{"id": "1", "unknown": true}`,
+		},
+		{
+			name:      "valid",
+			marshaler: strictMarshaler(),
+			payload:   valid,
+		},
+		{
+			name:          "unknown_field",
+			marshaler:     strictMarshaler(),
+			payload:       `{"source": "test", "id": "1", "sender": {"name": "Alice"}, "removed": 1}`,
+			expectedField: "removed",
+			expectedError: "*transport_test.strictAddressed: field removed: unknown field",
+		},
+		{
+			name:          "missing_required_field",
+			marshaler:     strictMarshaler(),
+			payload:       `{"source": "test", "sender": {"name": "Alice"}}`,
+			expectedField: "id",
+			expectedError: "*transport_test.strictAddressed: field id: missing required field",
+		},
+		{
+			name:          "null_required_field",
+			marshaler:     strictMarshaler(),
+			payload:       `{"source": "test", "id": null, "sender": {"name": "Alice"}}`,
+			expectedField: "id",
+			expectedError: "*transport_test.strictAddressed: field id: missing required field",
+		},
+		{
+			name:          "missing_embedded_required_field",
+			marshaler:     strictMarshaler(),
+			payload:       `{"id": "1", "sender": {"name": "Alice"}}`,
+			expectedField: "source",
+			expectedError: "*transport_test.strictAddressed: field source: missing required field",
+		},
+		{
+			name:          "missing_nested_required_field",
+			marshaler:     strictMarshaler(),
+			payload:       `{"source": "test", "id": "1", "sender": {"line1": "Street"}}`,
+			expectedField: "sender.name",
+			expectedError: "*transport_test.strictAddressed: field sender.name: missing required field",
+		},
+		{
+			name:          "missing_required_field_in_slice",
+			marshaler:     strictMarshaler(),
+			payload:       `{"source": "test", "id": "1", "sender": {"name": "Alice"}, "addressees": [{"name": "Bob"}, {}]}`,
+			expectedField: "addressees[1].name",
+			expectedError: "*transport_test.strictAddressed: field addressees[1].name: missing required field",
+		},
+		{
+			name:          "missing_required_field_in_pointer",
+			marshaler:     strictMarshaler(),
+			payload:       `{"source": "test", "id": "1", "sender": {"name": "Alice"}, "note": {"line1": "Street"}}`,
+			expectedField: "note.name",
+			expectedError: "*transport_test.strictAddressed: field note.name: missing required field",
+		},
+		{
+			name:      "missing_optional_pointer",
+			marshaler: strictMarshaler(),
+			payload:   `{"source": "test", "id": "1", "sender": {"name": "Alice"}, "note": null}`,
+		},
+		{
+			name:          "invalid_type",
+			marshaler:     strictMarshaler(),
+			payload:       `{"source": "test", "id": 1, "sender": {"name": "Alice"}}`,
+			expectedField: "id",
+			expectedError: "*transport_test.strictAddressed: field id: json: cannot unmarshal number",
+		},
+		{
+			name:          "trailing_data",
+			marshaler:     strictMarshaler(),
+			payload:       `{"source": "test", "id": "1", "sender": {"name": "Alice"}} {}`,
+			expectedError: "*transport_test.strictAddressed: unexpected data after the payload",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			event := strictAddressed{}
+			err := tc.marshaler.Unmarshal([]byte(tc.payload), &event)
+
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			assert.ErrorContains(t, err, tc.expectedError)
+
+			var unmarshalErr transport.UnmarshalError
+			require.ErrorAs(t, err, &unmarshalErr)
+			assert.Equal(t, "*transport_test.strictAddressed", unmarshalErr.Event)
+			assert.Equal(t, tc.expectedField, unmarshalErr.Field)
+		})
+	}
+}
+
+func TestStrictJSONMarshaler_UseNumber(t *testing.T) {
+	payload := []byte(`{"source": "test", "id": "1", "sender": {"name": "Alice"}, "extra": 12345678901234567890}`)
+
+	event := strictAddressed{}
+	err := transport.StrictJSONMarshaler{UseNumber: true}.Unmarshal(payload, &event)
+	require.NoError(t, err)
+	assert.Equal(t, json.Number("12345678901234567890"), event.Extra)
+
+	event = strictAddressed{}
+	err = transport.StrictJSONMarshaler{}.Unmarshal(payload, &event)
+	require.NoError(t, err)
+	assert.IsType(t, float64(0), event.Extra)
+}
+
+func TestStrictJSONMarshaler_round_trip(t *testing.T) {
+	marshaler := strictMarshaler()
+
+	event := strictAddressed{
+		strictMetadata: strictMetadata{Source: "test"},
+		ID:             "1",
+		Sender:         strictAddress{Name: "Alice"},
+	}
+
+	payload, err := marshaler.Marshal(&event)
+	require.NoError(t, err)
+
+	decoded := strictAddressed{}
+	err = marshaler.Unmarshal(payload, &decoded)
+	require.NoError(t, err)
+
+	assert.Equal(t, event, decoded)
+}
+
+func strictMarshaler() transport.StrictJSONMarshaler {
+	return transport.StrictJSONMarshaler{
+		DisallowUnknownFields: true,
+		UseNumber:             true,
+		RequiredFields:        true,
+	}
+}
@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"reflect"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// timesToUTC converts the time values reachable from v to UTC.
+// Only exported struct fields are converted, as the others aren't decoded.
+func timesToUTC(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			timesToUTC(v.Elem())
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+
+		elem := v.Elem()
+		if elem.Type() == timeType && v.CanSet() {
+			v.Set(reflect.ValueOf(elem.Interface().(time.Time).UTC()))
+			return
+		}
+
+		timesToUTC(elem)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			if v.CanSet() {
+				v.Set(reflect.ValueOf(v.Interface().(time.Time).UTC()))
+			}
+			return
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				timesToUTC(v.Field(i))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			timesToUTC(v.Index(i))
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+
+		iter := v.MapRange()
+		for iter.Next() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(iter.Value())
+
+			timesToUTC(value)
+			v.SetMapIndex(iter.Key(), value)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package transport
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ThreeDotsLabs/esja"
+)
+
+// ValidateMapper returns the error of the mapper's Validate method, if it has one.
+// NoOpMapper and DefaultMapper report invalid supported events this way.
+func ValidateMapper[T any](mapper Mapper[T]) error {
+	v, ok := mapper.(interface{ Validate() error })
+	if !ok {
+		return nil
+	}
+
+	return v.Validate()
+}
+
+// SupportedEvents returns the names of events supported by the mapper,
+// or nil if the mapper doesn't have the SupportedEvents method.
+func SupportedEvents[T any](mapper Mapper[T]) []string {
+	l, ok := mapper.(interface{ SupportedEvents() []string })
+	if !ok {
+		return nil
+	}
+
+	return l.SupportedEvents()
+}
+
+// ValidateEvents validates the mapper and checks that all events are supported by it.
+// It's meant to be called on startup with all events the entity records.
+func ValidateEvents[T any](mapper Mapper[T], events ...esja.Event[T]) error {
+	err := ValidateMapper(mapper)
+	if err != nil {
+		return err
+	}
+
+	var unsupported []string
+	for _, e := range events {
+		_, err := mapper.New(e.EventName())
+		if err != nil {
+			unsupported = append(unsupported, e.EventName())
+		}
+	}
+
+	if len(unsupported) > 0 {
+		return fmt.Errorf("events not supported by the mapper: %s", strings.Join(unsupported, ", "))
+	}
+
+	return nil
+}
+
+// registrationProblems collects problems found while registering supported events.
+type registrationProblems []string
+
+func (p *registrationProblems) add(format string, args ...any) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+func (p registrationProblems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("invalid supported events: %s", strings.Join(p, "; "))
+}
+
+// prototype replaces the typed nil pointer with a pointer to a zero value,
+// so the methods of the registered event can be called.
+func prototype[E any](e E) E {
+	v := reflect.ValueOf(e)
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return reflect.New(v.Type().Elem()).Interface().(E)
+	}
+
+	return e
+}
+
+func sortedNames[E any](supported map[string]E) []string {
+	names := make([]string, 0, len(supported))
+	for name := range supported {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
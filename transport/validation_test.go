@@ -0,0 +1,163 @@
+package transport_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ThreeDotsLabs/esja"
+	"github.com/ThreeDotsLabs/esja/transport"
+)
+
+type entity struct{}
+
+type created struct{}
+
+func (created) EventName() string {
+	return "Created_v1"
+}
+
+func (created) ApplyTo(*entity) error {
+	return nil
+}
+
+type createdAgain struct{}
+
+func (createdAgain) EventName() string {
+	return "Created_v1"
+}
+
+func (createdAgain) ApplyTo(*entity) error {
+	return nil
+}
+
+type updated struct{}
+
+func (updated) EventName() string {
+	return "Updated_v1"
+}
+
+func (updated) ApplyTo(*entity) error {
+	return nil
+}
+
+type createdTransport struct{}
+
+func (createdTransport) StreamEventName() string {
+	return created{}.EventName()
+}
+
+func (createdTransport) FromStreamEvent(esja.Event[entity]) {}
+
+func (createdTransport) ToStreamEvent() esja.Event[entity] {
+	return created{}
+}
+
+func TestNoOpMapper_Validate(t *testing.T) {
+	mapper := transport.NewNoOpMapper([]esja.Event[entity]{
+		updated{},
+		created{},
+	})
+	assert.NoError(t, mapper.Validate())
+	assert.Equal(t, []string{"Created_v1", "Updated_v1"}, mapper.SupportedEvents())
+
+	mapper = transport.NewNoOpMapper([]esja.Event[entity]{
+		created{},
+		createdAgain{},
+		nil,
+	})
+
+	err := mapper.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "event name 'Created_v1' is registered by both transport_test.created and transport_test.createdAgain")
+	assert.Contains(t, err.Error(), "event at index 2 is nil")
+}
+
+func TestDefaultMapper_Validate(t *testing.T) {
+	mapper := transport.NewDefaultMapper([]transport.Event[entity]{
+		&createdTransport{},
+	})
+	assert.NoError(t, mapper.Validate())
+	assert.Equal(t, []string{"Created_v1"}, mapper.SupportedEvents())
+
+	mapper = transport.NewDefaultMapper([]transport.Event[entity]{
+		createdTransport{},
+		nil,
+	})
+
+	err := mapper.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "transport event transport_test.createdTransport is not a pointer")
+	assert.Contains(t, err.Error(), "event at index 1 is nil")
+}
+
+func TestNewValidatedDefaultMapper(t *testing.T) {
+	// Typed nil pointers are valid prototypes.
+	mapper, err := transport.NewValidatedDefaultMapper([]transport.Event[entity]{
+		(*createdTransport)(nil),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Created_v1"}, mapper.SupportedEvents())
+
+	e, err := mapper.New("Created_v1")
+	require.NoError(t, err)
+	assert.IsType(t, &createdTransport{}, e)
+
+	_, err = transport.NewValidatedDefaultMapper([]transport.Event[entity]{
+		&createdTransport{},
+		createdTransport{},
+	})
+	assert.ErrorContains(t, err, "transport event transport_test.createdTransport is not a pointer")
+}
+
+func TestNewValidatedNoOpMapper(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := transport.NewValidatedNoOpMapper([]esja.Event[entity]{
+		created{},
+		createdAgain{},
+	})
+	assert.ErrorContains(t, err, "event name 'Created_v1' is registered by both")
+
+	// Pointer events and typed nil pointers are loaded as pointers.
+	mapper, err := transport.NewValidatedNoOpMapper([]esja.Event[entity]{
+		&created{},
+		(*updated)(nil),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Created_v1", "Updated_v1"}, mapper.SupportedEvents())
+
+	for _, name := range mapper.SupportedEvents() {
+		e, err := mapper.New(name)
+		require.NoError(t, err)
+
+		err = transport.JSONMarshaler{}.Unmarshal([]byte("{}"), e)
+		require.NoError(t, err)
+
+		event, err := mapper.FromTransport(ctx, "stream", e)
+		require.NoError(t, err)
+		assert.Equal(t, name, event.EventName())
+	}
+}
+
+func TestValidateEvents(t *testing.T) {
+	mapper := transport.NewNoOpMapper([]esja.Event[entity]{
+		created{},
+	})
+
+	err := transport.ValidateEvents[entity](mapper, created{})
+	assert.NoError(t, err)
+
+	err = transport.ValidateEvents[entity](mapper, created{}, updated{})
+	assert.EqualError(t, err, "events not supported by the mapper: Updated_v1")
+
+	anonymizer := transport.NewAnonymizer[entity](transport.NewNoOpMapper([]esja.Event[entity]{
+		created{},
+		createdAgain{},
+	}), nil)
+
+	err = transport.ValidateEvents[entity](anonymizer, created{})
+	assert.Error(t, err, "wrapped mapper should be validated")
+}